@@ -22,6 +22,49 @@
 
 	grnc-bind will need to be re-run whenever a component definition file is modified.
 
+	Factories
+
+	Instead of a type, a component definition may declare a factory that will build the component's instance. The factory
+	can either be a reference to another component implementing ioc.Factory or the package-qualified name of a function
+	matching ioc.FactoryFunc:
+
+		"inventoryDB": {
+		  "factory": "ref:dbFactory",
+		  "DataSource": "conf:Inventory.DataSource"
+		},
+		"auditDB": {
+		  "factory": "db.OpenConnection",
+		  "DataSource": "conf:Audit.DataSource"
+		}
+
+	The remaining references, configuration promises and values in the definition are passed to the factory as inputs.
+	Object values are not supported as factory inputs.
+
+	Scopes
+
+	A component definition may include a scope of singleton (the default), prototype or request:
+
+		"unitOfWork": {
+		  "type": "inventory.UnitOfWork",
+		  "scope": "request"
+		}
+
+	Components built by factories must be singletons. See the ioc package documentation for more information on scopes.
+
+	Conditional components
+
+	A component definition may include one or more conditions (a string or an array of strings) that must be met for the
+	component to be created, and an alternative name to register the component under:
+
+		"loggingMailSender": {
+		  "type": "mail.LoggingSender",
+		  "if": ["profile:dev", "!conf:Mail.ForceSMTP"],
+		  "as": "mailSender"
+		}
+
+	Conditions are evaluated by the container when your application starts. See the ioc package documentation for the
+	supported conditions.
+
 	Usage of grnc-bind:
 
 		grnc-bind [-c component-files] [-m merged-file-out] [-o generated-file]
@@ -63,6 +106,10 @@ const (
 	templateFieldAlias = "ct"
 	typeField          = "type"
 	typeFieldAlias     = "t"
+	factoryField       = "factory"
+	scopeField         = "scope"
+	conditionField     = "if"
+	registerAsField    = "as"
 
 	protoSuffix = "Proto"
 	modsSuffix  = "Mods"
@@ -73,6 +120,7 @@ const (
 	protoArrayVar              = "protoComponents"
 	modifierVar                = "frameworkModifiers"
 	serialisedVar              = "ser"
+	serialisedSchemaVar        = "sch"
 	confLocationFlag    string = "c"
 	confLocationDefault string = "resource/components"
 	confLocationHelp    string = "A comma separated list of component definition files or directories containing component definition files"
@@ -87,6 +135,10 @@ const (
 
 	newline = "\n"
 
+	singletonScope = "singleton"
+	prototypeScope = "prototype"
+	requestScope   = "request"
+
 	refPrefix  = "ref:"
 	refAlias   = "r:"
 	confPrefix = "conf:"
@@ -115,10 +167,11 @@ func main() {
 
 }
 
-func serialiseBuiltinConfig() string {
+// Merges and serialises the JSON files in the supplied sub-directory of Granitic's resource directory.
+func serialiseBuiltinConfig(dir string) string {
 	gh := config.GraniticHome()
 
-	ghr := path.Join(gh, "resource", "facility-config")
+	ghr := path.Join(gh, "resource", dir)
 
 	if fcf, err := config.FindConfigFilesInDir(ghr); err != nil {
 		fmt.Printf("%s does not seem to contain a valid Granitic installation. Check your %s and/or %s environment variables\n", gh, "GRANITIC_HOME", "GOPATH")
@@ -127,11 +180,12 @@ func serialiseBuiltinConfig() string {
 
 		jm := new(config.JsonMerger)
 		jm.MergeArrays = true
+		jm.SkipPlaceholders = true
 		jm.Logger = new(logging.ConsoleErrorLogger)
 
 		if mc, err := jm.LoadAndMergeConfig(fcf); err != nil {
 
-			fmt.Printf("Problem serialising Granitic's built-in config files: %s\n", err.Error())
+			fmt.Printf("Problem serialising Granitic's built-in files in %s: %s\n", ghr, err.Error())
 			instance.ExitError()
 
 		} else {
@@ -143,7 +197,7 @@ func serialiseBuiltinConfig() string {
 			gob.Register([]interface{}{})
 
 			if err := e.Encode(mc); err != nil {
-				fmt.Printf("Problem serialising Granitic's built-in config files: %s\n", err.Error())
+				fmt.Printf("Problem serialising Granitic's built-in files in %s: %s\n", ghr, err.Error())
 				instance.ExitError()
 			}
 
@@ -233,14 +287,26 @@ func writeComponent(w *bufio.Writer, name string, component map[string]interface
 	confPromises := make(map[string]interface{})
 
 	mergeValueSources(component, templates)
+
+	if component[factoryField] != nil {
+		writeFactoryComponent(w, name, component, index)
+		return
+	}
+
 	validateHasTypeField(component, name)
 
 	writeComponentNameComment(w, name, baseIdent)
 	writeInstanceVar(w, name, component[typeField].(string), baseIdent)
-	writeProto(w, name, index, baseIdent)
+	writeProto(w, name, registeredName(name, component), index, baseIdent)
+	writeScope(w, name, component, baseIdent)
+	writeConditions(w, name, component, baseIdent)
 
 	for field, value := range component {
 
+		if reservedFieldName(field) {
+			continue
+		}
+
 		if isPromise(value) {
 			confPromises[field] = value
 
@@ -262,6 +328,174 @@ func writeComponent(w *bufio.Writer, name string, component map[string]interface
 
 }
 
+func writeFactoryComponent(w *bufio.Writer, name string, component map[string]interface{}, index int) {
+	baseIdent := 1
+
+	values := make(map[string]interface{})
+	refs := make(map[string]interface{})
+	confPromises := make(map[string]interface{})
+
+	f, found := component[factoryField].(string)
+
+	if !found || f == "" {
+		m := fmt.Sprintf("Component %s has a '%s' field defined but the value of the field is not a string.\n", name, factoryField)
+		exitError(m)
+	}
+
+	if component[typeField] != nil {
+		m := fmt.Sprintf("Component %s has both a '%s' and a '%s' defined. Only one is allowed.\n", name, typeField, factoryField)
+		exitError(m)
+	}
+
+	if sc := component[scopeField]; sc != nil && sc != singletonScope {
+		m := fmt.Sprintf("Component %s is built by a factory so must have a %s of %s.\n", name, scopeField, singletonScope)
+		exitError(m)
+	}
+
+	writeComponentNameComment(w, name, baseIdent)
+	writeFactoryProto(w, name, registeredName(name, component), f, index, baseIdent)
+	writeConditions(w, name, component, baseIdent)
+
+	for field, value := range component {
+
+		if reservedFieldName(field) {
+			continue
+		}
+
+		if isPromise(value) {
+			confPromises[field] = value
+
+		} else if isRef(value) {
+			refs[field] = value
+
+		} else {
+			values[field] = value
+		}
+
+	}
+
+	writeFactoryValues(w, name, values, baseIdent)
+	writeDeferred(w, name, confPromises, baseIdent, "AddConfigPromise")
+	writeDeferred(w, name, refs, baseIdent, "AddDependency")
+
+	w.WriteString(newline)
+	w.WriteString(newline)
+}
+
+func writeScope(w *bufio.Writer, n string, component map[string]interface{}, tabs int) {
+
+	v := component[scopeField]
+
+	if v == nil {
+		return
+	}
+
+	sc, found := v.(string)
+
+	if !found || (sc != singletonScope && sc != prototypeScope && sc != requestScope) {
+		m := fmt.Sprintf("Component %s has an invalid %s. Valid values are %s, %s and %s.\n", n, scopeField, singletonScope, prototypeScope, requestScope)
+		exitError(m)
+	}
+
+	if sc == singletonScope {
+		return
+	}
+
+	s := fmt.Sprintf("%s.Scope = %s\n", protoName(n), quoteString(sc))
+	w.WriteString(tabIndent(s, tabs))
+}
+
+func writeConditions(w *bufio.Writer, n string, component map[string]interface{}, tabs int) {
+
+	v := component[conditionField]
+
+	if v == nil {
+		return
+	}
+
+	var conditions []interface{}
+
+	if a, found := v.([]interface{}); found {
+		conditions = a
+	} else {
+		conditions = []interface{}{v}
+	}
+
+	for _, c := range conditions {
+
+		cs, found := c.(string)
+
+		if !found || cs == "" {
+			m := fmt.Sprintf("Component %s has an invalid '%s'. Conditions must be a string or an array of strings.\n", n, conditionField)
+			exitError(m)
+		}
+
+		s := fmt.Sprintf("%s.AddCondition(%s)\n", protoName(n), quoteString(cs))
+		w.WriteString(tabIndent(s, tabs))
+	}
+}
+
+// registeredName returns the name a component will be registered with in the container - the value of its 'as' field
+// if set, otherwise the name of its definition.
+func registeredName(n string, component map[string]interface{}) string {
+
+	v := component[registerAsField]
+
+	if v == nil {
+		return n
+	}
+
+	as, found := v.(string)
+
+	if !found || as == "" {
+		m := fmt.Sprintf("Component %s has an invalid '%s'. The value must be a non-empty string.\n", n, registerAsField)
+		exitError(m)
+	}
+
+	return as
+}
+
+func writeFactoryProto(w *bufio.Writer, n string, rn string, f string, index int, tabs int) {
+
+	p := protoName(n)
+
+	var s string
+
+	if isRef(f) {
+		fc := strings.SplitN(f, ":", 2)[1]
+		s = fmt.Sprintf("%s := ioc.CreateFactoryRefProtoComponent(%s, %s)\n", p, quoteString(fc), quoteString(rn))
+	} else {
+		s = fmt.Sprintf("%s := ioc.CreateFactoryProtoComponent(%s, %s)\n", p, f, quoteString(rn))
+	}
+
+	w.WriteString(tabIndent(s, tabs))
+	s = fmt.Sprintf("%s[%d] = %s\n", protoArrayVar, index, p)
+	w.WriteString(tabIndent(s, tabs))
+}
+
+func writeFactoryValues(w *bufio.Writer, cName string, values map[string]interface{}, tabs int) {
+
+	p := protoName(cName)
+
+	if len(values) > 0 {
+		w.WriteString(newline)
+	}
+
+	for k, v := range values {
+
+		if config.JsonType(v) == config.JsonMap {
+			m := fmt.Sprintf("Component %s is built by a factory and cannot have an object as the value of %s.\n", cName, k)
+			exitError(m)
+		}
+
+		init, _ := asGoInit(v)
+
+		s := fmt.Sprintf("%s.AddFactoryValue(%s, %s)\n", p, quoteString(k), init)
+		w.WriteString(tabIndent(s, tabs))
+	}
+
+}
+
 func writeValues(w *bufio.Writer, cName string, values map[string]interface{}, tabs int) {
 
 	if len(values) > 0 {
@@ -444,18 +678,19 @@ func writeInstanceVar(w *bufio.Writer, n string, ct string, tabs int) {
 	w.WriteString(tabIndent(s, tabs))
 }
 
-func writeProto(w *bufio.Writer, n string, index int, tabs int) {
+func writeProto(w *bufio.Writer, n string, rn string, index int, tabs int) {
 
 	p := protoName(n)
 
-	s := fmt.Sprintf("%s := ioc.CreateProtoComponent(%s, %s)\n", p, n, quoteString(n))
+	s := fmt.Sprintf("%s := ioc.CreateProtoComponent(%s, %s)\n", p, n, quoteString(rn))
 	w.WriteString(tabIndent(s, tabs))
 	s = fmt.Sprintf("%s[%d] = %s\n", protoArrayVar, index, p)
 	w.WriteString(tabIndent(s, tabs))
 }
 
 func writeEntryFunctionClose(w *bufio.Writer) {
-	a := fmt.Sprintf("\treturn ioc.NewProtoComponents(%s, %s, &%s)\n}\n", protoArrayVar, modifierVar, serialisedVar)
+	a := fmt.Sprintf("\tpc := ioc.NewProtoComponents(%s, %s, &%s)\n", protoArrayVar, modifierVar, serialisedVar)
+	a += fmt.Sprintf("\tpc.FrameworkSchema = &%s\n\n\treturn pc\n}\n", serialisedSchemaVar)
 	w.WriteString(a)
 }
 
@@ -486,7 +721,8 @@ func isRef(v interface{}) bool {
 }
 
 func reservedFieldName(f string) bool {
-	return f == templateField || f == templateFieldAlias || f == typeField || f == typeFieldAlias
+	return f == templateField || f == templateFieldAlias || f == typeField || f == typeFieldAlias || f == factoryField || f == scopeField ||
+		f == conditionField || f == registerAsField
 }
 
 func validateHasTypeField(v map[string]interface{}, name string) {
@@ -592,12 +828,18 @@ func parseTemplates(ca *config.ConfigAccessor) map[string]interface{} {
 
 func writeSerialisedConfig(w *bufio.Writer) {
 
-	sv := serialiseBuiltinConfig()
+	sv := serialiseBuiltinConfig("facility-config")
 
 	s := fmt.Sprintf("%s := \"%s\"\n", serialisedVar, sv)
 
 	w.WriteString(tabIndent(s, 1))
 
+	sv = serialiseBuiltinConfig("facility-schema")
+
+	s = fmt.Sprintf("%s := \"%s\"\n", serialisedSchemaVar, sv)
+
+	w.WriteString(tabIndent(s, 1))
+
 }
 
 func writeFrameworkModifiers(w *bufio.Writer, ca *config.ConfigAccessor) {
@@ -723,6 +965,7 @@ func loadConfig(l string) *config.ConfigAccessor {
 
 	jm := new(config.JsonMerger)
 	jm.MergeArrays = true
+	jm.SkipPlaceholders = true
 	jm.Logger = new(logging.ConsoleErrorLogger)
 
 	mc, err := jm.LoadAndMergeConfig(fl)
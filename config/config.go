@@ -81,6 +81,47 @@ would result in:
 
 Another core concept used by the types in this package is a config path. This is the absolute path to field in the
 eventual merged configuration file with a dot-delimited notation. E.g "database.host".
+
+The JsonMerger records which file or URL supplied each value in the merged configuration. Starting an application with the
+-e command line argument prints every value and its source (then exits) and the config runtime control command shows the
+same information for a running application. See EffectiveConfig.
+
+Configuration formats
+
+Configuration can also be written in YAML (files ending .yaml or .yml) or TOML (.toml). Files in any supported format can
+be mixed and are merged in the same way as JSON files. Files loaded from a URL are decoded according to the Content-Type
+returned by the server, falling back to the extension in the URL and then JSON. Additional formats can be supported by
+registering a ConfigDecoder with RegisterDecoder.
+
+Placeholders
+
+String values in configuration files can contain placeholders that are resolved when the files are loaded, allowing
+passwords and other secrets to be kept out of configuration files:
+
+	{
+		"database": {
+			"password": "${file:/run/secrets/db}",
+			"user": "${env:DB_USER}",
+			"port": "${env:DB_PORT:3306}"
+		}
+	}
+
+The application will fail to start if a placeholder cannot be resolved. Values resolved from placeholders are masked if
+configuration is displayed (e.g. with the config runtime control command). See PlaceholderStart for more details.
+
+Configuration schemas
+
+If System.ValidateConfig is set to true, the merged configuration is checked against schemas for Granitic's built-in
+facilities and any schemas supplied by the application (using the -s command line argument) when an application starts.
+The application will fail to start with a list of unknown keys, values of the wrong type and missing values if the
+configuration does not match. Validation is disabled by default. See SchemaType for the supported subset of JSON Schema.
+
+Reloading configuration
+
+If System.AllowConfigReload is set to true, configuration can be reloaded while the application is running by sending the
+application a SIGHUP signal or by using the reload runtime control command. The files and URLs used at startup are
+re-merged and compared with the previous configuration. Components implementing ConfigChangeListener are notified if
+any configuration promised to their fields has changed. See ConfigReloader for more details.
 */
 package config
 
@@ -92,6 +133,7 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 )
 
 // The character used to delimit paths to config values.
@@ -111,17 +153,40 @@ const (
 // A ConfigAccessor provides access to a merged view of configuration files during the initialisation and
 // configuration of the Granitic IoC container.
 type ConfigAccessor struct {
-	// The merged JSON configuration in object form.
+	// The merged JSON configuration in object form. Use CurrentJsonData and ReplaceJsonData if configuration may be
+	// reloaded while the application is running.
 	JsonData map[string]interface{}
 
 	// Logger used by Granitic framework components. Automatically injected.
 	FrameworkLogger logging.Logger
 }
 
+// Guards JsonData on all ConfigAccessors and the sources recorded by JsonMergers, which may be replaced by a
+// ConfigReloader while other goroutines are reading them.
+var jsonDataLock sync.RWMutex
+
 // Flush removes internal references to the (potentially very large) merged JSON data so the associated
 // memory can be recovered during the next garbage collection.
 func (c *ConfigAccessor) Flush() {
-	c.JsonData = nil
+	c.ReplaceJsonData(nil)
+}
+
+// CurrentJsonData returns the merged JSON configuration. Code that may run while configuration is being reloaded
+// should use this method rather than reading JsonData directly.
+func (c *ConfigAccessor) CurrentJsonData() map[string]interface{} {
+	jsonDataLock.RLock()
+	defer jsonDataLock.RUnlock()
+
+	return c.JsonData
+}
+
+// ReplaceJsonData replaces the merged JSON configuration in a way that is safe while other goroutines are reading it.
+// The supplied map must not be modified after it has been passed to this method.
+func (c *ConfigAccessor) ReplaceJsonData(data map[string]interface{}) {
+	jsonDataLock.Lock()
+	defer jsonDataLock.Unlock()
+
+	c.JsonData = data
 }
 
 // PathExists check to see whether the supplied dot-delimited path exists in the configuration and points to a non-null JSON value.
@@ -136,7 +201,7 @@ func (c *ConfigAccessor) Value(path string) interface{} {
 
 	splitPath := strings.Split(path, JsonPathSeparator)
 
-	return c.configVal(splitPath, c.JsonData)
+	return c.configVal(splitPath, c.CurrentJsonData())
 
 }
 
@@ -0,0 +1,170 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// A ConfigDecoder converts the contents of a configuration file in a particular format into the same tree of maps,
+// slices, strings, float64s and bools that would be created by parsing the equivalent JSON file, so that configuration in
+// any format can be merged and accessed in the same way.
+type ConfigDecoder interface {
+	// Decode parses the supplied data. Problems with the data should be returned as a *ParseError where possible so the
+	// line of the problem can be reported.
+	Decode(data []byte) (map[string]interface{}, error)
+
+	// Extensions returns the file extensions (including the leading .) of files in this format.
+	Extensions() []string
+
+	// ContentTypes returns the MIME types an HTTP server might use when serving files in this format.
+	ContentTypes() []string
+}
+
+// A ParseError describes a problem with the contents of a configuration file.
+type ParseError struct {
+	// The line (starting at 1) on which the problem was found or 0 if unknown.
+	Line int
+
+	// A description of the problem.
+	Message string
+}
+
+// Error returns a description of the problem including the line number (if known).
+func (pe *ParseError) Error() string {
+
+	if pe.Line > 0 {
+		return fmt.Sprintf("line %d: %s", pe.Line, pe.Message)
+	}
+
+	return pe.Message
+}
+
+var decoders = struct {
+	sync.RWMutex
+	registered []ConfigDecoder
+}{registered: []ConfigDecoder{new(JsonDecoder), new(YamlDecoder), new(TomlDecoder)}}
+
+// RegisterDecoder makes a ConfigDecoder available for loading configuration and component definition files. If the
+// decoder declares an extension or content type already handled by another decoder, the new decoder will be used instead.
+// Decoders must be registered before configuration is loaded (e.g. in an init function in your application's main package).
+func RegisterDecoder(d ConfigDecoder) {
+	decoders.Lock()
+	defer decoders.Unlock()
+
+	decoders.registered = append([]ConfigDecoder{d}, decoders.registered...)
+}
+
+// DecoderForExtension returns the ConfigDecoder registered for the supplied file extension (including the leading .)
+// or nil if there is no matching decoder.
+func DecoderForExtension(ext string) ConfigDecoder {
+	decoders.RLock()
+	defer decoders.RUnlock()
+
+	ext = strings.ToLower(ext)
+
+	for _, d := range decoders.registered {
+		for _, e := range d.Extensions() {
+			if e == ext {
+				return d
+			}
+		}
+	}
+
+	return nil
+}
+
+// DecoderForContentType returns the ConfigDecoder registered for the supplied HTTP Content-Type (parameters such as
+// charset are ignored) or nil if there is no matching decoder.
+func DecoderForContentType(contentType string) ConfigDecoder {
+	decoders.RLock()
+	defer decoders.RUnlock()
+
+	mt, _, err := mime.ParseMediaType(contentType)
+
+	if err != nil {
+		return nil
+	}
+
+	for _, d := range decoders.registered {
+		for _, ct := range d.ContentTypes() {
+			if ct == mt {
+				return d
+			}
+		}
+	}
+
+	return nil
+}
+
+// Chooses a decoder for a file or URL, based on the Content-Type returned by the server (for URLs) or the extension
+// of the file. JSON is assumed if no other decoder matches.
+func decoderFor(location string, contentType string) ConfigDecoder {
+
+	if contentType != "" {
+		if d := DecoderForContentType(contentType); d != nil {
+			return d
+		}
+	}
+
+	p := location
+
+	if isURL(location) {
+		if u, err := url.Parse(location); err == nil {
+			p = u.Path
+		}
+	}
+
+	if d := DecoderForExtension(filepath.Ext(p)); d != nil {
+		return d
+	}
+
+	return new(JsonDecoder)
+}
+
+// JsonDecoder decodes configuration in JSON format.
+type JsonDecoder struct{}
+
+// Decode implements ConfigDecoder.Decode
+func (jd *JsonDecoder) Decode(data []byte) (map[string]interface{}, error) {
+
+	var loaded interface{}
+
+	if err := json.Unmarshal(data, &loaded); err != nil {
+
+		pe := new(ParseError)
+		pe.Message = err.Error()
+
+		if se, found := err.(*json.SyntaxError); found {
+			pe.Line = bytes.Count(data[:se.Offset], []byte("\n")) + 1
+		}
+
+		return nil, pe
+	}
+
+	m, found := loaded.(map[string]interface{})
+
+	if !found {
+		return nil, &ParseError{Message: "the top level of the file must be a JSON object"}
+	}
+
+	return m, nil
+}
+
+// Extensions implements ConfigDecoder.Extensions
+func (jd *JsonDecoder) Extensions() []string {
+	return []string{".json"}
+}
+
+// ContentTypes implements ConfigDecoder.ContentTypes
+func (jd *JsonDecoder) ContentTypes() []string {
+	return []string{"application/json", "text/json"}
+}
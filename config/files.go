@@ -11,8 +11,8 @@ import (
 	"path/filepath"
 )
 
-// FindConfigFilesInDir finds all files with a .json extension in the supplied directory path, recursively checking
-// sub-directories. Note that each directory's contents are examined and added to the list of files lexicographically,
+// FindConfigFilesInDir finds all files with an extension supported by a registered ConfigDecoder (.json, .yaml, .yml and
+// .toml by default) in the supplied directory path, recursively checking sub-directories. Note that each directory's contents are examined and added to the list of files lexicographically,
 // so any files in a sub-directory 'b' would appear in the resulting list of files before 'c.json'
 func FindConfigFilesInDir(dirPath string) ([]string, error) {
 
@@ -40,7 +40,7 @@ func FindConfigFilesInDir(dirPath string) ([]string, error) {
 				files = append(files, sub...)
 			}
 
-		} else if filepath.Ext(fileName) == ".json" {
+		} else if DecoderForExtension(filepath.Ext(fileName)) != nil {
 
 			f := filepath.Join(dirPath, fileName)
 
@@ -80,8 +80,26 @@ type InitialSettings struct {
 	// An (optional) unique identifier for this instance of a Granitic application.
 	InstanceId string
 
+	// The names of profiles to activate (in addition to any listed in the System.Profiles configuration). Profiles are
+	// used to decide which conditional components are created.
+	Profiles []string
+
 	// A base 64 serialised version of Granitic's built-in configuration files
 	BuiltInConfig *string
+
+	// A base 64 serialised version of the schemas for Granitic's built-in configuration files
+	BuiltInSchema *string
+
+	// Files, directories and URLs from which the application's configuration schemas should be loaded and merged.
+	ConfigSchema []string
+
+	// If true, the merged configuration (and the file or URL that supplied each value) is printed and the application
+	// exits without starting any components.
+	ExplainConfig bool
+
+	// Controls authentication, timeouts, retries and caching when configuration is loaded from URLs. If nil, URLs are
+	// loaded without authentication, retries or caching.
+	URLLoader *URLLoader
 }
 
 // InitialSettingsFromEnvironment builds an InitialSettings and populates it with defaults or the values of command line
@@ -96,6 +114,15 @@ func InitialSettingsFromEnvironment() *InitialSettings {
 
 	processCommandLineArgs(is)
 
+	ul, err := URLLoaderFromEnvironment()
+
+	if err != nil {
+		fmt.Println(err)
+		instance.ExitError()
+	}
+
+	is.URLLoader = ul
+
 	return is
 
 }
@@ -104,6 +131,9 @@ func processCommandLineArgs(is *InitialSettings) {
 	configFilePtr := flag.String("c", "resource/config", "Path to container configuration files")
 	startupLogLevel := flag.String("l", "INFO", "Logging threshold for messages from components during bootstrap")
 	instanceId := flag.String("i", "", "A unique identifier for this instance of the application")
+	profiles := flag.String("p", "", "A comma separated list of profiles to activate")
+	schemaPtr := flag.String("s", "", "Path to configuration schema files")
+	explain := flag.Bool("e", false, "Print the merged configuration and the source of each value, then exit")
 	flag.Parse()
 
 	ll, err := logging.LogLevelFromLabel(*startupLogLevel)
@@ -122,8 +152,29 @@ func processCommandLineArgs(is *InitialSettings) {
 	}
 
 	is.Configuration = append(is.Configuration, userConfig...)
+
+	if *schemaPtr != "" {
+
+		schema, err := ExpandToFilesAndURLs(strings.Split(*schemaPtr, ","))
+
+		if err != nil {
+			fmt.Println(err)
+			instance.ExitError()
+		}
+
+		is.ConfigSchema = schema
+	}
+
 	is.FrameworkLogLevel = ll
 	is.InstanceId = *instanceId
+	is.ExplainConfig = *explain
+
+	for _, p := range strings.Split(*profiles, ",") {
+
+		if p = strings.TrimSpace(p); p != "" {
+			is.Profiles = append(is.Profiles, p)
+		}
+	}
 
 }
 
@@ -4,23 +4,21 @@
 package config
 
 import (
-	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/graniticio/granitic/instance"
 	"github.com/graniticio/granitic/logging"
 	"io/ioutil"
-	"net/http"
 )
 
-const jsonMergerComponentName string = instance.FrameworkPrefix + "JsonMerger"
+// The name of the component in the IoC container holding the JsonMerger used to load the application's configuration.
+const JsonMergerComponentName string = instance.FrameworkPrefix + "JsonMerger"
 
 // NewJsonMerger creates a JsonMerger with a Logger
 func NewJsonMerger(flm *logging.ComponentLoggerManager) *JsonMerger {
 	jm := new(JsonMerger)
 
-	jm.Logger = flm.CreateLogger(jsonMergerComponentName)
+	jm.Logger = flm.CreateLogger(JsonMergerComponentName)
 
 	return jm
 }
@@ -34,6 +32,18 @@ type JsonMerger struct {
 
 	// True if arrays should be joined when merging; false if the entire conetnts of the array should be overwritten.
 	MergeArrays bool
+
+	// True if placeholders (see PlaceholderStart) in string values should be left unresolved.
+	SkipPlaceholders bool
+
+	// Used to load configuration from URLs. If not set, URLs are loaded without authentication, retries or caching.
+	URLLoader *URLLoader
+
+	// Paths of values resolved from placeholders during the most recent successful merge.
+	substituted []string
+
+	// The file or URL that supplied each leaf value during the most recent successful merge.
+	sources map[string]string
 }
 
 // LoadAndMergeConfig takes a list of file paths or URIs to JSON files and merges them into a single in-memory object representation.
@@ -45,22 +55,33 @@ func (jm *JsonMerger) LoadAndMergeConfig(files []string) (map[string]interface{}
 	return jm.LoadAndMergeConfigWithBase(mergedConfig, files)
 }
 
+// LoadAndMergeConfigWithBase merges the supplied files and URLs on top of the supplied configuration (which is modified).
+// Placeholders in each file are resolved before the file is merged - an error listing all of the placeholders that could
+// not be resolved is returned after all files have been merged.
 func (jm *JsonMerger) LoadAndMergeConfigWithBase(config map[string]interface{}, files []string) (map[string]interface{}, error) {
 
-	var jsonData []byte
+	var data []byte
+	var contentType string
 	var err error
 
+	substitutedPaths := make([]string, 0)
+	unresolved := make([]string, 0)
+	sources := make(map[string]string)
+
+	recordSources("", config, BaseSource, sources)
+
 	for _, fileName := range files {
 
 		if isURL(fileName) {
 			jm.Logger.LogTracef("Acessing URL %s", fileName)
 
-			jsonData, err = jm.loadFromURL(fileName)
+			data, contentType, err = jm.loadFromURL(fileName)
 
 		} else {
 			jm.Logger.LogTracef("Reading file %s", fileName)
 
-			jsonData, err = ioutil.ReadFile(fileName)
+			data, err = ioutil.ReadFile(fileName)
+			contentType = ""
 		}
 
 		if err != nil {
@@ -68,64 +89,108 @@ func (jm *JsonMerger) LoadAndMergeConfigWithBase(config map[string]interface{},
 			return nil, errors.New(m)
 		}
 
-		var loadedConfig interface{}
-		err = json.Unmarshal(jsonData, &loadedConfig)
+		additionalConfig, err := decoderFor(fileName, contentType).Decode(data)
 
 		if err != nil {
-			m := fmt.Sprintf("Problem parsing data from a file or URL (%s) as JSON : %s", fileName, err)
+			m := fmt.Sprintf("Problem parsing data from a file or URL (%s): %s", fileName, err)
 			return nil, errors.New(m)
 		}
 
-		additionalConfig := loadedConfig.(map[string]interface{})
+		if !jm.SkipPlaceholders {
+			substituted, failed := resolvePlaceholders(additionalConfig)
 
-		config = jm.merge(config, additionalConfig)
+			substitutedPaths = append(substitutedPaths, substituted...)
+
+			for _, f := range failed {
+				unresolved = append(unresolved, f+" ("+fileName+")")
+			}
+		}
+
+		config = jm.merge(config, additionalConfig, "", fileName, sources)
+
+	}
 
+	if len(unresolved) > 0 {
+		return nil, unresolvedError(unresolved)
 	}
 
+	// The merger may be used to reload configuration while the sources of the current configuration are being read
+	jsonDataLock.Lock()
+	jm.substituted = substitutedPaths
+	jm.sources = sources
+	jsonDataLock.Unlock()
+
 	return config, nil
 }
 
-func (jm *JsonMerger) loadFromURL(url string) ([]byte, error) {
+// SubstitutedPaths returns the paths of configuration values that were resolved from placeholders during the most
+// recent successful merge. These values should be masked if configuration is displayed (see MaskedCopy).
+func (jm *JsonMerger) SubstitutedPaths() []string {
+	jsonDataLock.RLock()
+	defer jsonDataLock.RUnlock()
 
-	r, err := http.Get(url)
+	return jm.substituted
+}
 
-	if err != nil {
-		return nil, err
-	}
+// Sources returns the file or URL that supplied each value in the configuration created by the most recent successful
+// merge. The map's keys are the dot-delimited paths of leaf values (strings, numbers, bools, nulls, arrays and empty
+// objects - see FlattenConfig). Values that were present in the base configuration and never overridden have the source
+// BaseSource. Where arrays from several files have been joined (see MergeArrays), the source lists each file.
+func (jm *JsonMerger) Sources() map[string]string {
+	jsonDataLock.RLock()
+	defer jsonDataLock.RUnlock()
 
-	if r.StatusCode >= 400 {
-		m := fmt.Sprintf("HTTP %d", r.StatusCode)
-		return nil, errors.New(m)
-	}
+	return jm.sources
+}
 
-	var b bytes.Buffer
+func (jm *JsonMerger) loadFromURL(url string) ([]byte, string, error) {
 
-	b.ReadFrom(r.Body)
-	r.Body.Close()
+	ul := jm.URLLoader
 
-	return b.Bytes(), nil
+	if ul == nil {
+		ul = new(URLLoader)
+	}
+
+	if ul.FrameworkLogger == nil {
+		ul.FrameworkLogger = jm.Logger
+	}
+
+	return ul.Load(url)
 }
 
-func (jm *JsonMerger) merge(base, additional map[string]interface{}) map[string]interface{} {
+// Merges additional into base, recording the supplied source against every leaf value taken from additional.
+func (jm *JsonMerger) merge(base, additional map[string]interface{}, prefix string, source string, sources map[string]string) map[string]interface{} {
 
 	for key, value := range additional {
 
+		path := prefix + key
+
 		if existingEntry, ok := base[key]; ok {
 
 			existingEntryType := JsonType(existingEntry)
 			newEntryType := JsonType(value)
 
-			if existingEntryType == JsonMap && newEntryType == JsonMap {
-				jm.merge(existingEntry.(map[string]interface{}), value.(map[string]interface{}))
+			if existingEntryType == JsonMap && newEntryType == JsonMap && len(value.(map[string]interface{})) > 0 {
+				if len(existingEntry.(map[string]interface{})) == 0 {
+					delete(sources, path)
+				}
+
+				jm.merge(existingEntry.(map[string]interface{}), value.(map[string]interface{}), path+JsonPathSeparator, source, sources)
 			} else if jm.MergeArrays && existingEntryType == JsonArray && newEntryType == JsonArray {
 				base[key] = jm.mergeArrays(existingEntry.([]interface{}), value.([]interface{}))
+				sources[path] = joinSources(sources[path], source)
+			} else if existingEntryType == JsonMap && newEntryType == JsonMap {
+				// Merging an empty object makes no change
 			} else {
 				base[key] = value
+				forgetSources(path, sources)
+				recordSources(path, value, source, sources)
 			}
 		} else {
 			jm.Logger.LogTracef("Adding %s", key)
 
 			base[key] = value
+			recordSources(path, value, source, sources)
 		}
 
 	}
@@ -0,0 +1,246 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+/*
+Placeholders allow configuration values to be supplied from outside of configuration files. A placeholder can be used
+anywhere in a JSON string value and will be resolved when the file is loaded by a JsonMerger:
+
+	${env:DB_PASSWORD}        The value of the environment variable DB_PASSWORD (an error if it is not set).
+
+	${env:PORT:8080}          The value of the environment variable PORT or 8080 if it is not set.
+
+	${file:/run/secrets/db}   The contents of the file /run/secrets/db, with any trailing whitespace removed.
+
+If the entire string is a single placeholder and the resolved value is a JSON number or bool (e.g. 8080 or true) the
+value is converted to that type, otherwise the resolved value is inserted into the string. $${ is used to include a
+literal ${ in a string.
+
+The values resolved from placeholders are treated as secrets and are masked when configuration is displayed.
+*/
+const (
+	PlaceholderStart = "${"
+	PlaceholderEnd   = "}"
+	EnvPlaceholder   = "env"
+	FilePlaceholder  = "file"
+	escapedStart     = "$${"
+	MaskedValue      = "******"
+)
+
+// Resolves any placeholders in string values in the supplied configuration (modifying the supplied map). Returns the
+// paths of any values that contained placeholders and a description of each placeholder that could not be resolved.
+func resolvePlaceholders(config map[string]interface{}) ([]string, []string) {
+
+	r := new(placeholderResolver)
+	r.resolveObject("", config)
+
+	return r.substituted, r.unresolved
+}
+
+type placeholderResolver struct {
+	substituted []string
+	unresolved  []string
+}
+
+func (pr *placeholderResolver) resolveObject(prefix string, o map[string]interface{}) {
+
+	for k, v := range o {
+		o[k] = pr.resolveValue(prefix+k, v)
+	}
+}
+
+func (pr *placeholderResolver) resolveValue(path string, v interface{}) interface{} {
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		pr.resolveObject(path+JsonPathSeparator, t)
+
+	case []interface{}:
+		for i, e := range t {
+			t[i] = pr.resolveValue(fmt.Sprintf("%s[%d]", path, i), e)
+		}
+
+	case string:
+		if strings.Contains(t, PlaceholderStart) {
+			return pr.resolveString(path, t)
+		}
+	}
+
+	return v
+}
+
+func (pr *placeholderResolver) resolveString(path, s string) interface{} {
+
+	var b bytes.Buffer
+
+	remaining := s
+	found := 0
+	whole := false
+
+	for {
+		i := strings.Index(remaining, PlaceholderStart)
+
+		if i < 0 {
+			b.WriteString(remaining)
+			break
+		}
+
+		if i > 0 && remaining[i-1] == '$' {
+			b.WriteString(remaining[:i-1] + PlaceholderStart)
+			remaining = remaining[i+len(PlaceholderStart):]
+			continue
+		}
+
+		end := strings.Index(remaining[i:], PlaceholderEnd)
+
+		if end < 0 {
+			b.WriteString(remaining)
+			break
+		}
+
+		placeholder := remaining[i : i+end+len(PlaceholderEnd)]
+		whole = placeholder == s
+
+		b.WriteString(remaining[:i])
+
+		value, err := resolvePlaceholder(placeholder)
+
+		if err != nil {
+			pr.unresolved = append(pr.unresolved, fmt.Sprintf("%s at %s: %s", placeholder, path, err.Error()))
+		}
+
+		b.WriteString(value)
+		found++
+
+		remaining = remaining[i+end+len(PlaceholderEnd):]
+	}
+
+	if found == 0 {
+		return b.String()
+	}
+
+	pr.substituted = append(pr.substituted, path)
+
+	resolved := b.String()
+
+	if whole {
+		var typed interface{}
+
+		if err := json.Unmarshal([]byte(resolved), &typed); err == nil {
+			switch typed.(type) {
+			case float64, bool:
+				return typed
+			}
+		}
+	}
+
+	return resolved
+}
+
+// Finds the value for a single placeholder in the form ${source:name} or ${source:name:default}
+func resolvePlaceholder(placeholder string) (string, error) {
+
+	body := strings.TrimSuffix(strings.TrimPrefix(placeholder, PlaceholderStart), PlaceholderEnd)
+
+	parts := strings.SplitN(body, ":", 2)
+
+	if len(parts) < 2 || parts[1] == "" {
+		m := fmt.Sprintf("placeholders must be in the form ${%s:NAME}, ${%s:NAME:default} or ${%s:/path/to/file}", EnvPlaceholder, EnvPlaceholder, FilePlaceholder)
+		return "", errors.New(m)
+	}
+
+	switch parts[0] {
+	case EnvPlaceholder:
+		nameAndDefault := strings.SplitN(parts[1], ":", 2)
+
+		if v, found := os.LookupEnv(nameAndDefault[0]); found {
+			return v, nil
+		}
+
+		if len(nameAndDefault) == 2 {
+			return nameAndDefault[1], nil
+		}
+
+		return "", errors.New("environment variable " + nameAndDefault[0] + " is not set")
+
+	case FilePlaceholder:
+		b, err := ioutil.ReadFile(parts[1])
+
+		if err != nil {
+			return "", err
+		}
+
+		return strings.TrimRight(string(b), " \t\r\n"), nil
+	}
+
+	m := fmt.Sprintf("unsupported placeholder type '%s' (supported types are %s and %s)", parts[0], EnvPlaceholder, FilePlaceholder)
+	return "", errors.New(m)
+}
+
+// MaskedCopy returns a deep copy of the supplied configuration where the values at the supplied paths (and any values
+// inside those paths) are replaced with MaskedValue.
+func MaskedCopy(config map[string]interface{}, maskedPaths []string) map[string]interface{} {
+
+	masked := make(map[string]bool)
+
+	for _, p := range maskedPaths {
+		masked[p] = true
+	}
+
+	return maskObject("", config, masked)
+}
+
+func maskObject(prefix string, o map[string]interface{}, masked map[string]bool) map[string]interface{} {
+
+	c := make(map[string]interface{})
+
+	for k, v := range o {
+		c[k] = maskValue(prefix+k, v, masked)
+	}
+
+	return c
+}
+
+func maskValue(path string, v interface{}, masked map[string]bool) interface{} {
+
+	if masked[path] {
+		return MaskedValue
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return maskObject(path+JsonPathSeparator, t, masked)
+
+	case []interface{}:
+		c := make([]interface{}, len(t))
+
+		for i, e := range t {
+			c[i] = maskValue(fmt.Sprintf("%s[%d]", path, i), e, masked)
+		}
+
+		return c
+	}
+
+	return v
+}
+
+func unresolvedError(unresolved []string) error {
+
+	sort.Strings(unresolved)
+
+	m := fmt.Sprintf("Unable to resolve placeholders in configuration:\n  %s", strings.Join(unresolved, "\n  "))
+
+	return errors.New(m)
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"github.com/graniticio/granitic/logging"
+	"github.com/graniticio/granitic/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTempConfig(t *testing.T, dir, name, contents string) string {
+	f := filepath.Join(dir, name)
+
+	if err := ioutil.WriteFile(f, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func TestPlaceholdersResolved(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "placeholder")
+	test.ExpectNil(t, err)
+	defer os.RemoveAll(dir)
+
+	secret := writeTempConfig(t, dir, "secret", "s3cret\n")
+
+	os.Setenv("GRNC_TEST_USER", "admin")
+	os.Unsetenv("GRNC_TEST_PORT")
+
+	conf := `{
+	  "Db": {
+	    "User": "${env:GRNC_TEST_USER}",
+	    "Password": "${file:` + secret + `}",
+	    "Port": "${env:GRNC_TEST_PORT:8080}",
+	    "Url": "db://${env:GRNC_TEST_USER}@localhost:${env:GRNC_TEST_PORT:5432}",
+	    "Literal": "$${env:GRNC_TEST_USER}"
+	  },
+	  "Hosts": ["${env:GRNC_TEST_USER}"]
+	}`
+
+	f := writeTempConfig(t, dir, "config.json", conf)
+
+	jm := NewJsonMerger(logging.CreateComponentLoggerManager(logging.Fatal, map[string]interface{}{}, []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter()))
+
+	merged, err := jm.LoadAndMergeConfig([]string{f})
+	test.ExpectNil(t, err)
+
+	ca := &ConfigAccessor{merged, new(logging.ConsoleErrorLogger)}
+
+	u, _ := ca.StringVal("Db.User")
+	test.ExpectString(t, u, "admin")
+
+	p, _ := ca.StringVal("Db.Password")
+	test.ExpectString(t, p, "s3cret")
+
+	port, err := ca.IntVal("Db.Port")
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, port, 8080)
+
+	url, _ := ca.StringVal("Db.Url")
+	test.ExpectString(t, url, "db://admin@localhost:5432")
+
+	l, _ := ca.StringVal("Db.Literal")
+	test.ExpectString(t, l, "${env:GRNC_TEST_USER}")
+
+	substituted := jm.SubstitutedPaths()
+	test.ExpectInt(t, len(substituted), 5)
+
+	masked := MaskedCopy(merged, substituted)
+	mca := &ConfigAccessor{masked, new(logging.ConsoleErrorLogger)}
+
+	mp, _ := mca.StringVal("Db.Password")
+	test.ExpectString(t, mp, MaskedValue)
+
+	h, _ := mca.Array("Hosts")
+	test.ExpectString(t, h[0].(string), MaskedValue)
+
+	ml, _ := mca.StringVal("Db.Literal")
+	test.ExpectString(t, ml, "${env:GRNC_TEST_USER}")
+
+	p, _ = ca.StringVal("Db.Password")
+	test.ExpectString(t, p, "s3cret")
+}
+
+func TestUnresolvedPlaceholders(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "placeholder")
+	test.ExpectNil(t, err)
+	defer os.RemoveAll(dir)
+
+	os.Unsetenv("GRNC_TEST_MISSING")
+
+	conf := `{"A": "${env:GRNC_TEST_MISSING}", "B": "${file:` + filepath.Join(dir, "none") + `}", "C": "${vault:x}"}`
+
+	f := writeTempConfig(t, dir, "config.json", conf)
+
+	jm := NewJsonMerger(logging.CreateComponentLoggerManager(logging.Fatal, map[string]interface{}{}, []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter()))
+
+	_, err = jm.LoadAndMergeConfig([]string{f})
+	test.ExpectNotNil(t, err)
+
+	m := err.Error()
+
+	test.ExpectBool(t, strings.Contains(m, "GRNC_TEST_MISSING is not set"), true)
+	test.ExpectBool(t, strings.Contains(m, "at B"), true)
+	test.ExpectBool(t, strings.Contains(m, "vault"), true)
+
+	jm.SkipPlaceholders = true
+
+	merged, err := jm.LoadAndMergeConfig([]string{f})
+	test.ExpectNil(t, err)
+	test.ExpectString(t, merged["A"].(string), "${env:GRNC_TEST_MISSING}")
+}
@@ -0,0 +1,122 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// BaseSource is recorded as the source of values that were already present in the configuration that files and URLs
+// were merged on top of (normally Granitic's built-in configuration).
+const BaseSource = "built-in"
+
+// A SourcedValue is a single value from merged configuration and the file or URL that supplied it.
+type SourcedValue struct {
+	// The dot-delimited path to the value.
+	Path string
+
+	// The value (or MaskedValue if the value was resolved from a placeholder).
+	Value interface{}
+
+	// The file or URL that supplied the value (or BaseSource).
+	Source string
+}
+
+// EffectiveConfig returns every leaf value (see FlattenConfig) in the supplied merged configuration at or below the
+// supplied path (all values if the path is empty), sorted by path. The source of each value is taken from the supplied
+// JsonMerger (which should be the merger that created the configuration) and values resolved from placeholders are masked.
+func EffectiveConfig(config map[string]interface{}, jm *JsonMerger, path string) []*SourcedValue {
+
+	var sources map[string]string
+
+	if jm != nil {
+		config = MaskedCopy(config, jm.SubstitutedPaths())
+		sources = jm.Sources()
+	}
+
+	values := FlattenConfig(config)
+	sv := make([]*SourcedValue, 0, len(values))
+
+	for p, v := range values {
+		if path == "" || p == path || strings.HasPrefix(p, path+JsonPathSeparator) {
+			sv = append(sv, &SourcedValue{Path: p, Value: v, Source: sources[p]})
+		}
+	}
+
+	sort.Slice(sv, func(i, j int) bool { return sv[i].Path < sv[j].Path })
+
+	return sv
+}
+
+// FlattenConfig converts a configuration tree into a map of dot-delimited paths to leaf values. Arrays and empty objects
+// are treated as leaf values.
+func FlattenConfig(config map[string]interface{}) map[string]interface{} {
+
+	values := make(map[string]interface{})
+
+	flattenObject("", config, values)
+
+	return values
+}
+
+func flattenObject(prefix string, o map[string]interface{}, values map[string]interface{}) {
+
+	for k, v := range o {
+
+		p := prefix + k
+
+		if m, found := v.(map[string]interface{}); found && len(m) > 0 {
+			flattenObject(p+JsonPathSeparator, m, values)
+		} else {
+			values[p] = v
+		}
+	}
+}
+
+// Records the supplied source against the supplied value (if it is a leaf) or every leaf inside it.
+func recordSources(path string, value interface{}, source string, sources map[string]string) {
+
+	m, found := value.(map[string]interface{})
+
+	if !found || (len(m) == 0 && path != "") {
+		sources[path] = source
+		return
+	}
+
+	prefix := ""
+
+	if path != "" {
+		prefix = path + JsonPathSeparator
+	}
+
+	for k, v := range m {
+		recordSources(prefix+k, v, source, sources)
+	}
+}
+
+// Removes the sources recorded for a value and any values inside it.
+func forgetSources(path string, sources map[string]string) {
+
+	for p := range sources {
+		if p == path || strings.HasPrefix(p, path+JsonPathSeparator) {
+			delete(sources, p)
+		}
+	}
+}
+
+func joinSources(existing, additional string) string {
+
+	if existing == "" || existing == additional {
+		return additional
+	}
+
+	for _, s := range strings.Split(existing, ", ") {
+		if s == additional {
+			return existing
+		}
+	}
+
+	return existing + ", " + additional
+}
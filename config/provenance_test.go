@@ -0,0 +1,61 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"github.com/graniticio/granitic/test"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSourcesRecorded(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "provenance")
+	test.ExpectNil(t, err)
+	defer os.RemoveAll(dir)
+
+	os.Setenv("GRNC_TEST_SECRET", "s3cret")
+
+	a := writeTempConfig(t, dir, "a.json", `{"Db": {"Host": "a", "Port": 1, "Opts": {"X": 1}}, "Tags": ["a"], "Empty": {}}`)
+	b := writeTempConfig(t, dir, "b.yaml", "Db:\n  Host: b\n  Opts: none\nTags: [b]\nEmpty:\n  Now: set\n")
+	c := writeTempConfig(t, dir, "c.json", `{"Db": {"Password": "${env:GRNC_TEST_SECRET}"}, "Tags": ["c"]}`)
+
+	base := map[string]interface{}{"Base": map[string]interface{}{"Value": true}, "Db": map[string]interface{}{"Port": 0.0}}
+
+	jm := testMerger()
+	jm.MergeArrays = true
+
+	merged, err := jm.LoadAndMergeConfigWithBase(base, []string{a, b, c})
+	test.ExpectNil(t, err)
+
+	sources := jm.Sources()
+
+	test.ExpectString(t, sources["Base.Value"], BaseSource)
+	test.ExpectString(t, sources["Db.Host"], b)
+	test.ExpectString(t, sources["Db.Port"], a)
+	test.ExpectString(t, sources["Db.Opts"], b)
+	test.ExpectString(t, sources["Db.Password"], c)
+	test.ExpectString(t, sources["Tags"], a+", "+b+", "+c)
+	test.ExpectString(t, sources["Empty.Now"], b)
+
+	_, found := sources["Db.Opts.X"]
+	test.ExpectBool(t, found, false)
+
+	_, found = sources["Empty"]
+	test.ExpectBool(t, found, false)
+
+	values := EffectiveConfig(merged, jm, "Db")
+	test.ExpectInt(t, len(values), 4)
+
+	test.ExpectString(t, values[0].Path, "Db.Host")
+	test.ExpectString(t, values[1].Path, "Db.Opts")
+	test.ExpectString(t, values[2].Path, "Db.Password")
+	test.ExpectString(t, values[2].Value.(string), MaskedValue)
+	test.ExpectString(t, values[2].Source, c)
+	test.ExpectString(t, values[3].Path, "Db.Port")
+
+	test.ExpectInt(t, len(EffectiveConfig(merged, jm, "")), 7)
+	test.ExpectInt(t, len(EffectiveConfig(merged, jm, "D")), 0)
+}
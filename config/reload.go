@@ -0,0 +1,179 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"github.com/graniticio/granitic/instance"
+	"github.com/graniticio/granitic/logging"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The name of the component in the IoC container that is able to reload configuration (only present if System.AllowConfigReload is true).
+const ConfigReloaderComponentName = instance.FrameworkPrefix + "ConfigReloader"
+
+// Implemented by components that need to react when configuration is reloaded while the application is running (see
+// ConfigReloader). A component implementing this interface is only notified if the configuration at one or more of the
+// paths promised to its fields (using conf: in its component definition) has changed.
+type ConfigChangeListener interface {
+	// ConfigChanged is called after configuration has been reloaded. The fields on the component are NOT updated by the
+	// container - the component is responsible for deciding how to apply each change (the supplied ConfigAccessor
+	// can be used to populate fields with the new values).
+	ConfigChanged(changes []*PromiseChange, ca *ConfigAccessor) error
+}
+
+// A PromiseChange describes a change to the configuration promised to a field on a component.
+type PromiseChange struct {
+	// The name of the field on the component that the configuration was promised to.
+	Field string
+
+	// The path of the configuration that was promised to the field.
+	Path string
+}
+
+// Implemented by a component (normally the IoC container) that can notify other components that configuration has changed.
+type ConfigChangeNotifier interface {
+	// NotifyConfigChanged is called after configuration has been reloaded with the paths that have changed.
+	NotifyConfigChanged(changedPaths []string) error
+}
+
+// ConfigReloader re-loads and merges configuration files and URLs while the application is running, updates the
+// application's ConfigAccessor with the result and notifies interested components of any configuration that has changed.
+// Reloading is triggered by the reload runtime control command or by sending the application a SIGHUP signal.
+//
+// The ConfigAccessor's merged configuration cannot be flushed after startup if reloading is allowed, as it is needed to
+// determine what has changed.
+type ConfigReloader struct {
+	// Logger used by Granitic framework components. Automatically injected.
+	FrameworkLogger logging.Logger
+
+	// The ConfigAccessor shared by all components, which will be updated with the reloaded configuration.
+	Accessor *ConfigAccessor
+
+	// The JsonMerger used to merge configuration files and URLs.
+	Merger *JsonMerger
+
+	// The files and URLs from which configuration will be reloaded (in the order in which they should be merged).
+	Locations []string
+
+	// A function that returns a new copy of the configuration that the files and URLs should be merged on top of
+	// (normally Granitic's built-in configuration).
+	Base func() (map[string]interface{}, error)
+
+	// Notified of any paths that have changed after configuration has been reloaded.
+	Notifier ConfigChangeNotifier
+
+	// If set, reloaded configuration must match this schema (see ValidateAgainstSchema) or it will be rejected.
+	Schema map[string]interface{}
+
+	mutex sync.Mutex
+}
+
+// Reload re-merges configuration, replaces the configuration held by the ConfigAccessor and notifies interested
+// components of the change. Returns the paths that changed. If an error is returned before components have been
+// notified, the existing configuration is left unchanged.
+func (cr *ConfigReloader) Reload() ([]string, error) {
+
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+
+	var base map[string]interface{}
+	var err error
+
+	if cr.Base == nil {
+		base = make(map[string]interface{})
+	} else if base, err = cr.Base(); err != nil {
+		return nil, err
+	}
+
+	merged, err := cr.Merger.LoadAndMergeConfigWithBase(base, cr.Locations)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cr.Schema != nil {
+		if err = ValidateAgainstSchema(merged, cr.Schema); err != nil {
+			return nil, err
+		}
+	}
+
+	changed := ChangedPaths(cr.Accessor.CurrentJsonData(), merged)
+
+	if len(changed) == 0 {
+		cr.FrameworkLogger.LogInfof("Configuration reloaded - no changes")
+		return changed, nil
+	}
+
+	cr.FrameworkLogger.LogInfof("Configuration reloaded - changed paths: %s", strings.Join(changed, ", "))
+
+	cr.Accessor.ReplaceJsonData(merged)
+
+	if cr.Notifier == nil {
+		return changed, nil
+	}
+
+	return changed, cr.Notifier.NotifyConfigChanged(changed)
+}
+
+// ChangedPaths compares two versions of merged configuration and returns the dot-delimited paths of any values that
+// have been added, removed or modified, sorted lexicographically. Where an entire object has been added or removed,
+// only the path of that object is returned.
+func ChangedPaths(previous, current map[string]interface{}) []string {
+
+	changed := make([]string, 0)
+
+	diffObjects("", previous, current, &changed)
+
+	sort.Strings(changed)
+
+	return changed
+}
+
+func diffObjects(prefix string, previous, current map[string]interface{}, changed *[]string) {
+
+	for k, pv := range previous {
+
+		path := prefix + k
+
+		cv, found := current[k]
+
+		if !found {
+			*changed = append(*changed, path)
+			continue
+		}
+
+		pm, pIsMap := pv.(map[string]interface{})
+		cm, cIsMap := cv.(map[string]interface{})
+
+		if pIsMap && cIsMap {
+			diffObjects(path+JsonPathSeparator, pm, cm, changed)
+
+		} else if !reflect.DeepEqual(pv, cv) {
+			*changed = append(*changed, path)
+		}
+	}
+
+	for k := range current {
+
+		if _, found := previous[k]; !found {
+			*changed = append(*changed, prefix+k)
+		}
+	}
+}
+
+// PathAffected returns true if any of the supplied changed paths are the same as, inside or contain the supplied path.
+func PathAffected(path string, changedPaths []string) bool {
+
+	for _, c := range changedPaths {
+
+		if c == path || strings.HasPrefix(c, path+JsonPathSeparator) || strings.HasPrefix(path, c+JsonPathSeparator) {
+			return true
+		}
+	}
+
+	return false
+}
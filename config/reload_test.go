@@ -0,0 +1,163 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/logging"
+	"github.com/graniticio/granitic/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type recordingNotifier struct {
+	changed []string
+}
+
+func (rn *recordingNotifier) NotifyConfigChanged(changed []string) error {
+	rn.changed = changed
+	return nil
+}
+
+func TestChangedPaths(t *testing.T) {
+
+	previous := map[string]interface{}{
+		"A": map[string]interface{}{"B": 1.0, "C": "x", "D": map[string]interface{}{"E": true}},
+		"F": []interface{}{"a", "b"},
+		"G": "removed",
+	}
+
+	current := map[string]interface{}{
+		"A": map[string]interface{}{"B": 2.0, "C": "x"},
+		"F": []interface{}{"a", "b"},
+		"H": map[string]interface{}{"I": 1.0},
+	}
+
+	changed := ChangedPaths(previous, current)
+
+	test.ExpectString(t, strings.Join(changed, ","), "A.B,A.D,G,H")
+
+	test.ExpectBool(t, PathAffected("A", changed), true)
+	test.ExpectBool(t, PathAffected("A.D.E", changed), true)
+	test.ExpectBool(t, PathAffected("A.C", changed), false)
+	test.ExpectBool(t, PathAffected("GG", changed), false)
+}
+
+func TestReloadReplacesConfig(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "reload")
+	test.ExpectNil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := filepath.Join(dir, "config.json")
+	ioutil.WriteFile(f, []byte(`{"Feature":{"Enabled":false}}`), 0644)
+
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, map[string]interface{}{}, []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter())
+
+	cr := new(ConfigReloader)
+	cr.FrameworkLogger = lm.CreateLogger("reloader")
+	cr.Merger = NewJsonMerger(lm)
+	cr.Locations = []string{f}
+
+	cr.Base = func() (map[string]interface{}, error) {
+		return map[string]interface{}{"Base": "b"}, nil
+	}
+
+	merged, _ := cr.Merger.LoadAndMergeConfigWithBase(map[string]interface{}{"Base": "b"}, cr.Locations)
+
+	cr.Accessor = &ConfigAccessor{merged, lm.CreateLogger("accessor")}
+
+	rn := new(recordingNotifier)
+	cr.Notifier = rn
+
+	changed, err := cr.Reload()
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(changed), 0)
+
+	ioutil.WriteFile(f, []byte(`{"Feature":{"Enabled":true}}`), 0644)
+
+	changed, err = cr.Reload()
+	test.ExpectNil(t, err)
+	test.ExpectString(t, strings.Join(rn.changed, ","), "Feature.Enabled")
+
+	b, _ := cr.Accessor.BoolVal("Feature.Enabled")
+	test.ExpectBool(t, b, true)
+
+	ioutil.WriteFile(f, []byte(`{"Feature":`), 0644)
+
+	_, err = cr.Reload()
+	test.ExpectNotNil(t, err)
+
+	b, _ = cr.Accessor.BoolVal("Feature.Enabled")
+	test.ExpectBool(t, b, true)
+}
+
+func TestReloadRejectsConfigNotMatchingSchema(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "reload")
+	test.ExpectNil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := writeTempConfig(t, dir, "config.json", `{"Feature":{"Enabled":false}}`)
+
+	cr := new(ConfigReloader)
+	cr.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	cr.Merger = testMerger()
+	cr.Locations = []string{f}
+	cr.Schema = parseJson(t, `{"properties": {"Feature": {"properties": {"Enabled": {"type": "boolean"}}}}}`)
+
+	merged, _ := cr.Merger.LoadAndMergeConfig(cr.Locations)
+	cr.Accessor = &ConfigAccessor{merged, new(logging.ConsoleErrorLogger)}
+
+	writeTempConfig(t, dir, "config.json", `{"Feature":{"Enabled":"yes"}}`)
+
+	_, err = cr.Reload()
+	test.ExpectNotNil(t, err)
+
+	e, _ := cr.Accessor.BoolVal("Feature.Enabled")
+	test.ExpectBool(t, e, false)
+}
+
+func TestReloadWhileReading(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "reload")
+	test.ExpectNil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := writeTempConfig(t, dir, "config.json", `{"Feature":{"Enabled":false}}`)
+
+	cr := new(ConfigReloader)
+	cr.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	cr.Merger = testMerger()
+	cr.Locations = []string{f}
+
+	merged, _ := cr.Merger.LoadAndMergeConfig(cr.Locations)
+	cr.Accessor = &ConfigAccessor{merged, new(logging.ConsoleErrorLogger)}
+
+	done := make(chan bool)
+
+	go func() {
+		for i := 0; i < 1000; i++ {
+			cr.Accessor.PathExists("Feature.Enabled")
+			EffectiveConfig(cr.Accessor.CurrentJsonData(), cr.Merger, "Feature")
+		}
+
+		done <- true
+	}()
+
+	for i := 0; i < 10; i++ {
+		writeTempConfig(t, dir, "config.json", fmt.Sprintf(`{"Feature":{"Enabled":%t}}`, i%2 == 0))
+
+		_, err = cr.Reload()
+		test.ExpectNil(t, err)
+	}
+
+	<-done
+
+	e, _ := cr.Accessor.BoolVal("Feature.Enabled")
+	test.ExpectBool(t, e, false)
+}
@@ -0,0 +1,318 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/logging"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+/*
+Environment variables used to configure how configuration is loaded from URLs when an application is started from the
+command line (see URLLoaderFromEnvironment). Environment variables are used instead of command line arguments so that
+credentials do not appear in process listings.
+
+	GRANITIC_CONFIG_TOKEN           Sent as a bearer token in the Authorization header
+	GRANITIC_CONFIG_USER            The user for HTTP basic authentication
+	GRANITIC_CONFIG_PASSWORD        The password for HTTP basic authentication
+	GRANITIC_CONFIG_TIMEOUT         The maximum time allowed for each attempt to load a URL (e.g. 5s)
+	GRANITIC_CONFIG_RETRIES         The number of times a failed request is retried
+	GRANITIC_CONFIG_RETRY_INTERVAL  The wait before the first retry, doubled for each subsequent retry (e.g. 500ms)
+	GRANITIC_CONFIG_CACHE           A directory in which to cache configuration loaded from URLs
+*/
+const (
+	RemoteTokenEnvVar         = "GRANITIC_CONFIG_TOKEN"
+	RemoteUserEnvVar          = "GRANITIC_CONFIG_USER"
+	RemotePasswordEnvVar      = "GRANITIC_CONFIG_PASSWORD"
+	RemoteTimeoutEnvVar       = "GRANITIC_CONFIG_TIMEOUT"
+	RemoteRetriesEnvVar       = "GRANITIC_CONFIG_RETRIES"
+	RemoteRetryIntervalEnvVar = "GRANITIC_CONFIG_RETRY_INTERVAL"
+	RemoteCacheEnvVar         = "GRANITIC_CONFIG_CACHE"
+)
+
+// The maximum time allowed for each attempt to load configuration from a URL if URLLoader.Timeout is not set.
+const DefaultURLTimeout = 30 * time.Second
+
+// The wait before the first retry if URLLoader.RetryInterval is not set.
+const DefaultRetryInterval = time.Second
+
+/*
+A URLLoader loads configuration files from HTTP(S) URLs on behalf of a JsonMerger.
+
+Requests can be authenticated with a bearer token or HTTP basic authentication and requests that fail because the server
+could not be reached, timed out or returned a 5xx or 429 status are retried, waiting RetryInterval before the first retry
+and twice as long before each subsequent retry.
+
+If CacheDir is set, each successfully loaded file is saved in that directory along with the ETag returned by the server.
+The ETag is sent with the next request for the same URL, so a server can respond with 304 Not Modified and the cached
+copy will be used. If the server cannot be reached (after all retries), the most recent cached copy is used instead and a
+warning is logged. Cached files may contain secrets and are only readable by the user running the application.
+*/
+type URLLoader struct {
+	// Logger used by Granitic framework components.
+	FrameworkLogger logging.Logger
+
+	// If set, sent as a bearer token in the Authorization header.
+	BearerToken string
+
+	// If set (and BearerToken is not), used with BasicAuthPassword for HTTP basic authentication.
+	BasicAuthUser string
+
+	// The password used with BasicAuthUser.
+	BasicAuthPassword string
+
+	// Additional headers to send with each request.
+	Headers map[string]string
+
+	// The maximum time allowed for each attempt to load a URL (DefaultURLTimeout if not set).
+	Timeout time.Duration
+
+	// The number of times a failed request will be retried.
+	Retries int
+
+	// The wait before the first retry (DefaultRetryInterval if not set).
+	RetryInterval time.Duration
+
+	// A directory in which to cache loaded files. Caching and the fallback to cached files are disabled if not set.
+	CacheDir string
+}
+
+// The contents of a cache file.
+type cachedURL struct {
+	URL         string
+	ETag        string
+	ContentType string
+	Body        []byte
+	Loaded      time.Time
+}
+
+// A failure that means the server could not be reached or was temporarily unable to respond, so the request can be
+// retried and a cached copy used.
+type unreachableError struct {
+	cause string
+}
+
+func (ue *unreachableError) Error() string {
+	return ue.cause
+}
+
+// URLLoaderFromEnvironment creates a URLLoader configured from the environment variables described above. Returns an
+// error if a timeout, interval or number of retries cannot be parsed.
+func URLLoaderFromEnvironment() (*URLLoader, error) {
+
+	ul := new(URLLoader)
+
+	ul.BearerToken = os.Getenv(RemoteTokenEnvVar)
+	ul.BasicAuthUser = os.Getenv(RemoteUserEnvVar)
+	ul.BasicAuthPassword = os.Getenv(RemotePasswordEnvVar)
+	ul.CacheDir = os.Getenv(RemoteCacheEnvVar)
+
+	var err error
+
+	if v := os.Getenv(RemoteTimeoutEnvVar); v != "" {
+		if ul.Timeout, err = time.ParseDuration(v); err != nil {
+			return nil, errors.New(fmt.Sprintf("%s must be a duration (e.g. 10s): %s", RemoteTimeoutEnvVar, err.Error()))
+		}
+	}
+
+	if v := os.Getenv(RemoteRetryIntervalEnvVar); v != "" {
+		if ul.RetryInterval, err = time.ParseDuration(v); err != nil {
+			return nil, errors.New(fmt.Sprintf("%s must be a duration (e.g. 500ms): %s", RemoteRetryIntervalEnvVar, err.Error()))
+		}
+	}
+
+	if v := os.Getenv(RemoteRetriesEnvVar); v != "" {
+		if ul.Retries, err = strconv.Atoi(v); err != nil || ul.Retries < 0 {
+			return nil, errors.New(fmt.Sprintf("%s must be zero or a positive integer", RemoteRetriesEnvVar))
+		}
+	}
+
+	return ul, nil
+}
+
+// Load returns the contents of the file at the supplied URL and the Content-Type returned by the server.
+func (ul *URLLoader) Load(url string) ([]byte, string, error) {
+
+	cached := ul.readCache(url)
+
+	wait := ul.RetryInterval
+
+	if wait <= 0 {
+		wait = DefaultRetryInterval
+	}
+
+	var err error
+
+	for attempt := 0; attempt <= ul.Retries; attempt++ {
+
+		if attempt > 0 {
+			ul.log().LogWarnf("Retrying %s in %s (%s)", url, wait, err.Error())
+			time.Sleep(wait)
+			wait *= 2
+		}
+
+		var body []byte
+		var contentType string
+
+		body, contentType, err = ul.request(url, cached)
+
+		if err == nil {
+			return body, contentType, nil
+		}
+
+		if _, retry := err.(*unreachableError); !retry {
+			return nil, "", err
+		}
+	}
+
+	if cached != nil {
+		ul.log().LogWarnf("Unable to load %s (%s). Using the copy cached at %s", url, err.Error(), cached.Loaded.Format(time.RFC3339))
+		return cached.Body, cached.ContentType, nil
+	}
+
+	return nil, "", err
+}
+
+func (ul *URLLoader) request(url string, cached *cachedURL) ([]byte, string, error) {
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	for k, v := range ul.Headers {
+		req.Header.Set(k, v)
+	}
+
+	if ul.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+ul.BearerToken)
+	} else if ul.BasicAuthUser != "" {
+		req.SetBasicAuth(ul.BasicAuthUser, ul.BasicAuthPassword)
+	}
+
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	timeout := ul.Timeout
+
+	if timeout <= 0 {
+		timeout = DefaultURLTimeout
+	}
+
+	client := &http.Client{Timeout: timeout}
+
+	r, err := client.Do(req)
+
+	if err != nil {
+		return nil, "", &unreachableError{err.Error()}
+	}
+
+	defer r.Body.Close()
+
+	var b bytes.Buffer
+
+	if _, err := b.ReadFrom(r.Body); err != nil {
+		return nil, "", &unreachableError{err.Error()}
+	}
+
+	switch {
+	case r.StatusCode == http.StatusNotModified && cached != nil:
+		ul.log().LogTracef("%s not modified - using cached copy", url)
+		return cached.Body, cached.ContentType, nil
+
+	case r.StatusCode >= 500 || r.StatusCode == http.StatusTooManyRequests:
+		return nil, "", &unreachableError{fmt.Sprintf("HTTP %d", r.StatusCode)}
+
+	case r.StatusCode >= 300:
+		return nil, "", errors.New(fmt.Sprintf("HTTP %d", r.StatusCode))
+	}
+
+	contentType := r.Header.Get("Content-Type")
+
+	ul.writeCache(url, r.Header.Get("ETag"), contentType, b.Bytes())
+
+	return b.Bytes(), contentType, nil
+}
+
+func (ul *URLLoader) cacheFile(url string) string {
+	h := sha256.Sum256([]byte(url))
+
+	return filepath.Join(ul.CacheDir, hex.EncodeToString(h[:])+".json")
+}
+
+func (ul *URLLoader) readCache(url string) *cachedURL {
+
+	if ul.CacheDir == "" {
+		return nil
+	}
+
+	f := ul.cacheFile(url)
+
+	b, err := ioutil.ReadFile(f)
+
+	if err != nil {
+		return nil
+	}
+
+	c := new(cachedURL)
+
+	if err = json.Unmarshal(b, c); err != nil || c.URL != url {
+		ul.log().LogWarnf("Ignoring unreadable cache file %s", f)
+		return nil
+	}
+
+	return c
+}
+
+// Saves a copy of a loaded file. Problems writing to the cache are logged but do not prevent configuration being loaded.
+func (ul *URLLoader) writeCache(url, etag, contentType string, body []byte) {
+
+	if ul.CacheDir == "" {
+		return
+	}
+
+	c := cachedURL{URL: url, ETag: etag, ContentType: contentType, Body: body, Loaded: time.Now()}
+
+	b, err := json.Marshal(c)
+
+	if err == nil {
+		err = os.MkdirAll(ul.CacheDir, 0700)
+	}
+
+	if err == nil {
+		// Write to a temporary file first so a partially written cache file is never read
+		f := ul.cacheFile(url)
+		tmp := f + ".tmp"
+
+		if err = ioutil.WriteFile(tmp, b, 0600); err == nil {
+			err = os.Rename(tmp, f)
+		}
+	}
+
+	if err != nil {
+		ul.log().LogWarnf("Unable to cache %s in %s: %s", url, ul.CacheDir, err.Error())
+	}
+}
+
+func (ul *URLLoader) log() logging.Logger {
+
+	if ul.FrameworkLogger == nil {
+		return new(logging.ConsoleErrorLogger)
+	}
+
+	return ul.FrameworkLogger
+}
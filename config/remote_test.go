@@ -0,0 +1,177 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"github.com/graniticio/granitic/logging"
+	"github.com/graniticio/granitic/test"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestURLLoaderAuthentication(t *testing.T) {
+
+	var auth, custom string
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		custom = r.Header.Get("X-Env")
+		w.Write([]byte(`{"A": 1}`))
+	}))
+	defer s.Close()
+
+	ul := &URLLoader{BearerToken: "tkn", Headers: map[string]string{"X-Env": "prod"}}
+
+	_, _, err := ul.Load(s.URL)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, auth, "Bearer tkn")
+	test.ExpectString(t, custom, "prod")
+
+	ul = &URLLoader{BasicAuthUser: "u", BasicAuthPassword: "p"}
+
+	_, _, err = ul.Load(s.URL)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, auth, "Basic dTpw")
+}
+
+func TestURLLoaderRetries(t *testing.T) {
+
+	attempts := 0
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte(`{"A": 1}`))
+	}))
+	defer s.Close()
+
+	ul := &URLLoader{Retries: 1, RetryInterval: time.Millisecond}
+
+	_, _, err := ul.Load(s.URL)
+	test.ExpectNotNil(t, err)
+	test.ExpectInt(t, attempts, 2)
+
+	attempts = 0
+	ul.Retries = 2
+
+	b, _, err := ul.Load(s.URL)
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, attempts, 3)
+	test.ExpectString(t, string(b), `{"A": 1}`)
+
+	attempts = 0
+
+	_, _, err = ul.Load(s.URL + "/missing")
+	test.ExpectNotNil(t, err)
+	test.ExpectInt(t, attempts, 1)
+}
+
+func TestURLLoaderTimeout(t *testing.T) {
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+	defer s.Close()
+
+	ul := &URLLoader{Timeout: 20 * time.Millisecond}
+
+	_, _, err := ul.Load(s.URL)
+	test.ExpectNotNil(t, err)
+}
+
+func TestURLLoaderCaching(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "urlcache")
+	test.ExpectNil(t, err)
+	defer os.RemoveAll(dir)
+
+	var conditional string
+	served := 0
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = r.Header.Get("If-None-Match")
+
+		if conditional == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		served++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/x-yaml")
+		w.Write([]byte("A: 1\n"))
+	}))
+
+	ul := &URLLoader{CacheDir: dir, RetryInterval: time.Millisecond}
+	url := s.URL + "/config"
+
+	b, ct, err := ul.Load(url)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, conditional, "")
+	test.ExpectString(t, ct, "application/x-yaml")
+
+	b, ct, err = ul.Load(url)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, conditional, `"v1"`)
+	test.ExpectInt(t, served, 1)
+	test.ExpectString(t, string(b), "A: 1\n")
+	test.ExpectString(t, ct, "application/x-yaml")
+
+	info, err := os.Stat(ul.cacheFile(url))
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, info.Mode().Perm() == 0600, true)
+
+	// Server unreachable - the cached copy should be used (and decoded using the cached Content-Type)
+	s.Close()
+
+	jm := testMerger()
+	jm.URLLoader = ul
+
+	merged, err := jm.LoadAndMergeConfig([]string{url})
+	test.ExpectNil(t, err)
+
+	ca := &ConfigAccessor{merged, new(logging.ConsoleErrorLogger)}
+	a, _ := ca.Float64Val("A")
+	test.ExpectFloat(t, a, 1)
+
+	// No cache for other URLs
+	_, _, err = ul.Load(s.URL + "/other")
+	test.ExpectNotNil(t, err)
+}
+
+func TestURLLoaderFromEnvironment(t *testing.T) {
+
+	os.Setenv(RemoteTokenEnvVar, "tkn")
+	os.Setenv(RemoteTimeoutEnvVar, "5s")
+	os.Setenv(RemoteRetriesEnvVar, "3")
+	defer os.Unsetenv(RemoteTokenEnvVar)
+	defer os.Unsetenv(RemoteTimeoutEnvVar)
+	defer os.Unsetenv(RemoteRetriesEnvVar)
+
+	ul, err := URLLoaderFromEnvironment()
+	test.ExpectNil(t, err)
+	test.ExpectString(t, ul.BearerToken, "tkn")
+	test.ExpectBool(t, ul.Timeout == 5*time.Second, true)
+	test.ExpectInt(t, ul.Retries, 3)
+
+	os.Setenv(RemoteRetriesEnvVar, "-1")
+
+	_, err = URLLoaderFromEnvironment()
+	test.ExpectNotNil(t, err)
+}
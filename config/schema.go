@@ -0,0 +1,389 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+/*
+Configuration schemas describe the structure and types of merged configuration so that mistakes (misspelt keys, values of
+the wrong type and missing values) are found when an application starts rather than silently leaving component fields
+unset. Schemas are written in a subset of JSON Schema (http://json-schema.org), using the keywords:
+
+	type                  A type name (or an array of type names) from object, array, string, number, integer, boolean and null
+	properties            An object mapping the names of expected keys to the schemas of their values
+	required              An array of the names of keys that must be present
+	additionalProperties  false if keys not listed in properties are not allowed, or a schema that all other keys must match
+	items                 The schema that every element of an array must match
+	enum                  An array of the allowed values
+	minimum, maximum      Inclusive limits on numeric values
+
+The annotation keywords $schema, title, description and default are allowed but ignored. Any other keyword is an error.
+
+Schemas can be spread across multiple files (each describing part of the configuration from the root) which are merged
+in the same way as configuration files. Granitic's built-in facilities each have a schema (see resource/facility-schema)
+and an application can supply its own with the -s command line argument. Keys at the root of the configuration that are
+not described by any schema are allowed unless a schema sets "additionalProperties": false at the root.
+*/
+const (
+	SchemaType                 = "type"
+	SchemaProperties           = "properties"
+	SchemaRequired             = "required"
+	SchemaAdditionalProperties = "additionalProperties"
+	SchemaItems                = "items"
+	SchemaEnum                 = "enum"
+	SchemaMinimum              = "minimum"
+	SchemaMaximum              = "maximum"
+)
+
+var schemaAnnotations = map[string]bool{
+	"$schema":     true,
+	"title":       true,
+	"description": true,
+	"default":     true,
+}
+
+var schemaTypes = map[string]bool{
+	"object":  true,
+	"array":   true,
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"null":    true,
+}
+
+// A SchemaViolationError lists every way in which configuration does not match a schema.
+type SchemaViolationError struct {
+	// A description of each problem, sorted by the path of the problem.
+	Problems []string
+}
+
+// Error returns all of the problems on separate lines.
+func (sv *SchemaViolationError) Error() string {
+	return fmt.Sprintf("Configuration does not match its schema:\n  %s", strings.Join(sv.Problems, "\n  "))
+}
+
+// ValidateAgainstSchema checks the supplied configuration against the supplied schema. If the configuration does not
+// match, a *SchemaViolationError describing every unknown key, wrong type and missing required value is returned. An
+// error is also returned if the schema itself uses unsupported keywords or is malformed.
+func ValidateAgainstSchema(config map[string]interface{}, schema map[string]interface{}) error {
+
+	if problems := checkSchema("", schema); len(problems) > 0 {
+		m := fmt.Sprintf("Configuration schema is invalid:\n  %s", strings.Join(problems, "\n  "))
+		return errors.New(m)
+	}
+
+	sv := new(schemaValidator)
+	sv.validate("", config, schema)
+
+	if len(sv.problems) == 0 {
+		return nil
+	}
+
+	sort.Strings(sv.problems)
+
+	return &SchemaViolationError{Problems: sv.problems}
+}
+
+// Finds unsupported keywords and keyword values of the wrong type in a schema.
+func checkSchema(path string, schema map[string]interface{}) []string {
+
+	problems := make([]string, 0)
+
+	problem := func(keyword, message string) {
+		problems = append(problems, fmt.Sprintf("%s at %s %s", keyword, displayPath(path), message))
+	}
+
+	for k, v := range schema {
+
+		switch k {
+		case SchemaType:
+			names, ok := schemaTypeNames(v)
+
+			if !ok {
+				problem(k, "must be a type name or an array of type names")
+			}
+
+			for _, n := range names {
+				if !schemaTypes[n] {
+					problem(k, "uses unknown type "+n)
+				}
+			}
+
+		case SchemaProperties:
+			props, ok := v.(map[string]interface{})
+
+			if !ok {
+				problem(k, "must be an object")
+				continue
+			}
+
+			for name, ps := range props {
+				if s, ok := ps.(map[string]interface{}); ok {
+					problems = append(problems, checkSchema(joinSchemaPath(path, name), s)...)
+				} else {
+					problem(k, "must only contain schemas (objects) but "+name+" is not an object")
+				}
+			}
+
+		case SchemaRequired:
+			if _, ok := stringList(v); !ok {
+				problem(k, "must be an array of strings")
+			}
+
+		case SchemaAdditionalProperties:
+			switch t := v.(type) {
+			case bool:
+			case map[string]interface{}:
+				problems = append(problems, checkSchema(joinSchemaPath(path, "*"), t)...)
+			default:
+				problem(k, "must be a boolean or a schema")
+			}
+
+		case SchemaItems:
+			if s, ok := v.(map[string]interface{}); ok {
+				problems = append(problems, checkSchema(path+"[]", s)...)
+			} else {
+				problem(k, "must be a schema")
+			}
+
+		case SchemaEnum:
+			if _, ok := v.([]interface{}); !ok {
+				problem(k, "must be an array")
+			}
+
+		case SchemaMinimum, SchemaMaximum:
+			if _, ok := v.(float64); !ok {
+				problem(k, "must be a number")
+			}
+
+		default:
+			if !schemaAnnotations[k] {
+				problem(k, "is not a supported schema keyword")
+			}
+		}
+	}
+
+	sort.Strings(problems)
+
+	return problems
+}
+
+type schemaValidator struct {
+	problems []string
+}
+
+func (sv *schemaValidator) problem(path string, message string, a ...interface{}) {
+	sv.problems = append(sv.problems, displayPath(path)+": "+fmt.Sprintf(message, a...))
+}
+
+func (sv *schemaValidator) validate(path string, value interface{}, schema map[string]interface{}) {
+
+	if t, found := schema[SchemaType]; found {
+
+		names, _ := schemaTypeNames(t)
+
+		if !matchesSchemaType(value, names) {
+			sv.problem(path, "should be %s but is %s", strings.Join(names, " or "), describeJsonType(value))
+			return
+		}
+	}
+
+	if allowed, found := schema[SchemaEnum]; found {
+		sv.checkEnum(path, value, allowed.([]interface{}))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		sv.validateObject(path, v, schema)
+
+	case []interface{}:
+		if is, found := schema[SchemaItems]; found {
+			for i, e := range v {
+				sv.validate(fmt.Sprintf("%s[%d]", path, i), e, is.(map[string]interface{}))
+			}
+		}
+
+	case float64:
+		if min, found := schema[SchemaMinimum]; found && v < min.(float64) {
+			sv.problem(path, "must be at least %v but is %v", min, v)
+		}
+
+		if max, found := schema[SchemaMaximum]; found && v > max.(float64) {
+			sv.problem(path, "must be at most %v but is %v", max, v)
+		}
+	}
+}
+
+func (sv *schemaValidator) validateObject(path string, o map[string]interface{}, schema map[string]interface{}) {
+
+	props, _ := schema[SchemaProperties].(map[string]interface{})
+
+	if r, found := schema[SchemaRequired]; found {
+
+		required, _ := stringList(r)
+
+		for _, name := range required {
+			if _, present := o[name]; !present {
+				sv.problem(joinSchemaPath(path, name), "is required but is missing")
+			}
+		}
+	}
+
+	for k, v := range o {
+
+		p := joinSchemaPath(path, k)
+
+		if ps, found := props[k]; found {
+			sv.validate(p, v, ps.(map[string]interface{}))
+			continue
+		}
+
+		switch ap := schema[SchemaAdditionalProperties].(type) {
+		case bool:
+			if !ap {
+				sv.problem(p, "is not a recognised key%s", suggestKey(k, props))
+			}
+		case map[string]interface{}:
+			sv.validate(p, v, ap)
+		}
+	}
+}
+
+func (sv *schemaValidator) checkEnum(path string, value interface{}, allowed []interface{}) {
+
+	for _, a := range allowed {
+		if reflect.DeepEqual(a, value) {
+			return
+		}
+	}
+
+	labels := make([]string, len(allowed))
+
+	for i, a := range allowed {
+		labels[i] = fmt.Sprintf("%v", a)
+	}
+
+	sv.problem(path, "must be one of %s but is %v", strings.Join(labels, ", "), value)
+}
+
+// Finds a key in the schema's properties that differs only in case from the supplied key, to help with typos.
+func suggestKey(key string, props map[string]interface{}) string {
+
+	for k := range props {
+		if strings.EqualFold(k, key) {
+			return " (did you mean " + k + "?)"
+		}
+	}
+
+	return ""
+}
+
+func matchesSchemaType(value interface{}, names []string) bool {
+
+	for _, n := range names {
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if n == "object" {
+				return true
+			}
+		case []interface{}:
+			if n == "array" {
+				return true
+			}
+		case string:
+			if n == "string" {
+				return true
+			}
+		case bool:
+			if n == "boolean" {
+				return true
+			}
+		case float64:
+			if n == "number" || (n == "integer" && v == math.Trunc(v)) {
+				return true
+			}
+		case nil:
+			if n == "null" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func describeJsonType(value interface{}) string {
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return fmt.Sprintf("a string (%q)", v)
+	case bool:
+		return fmt.Sprintf("a boolean (%v)", v)
+	case float64:
+		return fmt.Sprintf("a number (%v)", v)
+	case nil:
+		return "null"
+	}
+
+	return fmt.Sprintf("%T", value)
+}
+
+func schemaTypeNames(v interface{}) ([]string, bool) {
+
+	if s, found := v.(string); found {
+		return []string{s}, true
+	}
+
+	return stringList(v)
+}
+
+func stringList(v interface{}) ([]string, bool) {
+
+	a, found := v.([]interface{})
+
+	if !found {
+		return nil, false
+	}
+
+	s := make([]string, len(a))
+
+	for i, e := range a {
+		if s[i], found = e.(string); !found {
+			return nil, false
+		}
+	}
+
+	return s, true
+}
+
+func joinSchemaPath(path, key string) string {
+
+	if path == "" {
+		return key
+	}
+
+	return path + JsonPathSeparator + key
+}
+
+func displayPath(path string) string {
+
+	if path == "" {
+		return "the root of the configuration"
+	}
+
+	return path
+}
@@ -0,0 +1,125 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"encoding/json"
+	"github.com/graniticio/granitic/test"
+	"strings"
+	"testing"
+)
+
+func loadBuiltIn(t *testing.T, dir string) map[string]interface{} {
+
+	files, err := FindConfigFilesInDir(test.TestFilePath("../" + dir))
+	test.ExpectNil(t, err)
+
+	jm := testMerger()
+	jm.MergeArrays = true
+	jm.SkipPlaceholders = true
+
+	m, err := jm.LoadAndMergeConfig(files)
+	test.ExpectNil(t, err)
+
+	return m
+}
+
+func parseJson(t *testing.T, s string) map[string]interface{} {
+
+	var m map[string]interface{}
+
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestBuiltInConfigMatchesBuiltInSchema(t *testing.T) {
+
+	conf := loadBuiltIn(t, "facility-config")
+	schema := loadBuiltIn(t, "facility-schema")
+
+	err := ValidateAgainstSchema(conf, schema)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf["HttpServer"].(map[string]interface{})["Prot"] = 8080.0
+	conf["HttpServer"].(map[string]interface{})["Port"] = "8080"
+	conf["System"].(map[string]interface{})["StopRetries"] = 1.5
+
+	err = ValidateAgainstSchema(conf, schema)
+	test.ExpectNotNil(t, err)
+
+	sv := err.(*SchemaViolationError)
+	test.ExpectInt(t, len(sv.Problems), 3)
+	test.ExpectString(t, sv.Problems[0], `HttpServer.Port: should be integer but is a string ("8080")`)
+	test.ExpectString(t, sv.Problems[1], "HttpServer.Prot: is not a recognised key")
+	test.ExpectString(t, sv.Problems[2], "System.StopRetries: should be integer but is a number (1.5)")
+}
+
+func TestSchemaKeywords(t *testing.T) {
+
+	schema := parseJson(t, `{
+	  "type": "object",
+	  "additionalProperties": false,
+	  "required": ["Db"],
+	  "properties": {
+	    "Db": {
+	      "type": "object",
+	      "required": ["Host", "Port"],
+	      "properties": {
+	        "Host": {"type": "string"},
+	        "Port": {"type": "integer", "minimum": 1, "maximum": 65535},
+	        "Mode": {"enum": ["rw", "ro"]},
+	        "Replicas": {"type": "array", "items": {"type": "string"}}
+	      },
+	      "additionalProperties": {"type": ["string", "null"]}
+	    },
+	    "Logging": {"type": "object"}
+	  }
+	}`)
+
+	valid := parseJson(t, `{"Db": {"Host": "h", "Port": 5432, "Mode": "ro", "Replicas": ["a"], "Extra": null}}`)
+	test.ExpectNil(t, ValidateAgainstSchema(valid, schema))
+
+	invalid := parseJson(t, `{"Db": {"port": 0, "Mode": "rx", "Replicas": ["a", 1], "Extra": 1}, "Other": true}`)
+
+	err := ValidateAgainstSchema(invalid, schema)
+	test.ExpectNotNil(t, err)
+
+	expected := []string{
+		"Db.Extra: should be string or null but is a number (1)",
+		"Db.Host: is required but is missing",
+		"Db.Mode: must be one of rw, ro but is rx",
+		"Db.Port: is required but is missing",
+		"Db.Replicas[1]: should be string but is a number (1)",
+		"Db.port: should be string or null but is a number (0)",
+		"Other: is not a recognised key",
+	}
+
+	problems := err.(*SchemaViolationError).Problems
+	test.ExpectString(t, strings.Join(problems, "\n"), strings.Join(expected, "\n"))
+
+	test.ExpectNotNil(t, ValidateAgainstSchema(parseJson(t, `{}`), schema))
+	test.ExpectNotNil(t, ValidateAgainstSchema(parseJson(t, `{"Db": {"Host": "h", "Port": 70000}}`), schema))
+}
+
+func TestInvalidSchema(t *testing.T) {
+
+	schema := parseJson(t, `{"properties": {"A": {"type": "text", "pattern": "^a"}}, "required": "A"}`)
+
+	err := ValidateAgainstSchema(map[string]interface{}{}, schema)
+	test.ExpectNotNil(t, err)
+
+	_, violation := err.(*SchemaViolationError)
+	test.ExpectBool(t, violation, false)
+
+	m := err.Error()
+	test.ExpectBool(t, strings.Contains(m, "uses unknown type text"), true)
+	test.ExpectBool(t, strings.Contains(m, "pattern at A is not a supported schema keyword"), true)
+	test.ExpectBool(t, strings.Contains(m, "required at the root of the configuration must be an array of strings"), true)
+}
@@ -0,0 +1,697 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+TomlDecoder decodes configuration in TOML format. Tables, arrays of tables, dotted keys, inline tables, arrays and all
+string, integer, float and boolean forms are supported, apart from the special float values inf and nan, which cannot
+be represented in JSON. Integers and floats become float64s (as they would if the configuration were JSON) and dates and
+times are kept as strings.
+*/
+type TomlDecoder struct{}
+
+// Decode implements ConfigDecoder.Decode
+func (td *TomlDecoder) Decode(data []byte) (map[string]interface{}, error) {
+
+	if !utf8.Valid(data) {
+		return nil, &ParseError{Message: "file is not valid UTF-8"}
+	}
+
+	p := new(tomlParser)
+	p.text = strings.Replace(string(data), "\r\n", "\n", -1)
+	p.line = 1
+	p.root = make(map[string]interface{})
+	p.current = p.root
+	p.defined = make(map[string]bool)
+
+	if err := p.parse(); err != nil {
+		return nil, &ParseError{Line: p.line, Message: err.Error()}
+	}
+
+	return p.root, nil
+}
+
+// Extensions implements ConfigDecoder.Extensions
+func (td *TomlDecoder) Extensions() []string {
+	return []string{".toml"}
+}
+
+// ContentTypes implements ConfigDecoder.ContentTypes
+func (td *TomlDecoder) ContentTypes() []string {
+	return []string{"application/toml", "text/toml", "text/x-toml"}
+}
+
+type tomlParser struct {
+	text    string
+	pos     int
+	line    int
+	root    map[string]interface{}
+	current map[string]interface{}
+
+	// Tables explicitly defined with a [header] (so they can't be defined again)
+	defined map[string]bool
+}
+
+func (p *tomlParser) parse() error {
+
+	for {
+		p.skipWhitespaceAndNewlines()
+
+		if p.eof() {
+			return nil
+		}
+
+		var err error
+
+		if p.peek() == '[' {
+			err = p.parseTableHeader()
+		} else {
+			err = p.parseKeyValue(p.current)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err = p.endOfLine(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.text)
+}
+
+func (p *tomlParser) peek() byte {
+	return p.text[p.pos]
+}
+
+func (p *tomlParser) startsWith(s string) bool {
+	return strings.HasPrefix(p.text[p.pos:], s)
+}
+
+func (p *tomlParser) advance(n int) {
+
+	for k := 0; k < n && !p.eof(); k++ {
+
+		if p.text[p.pos] == '\n' {
+			p.line++
+		}
+
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	if !p.eof() && p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+}
+
+func (p *tomlParser) skipWhitespaceAndNewlines() {
+
+	for !p.eof() {
+		p.skipSpace()
+		p.skipComment()
+
+		if p.eof() || p.peek() != '\n' {
+			return
+		}
+
+		p.advance(1)
+	}
+}
+
+// Expects only whitespace and an optional comment before the end of the line.
+func (p *tomlParser) endOfLine() error {
+
+	p.skipSpace()
+	p.skipComment()
+
+	if p.eof() {
+		return nil
+	}
+
+	if p.peek() != '\n' {
+		return errors.New(fmt.Sprintf("unexpected text '%s' (expected the end of the line)", p.restOfLine()))
+	}
+
+	p.advance(1)
+
+	return nil
+}
+
+func (p *tomlParser) restOfLine() string {
+
+	end := strings.IndexByte(p.text[p.pos:], '\n')
+
+	if end < 0 {
+		return p.text[p.pos:]
+	}
+
+	return p.text[p.pos : p.pos+end]
+}
+
+func (p *tomlParser) parseTableHeader() error {
+
+	array := p.startsWith("[[")
+
+	if array {
+		p.advance(2)
+	} else {
+		p.advance(1)
+	}
+
+	p.skipSpace()
+
+	keys, err := p.parseKey()
+
+	if err != nil {
+		return err
+	}
+
+	p.skipSpace()
+
+	closing := "]"
+
+	if array {
+		closing = "]]"
+	}
+
+	if !p.startsWith(closing) {
+		return errors.New("expected " + closing + " at the end of the table header")
+	}
+
+	p.advance(len(closing))
+
+	parent, err := p.tableAt(p.root, keys[:len(keys)-1])
+
+	if err != nil {
+		return err
+	}
+
+	last := keys[len(keys)-1]
+	name := strings.Join(keys, ".")
+
+	if array {
+		existing, found := parent[last]
+
+		var tables []interface{}
+
+		if found {
+			if tables, found = existing.([]interface{}); !found || p.defined[name] {
+				return errors.New(fmt.Sprintf("%s is already defined and is not an array of tables", name))
+			}
+		}
+
+		t := make(map[string]interface{})
+		parent[last] = append(tables, t)
+		p.current = t
+
+		return nil
+	}
+
+	if p.defined[name] {
+		return errors.New(fmt.Sprintf("table %s is defined more than once", name))
+	}
+
+	t, err := p.tableAt(parent, []string{last})
+
+	if err != nil {
+		return err
+	}
+
+	p.defined[name] = true
+	p.current = t
+
+	return nil
+}
+
+// Finds (creating if necessary) the table at the supplied keys relative to the supplied table. If a key refers to an
+// array of tables, the last table in the array is used.
+func (p *tomlParser) tableAt(t map[string]interface{}, keys []string) (map[string]interface{}, error) {
+
+	for _, k := range keys {
+
+		switch v := t[k].(type) {
+		case nil:
+			n := make(map[string]interface{})
+			t[k] = n
+			t = n
+
+		case map[string]interface{}:
+			t = v
+
+		case []interface{}:
+			if len(v) == 0 {
+				return nil, errors.New(fmt.Sprintf("%s is not a table", k))
+			}
+
+			last, found := v[len(v)-1].(map[string]interface{})
+
+			if !found {
+				return nil, errors.New(fmt.Sprintf("%s is not a table", k))
+			}
+
+			t = last
+
+		default:
+			return nil, errors.New(fmt.Sprintf("%s already has a value and cannot be used as a table", k))
+		}
+	}
+
+	return t, nil
+}
+
+func (p *tomlParser) parseKeyValue(t map[string]interface{}) error {
+
+	keys, err := p.parseKey()
+
+	if err != nil {
+		return err
+	}
+
+	p.skipSpace()
+
+	if p.eof() || p.peek() != '=' {
+		return errors.New(fmt.Sprintf("expected = after key %s", strings.Join(keys, ".")))
+	}
+
+	p.advance(1)
+	p.skipSpace()
+
+	v, err := p.parseValue()
+
+	if err != nil {
+		return err
+	}
+
+	parent, err := p.tableAt(t, keys[:len(keys)-1])
+
+	if err != nil {
+		return err
+	}
+
+	last := keys[len(keys)-1]
+
+	if _, found := parent[last]; found {
+		return errors.New(fmt.Sprintf("key %s is defined more than once", strings.Join(keys, ".")))
+	}
+
+	parent[last] = v
+
+	return nil
+}
+
+// Parses a (possibly dotted) key made up of bare and quoted parts.
+func (p *tomlParser) parseKey() ([]string, error) {
+
+	keys := make([]string, 0)
+
+	for {
+		p.skipSpace()
+
+		if p.eof() {
+			return nil, errors.New("expected a key")
+		}
+
+		var k string
+
+		switch p.peek() {
+		case '"':
+			v, err := p.parseBasicString()
+
+			if err != nil {
+				return nil, err
+			}
+
+			k = v
+
+		case '\'':
+			v, err := p.parseLiteralString()
+
+			if err != nil {
+				return nil, err
+			}
+
+			k = v
+
+		default:
+			start := p.pos
+
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+
+			if start == p.pos {
+				return nil, errors.New(fmt.Sprintf("invalid key '%s'", p.restOfLine()))
+			}
+
+			k = p.text[start:p.pos]
+		}
+
+		keys = append(keys, k)
+
+		p.skipSpace()
+
+		if p.eof() || p.peek() != '.' {
+			return keys, nil
+		}
+
+		p.advance(1)
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+
+	if p.eof() {
+		return nil, errors.New("expected a value")
+	}
+
+	switch {
+	case p.startsWith(`"""`):
+		return p.parseMultilineBasicString()
+	case p.startsWith("'''"):
+		return p.parseMultilineLiteralString()
+	case p.peek() == '"':
+		return p.parseBasicString()
+	case p.peek() == '\'':
+		return p.parseLiteralString()
+	case p.peek() == '[':
+		return p.parseArray()
+	case p.peek() == '{':
+		return p.parseInlineTable()
+	}
+
+	start := p.pos
+
+	for !p.eof() && !strings.ContainsRune(" \t\n,]}#", rune(p.peek())) {
+		p.pos++
+	}
+
+	token := p.text[start:p.pos]
+
+	// Times can contain a space between the date and time
+	if isTomlDate(token) && p.startsWith(" ") && p.pos+1 < len(p.text) && p.text[p.pos+1] >= '0' && p.text[p.pos+1] <= '9' {
+		p.pos++
+
+		for !p.eof() && !strings.ContainsRune(" \t\n,]}#", rune(p.peek())) {
+			p.pos++
+		}
+
+		token = p.text[start:p.pos]
+	}
+
+	return tomlScalar(token)
+}
+
+func tomlScalar(token string) (interface{}, error) {
+
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "inf", "+inf", "-inf", "nan", "+nan", "-nan":
+		return nil, errors.New(fmt.Sprintf("'%s' is not supported (configuration must be representable as JSON)", token))
+	case "":
+		return nil, errors.New("expected a value")
+	}
+
+	if isTomlDate(token) || strings.Count(token, ":") == 2 && len(token) >= 8 && token[2] == ':' {
+		return token, nil
+	}
+
+	n := strings.Replace(token, "_", "", -1)
+
+	if strings.HasPrefix(n, "0x") || strings.HasPrefix(n, "0o") || strings.HasPrefix(n, "0b") {
+
+		base := map[byte]int{'x': 16, 'o': 8, 'b': 2}[n[1]]
+
+		if i, err := strconv.ParseInt(n[2:], base, 64); err == nil {
+			return float64(i), nil
+		}
+
+	} else if f, err := strconv.ParseFloat(n, 64); err == nil && !strings.ContainsAny(n, "iInN") {
+
+		if digits := strings.TrimLeft(n, "+-"); len(digits) > 1 && digits[0] == '0' && digits[1] >= '0' && digits[1] <= '9' {
+			return nil, errors.New(fmt.Sprintf("invalid number '%s' (leading zeros are not allowed)", token))
+		}
+
+		return f, nil
+	}
+
+	return nil, errors.New(fmt.Sprintf("invalid value '%s' (strings must be quoted)", token))
+}
+
+func isTomlDate(token string) bool {
+	return len(token) >= 10 && token[4] == '-' && token[7] == '-'
+}
+
+func (p *tomlParser) parseArray() (interface{}, error) {
+
+	p.advance(1)
+
+	a := make([]interface{}, 0)
+
+	for {
+		p.skipWhitespaceAndNewlines()
+
+		if p.eof() {
+			return nil, errors.New("unterminated array")
+		}
+
+		if p.peek() == ']' {
+			p.advance(1)
+			return a, nil
+		}
+
+		v, err := p.parseValue()
+
+		if err != nil {
+			return nil, err
+		}
+
+		a = append(a, v)
+
+		p.skipWhitespaceAndNewlines()
+
+		if p.eof() {
+			return nil, errors.New("unterminated array")
+		}
+
+		switch p.peek() {
+		case ',':
+			p.advance(1)
+		case ']':
+		default:
+			return nil, errors.New(fmt.Sprintf("expected , or ] in array but found '%c'", p.peek()))
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (interface{}, error) {
+
+	p.advance(1)
+
+	t := make(map[string]interface{})
+
+	p.skipSpace()
+
+	if !p.eof() && p.peek() == '}' {
+		p.advance(1)
+		return t, nil
+	}
+
+	for {
+		p.skipSpace()
+
+		if err := p.parseKeyValue(t); err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+
+		if p.eof() {
+			return nil, errors.New("unterminated inline table")
+		}
+
+		switch p.peek() {
+		case ',':
+			p.advance(1)
+		case '}':
+			p.advance(1)
+			return t, nil
+		default:
+			return nil, errors.New(fmt.Sprintf("expected , or } in inline table but found '%c' (inline tables must be on a single line)", p.peek()))
+		}
+	}
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+
+	p.advance(1)
+
+	var b bytes.Buffer
+
+	for !p.eof() {
+
+		c := p.peek()
+
+		switch c {
+		case '"':
+			p.advance(1)
+			return b.String(), nil
+
+		case '\n':
+			return "", errors.New("unterminated string")
+
+		case '\\':
+			p.advance(1)
+
+			if p.eof() {
+				return "", errors.New("unterminated string")
+			}
+
+			r, size, err := unescape(p.text[p.pos:])
+
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteString(r)
+			p.advance(size)
+
+		default:
+			b.WriteByte(c)
+			p.advance(1)
+		}
+	}
+
+	return "", errors.New("unterminated string")
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+
+	p.advance(1)
+
+	end := strings.IndexAny(p.text[p.pos:], "'\n")
+
+	if end < 0 || p.text[p.pos+end] == '\n' {
+		return "", errors.New("unterminated string")
+	}
+
+	s := p.text[p.pos : p.pos+end]
+	p.advance(end + 1)
+
+	return s, nil
+}
+
+func (p *tomlParser) parseMultilineBasicString() (string, error) {
+
+	p.advance(3)
+	p.skipLeadingNewline()
+
+	var b bytes.Buffer
+
+	for !p.eof() {
+
+		if p.startsWith(`"""`) {
+			p.advance(3)
+
+			// Up to two quotes are allowed immediately before the closing delimiter
+			for k := 0; k < 2 && !p.eof() && p.peek() == '"'; k++ {
+				b.WriteByte('"')
+				p.advance(1)
+			}
+
+			return b.String(), nil
+		}
+
+		c := p.peek()
+
+		if c != '\\' {
+			b.WriteByte(c)
+			p.advance(1)
+			continue
+		}
+
+		p.advance(1)
+
+		if p.eof() {
+			break
+		}
+
+		// A backslash at the end of a line trims the newline and any whitespace that follows
+		if rest := strings.TrimLeft(p.restOfLine(), " \t"); rest == "" {
+			for !p.eof() && strings.ContainsRune(" \t\n", rune(p.peek())) {
+				p.advance(1)
+			}
+
+			continue
+		}
+
+		r, size, err := unescape(p.text[p.pos:])
+
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(r)
+		p.advance(size)
+	}
+
+	return "", errors.New("unterminated multi-line string")
+}
+
+func (p *tomlParser) parseMultilineLiteralString() (string, error) {
+
+	p.advance(3)
+	p.skipLeadingNewline()
+
+	end := strings.Index(p.text[p.pos:], "'''")
+
+	if end < 0 {
+		p.advance(len(p.text))
+		return "", errors.New("unterminated multi-line string")
+	}
+
+	// Up to two quotes are allowed immediately before the closing delimiter
+	for k := 0; k < 2 && p.pos+end+3 < len(p.text) && p.text[p.pos+end+3] == '\''; k++ {
+		end++
+	}
+
+	s := p.text[p.pos : p.pos+end]
+	p.advance(end + 3)
+
+	return s, nil
+}
+
+func (p *tomlParser) skipLeadingNewline() {
+	if !p.eof() && p.peek() == '\n' {
+		p.advance(1)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"github.com/graniticio/granitic/logging"
+	"github.com/graniticio/granitic/test"
+	"testing"
+)
+
+func TestTomlDecoding(t *testing.T) {
+
+	doc := `# Application settings
+Title = "Example"
+Count = 1_000
+Mask = 0xff
+Ratio = 2.5e-1
+Offsets = [0, -0.5]
+Enabled = true
+Started = 1979-05-27T07:32:00Z
+Escaped = "tab\there \u00e9"
+Literal = 'C:\path'
+Hosts = [
+  "alpha",  # first
+  "beta",
+]
+Point = { X = 1, Y = 2 }
+Db.Timeout = 30
+
+[Db]
+Host = "localhost"
+
+[Db.Pool]
+Size = 10
+
+[[Servers]]
+Name = "one"
+
+[[Servers]]
+Name = "two"
+"Quoted Key" = true
+
+Notes = """
+first \
+  second"""
+`
+
+	m, err := new(TomlDecoder).Decode([]byte(doc))
+	test.ExpectNil(t, err)
+
+	ca := &ConfigAccessor{m, new(logging.ConsoleErrorLogger)}
+
+	title, _ := ca.StringVal("Title")
+	test.ExpectString(t, title, "Example")
+
+	c, _ := ca.Float64Val("Count")
+	test.ExpectFloat(t, c, 1000)
+
+	mask, _ := ca.Float64Val("Mask")
+	test.ExpectFloat(t, mask, 255)
+
+	r, _ := ca.Float64Val("Ratio")
+	test.ExpectFloat(t, r, 0.25)
+
+	o, _ := ca.Array("Offsets")
+	test.ExpectInt(t, len(o), 2)
+	test.ExpectFloat(t, o[1].(float64), -0.5)
+
+	e, _ := ca.BoolVal("Enabled")
+	test.ExpectBool(t, e, true)
+
+	s, _ := ca.StringVal("Started")
+	test.ExpectString(t, s, "1979-05-27T07:32:00Z")
+
+	esc, _ := ca.StringVal("Escaped")
+	test.ExpectString(t, esc, "tab\there \u00e9")
+
+	lit, _ := ca.StringVal("Literal")
+	test.ExpectString(t, lit, `C:\path`)
+
+	hosts, _ := ca.Array("Hosts")
+	test.ExpectInt(t, len(hosts), 2)
+
+	y, _ := ca.Float64Val("Point.Y")
+	test.ExpectFloat(t, y, 2)
+
+	to, _ := ca.Float64Val("Db.Timeout")
+	test.ExpectFloat(t, to, 30)
+
+	h, _ := ca.StringVal("Db.Host")
+	test.ExpectString(t, h, "localhost")
+
+	size, _ := ca.Float64Val("Db.Pool.Size")
+	test.ExpectFloat(t, size, 10)
+
+	servers, _ := ca.Array("Servers")
+	test.ExpectInt(t, len(servers), 2)
+
+	second := servers[1].(map[string]interface{})
+	test.ExpectString(t, second["Name"].(string), "two")
+	test.ExpectBool(t, second["Quoted Key"].(bool), true)
+	test.ExpectString(t, second["Notes"].(string), "first second")
+}
+
+func TestTomlErrors(t *testing.T) {
+
+	checkLine := func(doc string, line int) {
+		_, err := new(TomlDecoder).Decode([]byte(doc))
+
+		pe, found := err.(*ParseError)
+		test.ExpectBool(t, found, true)
+
+		if found {
+			test.ExpectInt(t, pe.Line, line)
+		}
+	}
+
+	checkLine("A = 1\nA = 2\n", 2)
+	checkLine("[T]\nA = 1\n\n[T]\n", 4)
+	checkLine("A = 1\nB = unquoted\n", 2)
+	checkLine("A = 1\nB = [1, 2\n", 3)
+	checkLine("A = 1 B = 2\n", 1)
+	checkLine("A = 1\nB = \"unterminated\n", 2)
+	checkLine("A = 1\nB = inf\n", 2)
+	checkLine("A = 1\nB = -nan\n", 2)
+	checkLine("A = 1\n\nB = 01\n", 3)
+	checkLine("A = [1, -007]\n", 1)
+}
@@ -0,0 +1,831 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+YamlDecoder decodes configuration in YAML format. The subset of YAML commonly used for configuration files is supported:
+
+	block mappings and sequences (including sequences of mappings)
+	plain, single-quoted and double-quoted scalars
+	literal (|) and folded (>) block scalars
+	flow sequences and mappings written on a single line ([a, b], {a: 1})
+	comments and a single document (optionally starting with ---)
+
+Anchors, aliases, tags and multiple documents are not supported. Plain scalars are converted to the same types as JSON:
+null and ~ become null, true and false become bools and numbers become float64s. The top level of the file must be a mapping.
+*/
+type YamlDecoder struct{}
+
+// Decode implements ConfigDecoder.Decode
+func (yd *YamlDecoder) Decode(data []byte) (map[string]interface{}, error) {
+
+	if !utf8.Valid(data) {
+		return nil, &ParseError{Message: "file is not valid UTF-8"}
+	}
+
+	p, err := newYamlParser(string(data))
+
+	if err != nil {
+		return nil, err
+	}
+
+	i := p.skipBlank(0)
+
+	if i >= len(p.lines) {
+		return map[string]interface{}{}, nil
+	}
+
+	if p.isSequenceItem(i) {
+		return nil, p.errorAt(i, "the top level of the file must be a mapping")
+	}
+
+	v, next, err := p.parseMapping(i, p.lines[i].indent)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if next = p.skipBlank(next); next < len(p.lines) {
+		return nil, p.errorAt(next, "unexpected content (check indentation)")
+	}
+
+	return v, nil
+}
+
+// Extensions implements ConfigDecoder.Extensions
+func (yd *YamlDecoder) Extensions() []string {
+	return []string{".yaml", ".yml"}
+}
+
+// ContentTypes implements ConfigDecoder.ContentTypes
+func (yd *YamlDecoder) ContentTypes() []string {
+	return []string{"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"}
+}
+
+type yamlLine struct {
+	number int
+	indent int
+	raw    string
+	text   string
+}
+
+type yamlParser struct {
+	lines []*yamlLine
+}
+
+func newYamlParser(doc string) (*yamlParser, error) {
+
+	p := new(yamlParser)
+
+	documentStarted := false
+
+	for i, raw := range strings.Split(strings.Replace(doc, "\r\n", "\n", -1), "\n") {
+
+		l := new(yamlLine)
+		l.number = i + 1
+		l.raw = raw
+
+		trimmed := strings.TrimLeft(raw, " ")
+		l.indent = len(raw) - len(trimmed)
+
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, &ParseError{Line: l.number, Message: "tabs cannot be used for indentation"}
+		}
+
+		l.text = strings.TrimSpace(stripYamlComment(trimmed))
+
+		switch {
+		case l.indent == 0 && (l.text == "---" || strings.HasPrefix(l.text, "--- ")):
+			if documentStarted {
+				return nil, &ParseError{Line: l.number, Message: "multiple documents are not supported"}
+			}
+
+			documentStarted = true
+			l.text = ""
+
+		case l.indent == 0 && l.text == "...":
+			l.text = ""
+
+		case l.indent == 0 && strings.HasPrefix(l.text, "%"):
+			return nil, &ParseError{Line: l.number, Message: "directives are not supported"}
+		}
+
+		if l.text != "" {
+			documentStarted = true
+		}
+
+		p.lines = append(p.lines, l)
+	}
+
+	return p, nil
+}
+
+// Removes a comment (a # at the start of the line or preceded by whitespace, outside of quotes) from a line.
+func stripYamlComment(s string) string {
+
+	var quote byte
+
+	for i := 0; i < len(s); i++ {
+
+		r := s[i]
+
+		switch {
+		case quote == '"' && r == '\\':
+			// Skip the escaped character
+			i++
+
+		case quote == '\'' && r == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			// An escaped single quote
+			i++
+
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+
+		case r == '"' || r == '\'':
+			if i == 0 || strings.IndexByte(" \t[{,:-?", s[i-1]) >= 0 {
+				quote = r
+			}
+
+		case r == '#':
+			if i == 0 || s[i-1] == ' ' || s[i-1] == '\t' {
+				return s[:i]
+			}
+		}
+	}
+
+	return s
+}
+
+func (p *yamlParser) errorAt(i int, message string) *ParseError {
+
+	line := 0
+
+	if i < len(p.lines) {
+		line = p.lines[i].number
+	} else if len(p.lines) > 0 {
+		line = p.lines[len(p.lines)-1].number
+	}
+
+	return &ParseError{Line: line, Message: message}
+}
+
+func (p *yamlParser) skipBlank(i int) int {
+
+	for i < len(p.lines) && p.lines[i].text == "" {
+		i++
+	}
+
+	return i
+}
+
+func (p *yamlParser) isSequenceItem(i int) bool {
+	t := p.lines[i].text
+	return t == "-" || strings.HasPrefix(t, "- ")
+}
+
+// Parses the block starting at line i, which has the supplied indentation.
+func (p *yamlParser) parseBlock(i int, indent int) (interface{}, int, error) {
+
+	if p.isSequenceItem(i) {
+		return p.parseSequence(i, indent)
+	}
+
+	if _, _, isEntry := splitYamlEntry(p.lines[i].text); isEntry {
+		return p.parseMapping(i, indent)
+	}
+
+	v, err := parseYamlScalar(p.lines[i].text)
+
+	if err != nil {
+		return nil, i, p.errorAt(i, err.Error())
+	}
+
+	return v, i + 1, nil
+}
+
+func (p *yamlParser) parseMapping(i int, indent int) (map[string]interface{}, int, error) {
+
+	m := make(map[string]interface{})
+
+	for i = p.skipBlank(i); i < len(p.lines); i = p.skipBlank(i) {
+
+		l := p.lines[i]
+
+		if l.indent < indent {
+			break
+		}
+
+		if l.indent > indent {
+			return nil, i, p.errorAt(i, "unexpected indentation")
+		}
+
+		if p.isSequenceItem(i) {
+			return nil, i, p.errorAt(i, "a sequence item cannot appear in a mapping")
+		}
+
+		k, rest, isEntry := splitYamlEntry(l.text)
+
+		if !isEntry {
+			return nil, i, p.errorAt(i, "expected a 'key: value' entry")
+		}
+
+		key, err := parseYamlKey(k)
+
+		if err != nil {
+			return nil, i, p.errorAt(i, err.Error())
+		}
+
+		if _, found := m[key]; found {
+			return nil, i, p.errorAt(i, fmt.Sprintf("duplicate key '%s'", key))
+		}
+
+		var v interface{}
+
+		v, i, err = p.parseEntryValue(i, indent, rest, true)
+
+		if err != nil {
+			return nil, i, err
+		}
+
+		m[key] = v
+	}
+
+	return m, i, nil
+}
+
+func (p *yamlParser) parseSequence(i int, indent int) ([]interface{}, int, error) {
+
+	s := make([]interface{}, 0)
+
+	for i = p.skipBlank(i); i < len(p.lines); i = p.skipBlank(i) {
+
+		l := p.lines[i]
+
+		if l.indent < indent || (l.indent == indent && !p.isSequenceItem(i)) {
+			break
+		}
+
+		if l.indent > indent {
+			return nil, i, p.errorAt(i, "unexpected indentation")
+		}
+
+		rest := strings.TrimSpace(strings.TrimPrefix(l.text, "-"))
+
+		_, _, isEntry := splitYamlEntry(rest)
+
+		if rest != "" && (isEntry || rest == "-" || strings.HasPrefix(rest, "- ")) {
+			// An item that starts a nested mapping or sequence on the same line (- name: x). Treat the remainder
+			// of the line as if it started on its own line at the same indentation as its content.
+			afterDash := l.raw[l.indent+1:]
+			offset := l.indent + 1 + len(afterDash) - len(strings.TrimLeft(afterDash, " "))
+
+			l.indent = offset
+			l.text = rest
+
+			v, next, err := p.parseBlock(i, offset)
+
+			if err != nil {
+				return nil, next, err
+			}
+
+			s = append(s, v)
+			i = next
+
+			continue
+		}
+
+		v, next, err := p.parseEntryValue(i, indent, rest, false)
+
+		if err != nil {
+			return nil, next, err
+		}
+
+		s = append(s, v)
+		i = next
+	}
+
+	return s, i, nil
+}
+
+// Parses the value of a mapping entry or sequence item found on line i, where rest is the text after the key or dash.
+func (p *yamlParser) parseEntryValue(i int, indent int, rest string, inMapping bool) (interface{}, int, error) {
+
+	if strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, ">") {
+		return p.parseBlockScalar(i, indent, rest)
+	}
+
+	if rest != "" {
+		v, err := parseYamlScalar(rest)
+
+		if err != nil {
+			return nil, i, p.errorAt(i, err.Error())
+		}
+
+		return v, i + 1, nil
+	}
+
+	next := p.skipBlank(i + 1)
+
+	if next >= len(p.lines) {
+		return nil, next, nil
+	}
+
+	nl := p.lines[next]
+
+	if nl.indent > indent || (inMapping && nl.indent == indent && p.isSequenceItem(next)) {
+		return p.parseBlock(next, nl.indent)
+	}
+
+	return nil, next, nil
+}
+
+// Parses a literal (|) or folded (>) block scalar whose header is on line i.
+func (p *yamlParser) parseBlockScalar(i int, indent int, header string) (interface{}, int, error) {
+
+	folded := header[0] == '>'
+	chomp := strings.TrimSpace(header[1:])
+
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return nil, i, p.errorAt(i, "unsupported block scalar indicator "+header)
+	}
+
+	lines := make([]string, 0)
+	contentIndent := -1
+
+	j := i + 1
+
+	for ; j < len(p.lines); j++ {
+
+		l := p.lines[j]
+
+		if strings.TrimSpace(l.raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+
+		if l.indent <= indent || (contentIndent >= 0 && l.indent < contentIndent) {
+			break
+		}
+
+		if contentIndent < 0 {
+			contentIndent = l.indent
+		}
+
+		lines = append(lines, l.raw[contentIndent:])
+	}
+
+	trailing := 0
+
+	for k := len(lines) - 1; k >= 0 && lines[k] == ""; k-- {
+		trailing++
+	}
+
+	content := lines[:len(lines)-trailing]
+
+	var text string
+
+	if folded {
+		text = foldYamlLines(content)
+	} else {
+		text = strings.Join(content, "\n")
+	}
+
+	switch {
+	case len(content) == 0:
+		text = ""
+	case chomp == "+":
+		text += strings.Repeat("\n", trailing+1)
+	case chomp == "":
+		text += "\n"
+	}
+
+	return text, j - trailing, nil
+}
+
+func foldYamlLines(lines []string) string {
+
+	var b bytes.Buffer
+
+	for k, l := range lines {
+
+		if k > 0 {
+			if l == "" || lines[k-1] == "" || strings.HasPrefix(l, " ") {
+				b.WriteString("\n")
+			} else {
+				b.WriteString(" ")
+			}
+		}
+
+		b.WriteString(l)
+	}
+
+	return b.String()
+}
+
+// Splits 'key: value' into its key and value. Returns false if the text is not a mapping entry.
+func splitYamlEntry(text string) (string, string, bool) {
+
+	var quote rune
+	depth := 0
+
+	for i, r := range text {
+
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+
+		case (r == '"' || r == '\'') && i == 0:
+			quote = r
+
+		case r == '[' || r == '{':
+			if i == 0 {
+				return "", "", false
+			}
+			depth++
+
+		case r == ']' || r == '}':
+			depth--
+
+		case r == ':' && depth == 0:
+			if i+1 == len(text) || text[i+1] == ' ' {
+				return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+			}
+		}
+	}
+
+	return "", "", false
+}
+
+func parseYamlKey(k string) (string, error) {
+
+	if k == "" {
+		return "", errors.New("empty key")
+	}
+
+	if k[0] == '"' || k[0] == '\'' {
+		v, err := parseYamlScalar(k)
+
+		if err != nil {
+			return "", err
+		}
+
+		return v.(string), nil
+	}
+
+	if strings.HasPrefix(k, "? ") || k[0] == '&' || k[0] == '*' || k[0] == '!' {
+		return "", errors.New(fmt.Sprintf("unsupported key '%s' (complex keys, anchors, aliases and tags are not supported)", k))
+	}
+
+	return k, nil
+}
+
+func parseYamlScalar(text string) (interface{}, error) {
+
+	if text == "" {
+		return nil, nil
+	}
+
+	switch text[0] {
+	case '"', '\'', '[', '{':
+		fp := &yamlFlowParser{text: text}
+
+		v, err := fp.parseValue()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if fp.skipSpace(); fp.pos < len(fp.text) {
+			return nil, errors.New(fmt.Sprintf("unexpected text after value: %s", fp.text[fp.pos:]))
+		}
+
+		return v, nil
+
+	case '&', '*', '!':
+		return nil, errors.New(fmt.Sprintf("anchors, aliases and tags are not supported (%s)", text))
+
+	case '@', '`':
+		return nil, errors.New(fmt.Sprintf("plain values cannot start with %c", text[0]))
+	}
+
+	if strings.Contains(text, ": ") || strings.HasSuffix(text, ":") {
+		// e.g. a: b: c - quote the value if the colon is intended
+		return nil, errors.New(fmt.Sprintf("mapping values are not allowed in this context (%s)", text))
+	}
+
+	return plainYamlValue(text), nil
+}
+
+// Converts an unquoted scalar to a null, bool, number or string.
+func plainYamlValue(text string) interface{} {
+
+	switch text {
+	case "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+
+	if looksNumeric(text) {
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f
+		}
+
+		if strings.HasPrefix(text, "0x") {
+			if i, err := strconv.ParseInt(text[2:], 16, 64); err == nil {
+				return float64(i)
+			}
+		}
+
+		if strings.HasPrefix(text, "0o") {
+			if i, err := strconv.ParseInt(text[2:], 8, 64); err == nil {
+				return float64(i)
+			}
+		}
+	}
+
+	return text
+}
+
+func looksNumeric(text string) bool {
+
+	for _, r := range text {
+		if !strings.ContainsRune("0123456789+-.eExXoOabcdefABCDEF", r) {
+			return false
+		}
+	}
+
+	t := strings.TrimLeft(text, "+-")
+
+	return len(t) > 0 && (t[0] >= '0' && t[0] <= '9' || t[0] == '.' && len(t) > 1)
+}
+
+// Parses flow collections ([a, b] and {a: b}) and quoted scalars.
+type yamlFlowParser struct {
+	text string
+	pos  int
+}
+
+func (fp *yamlFlowParser) skipSpace() {
+	for fp.pos < len(fp.text) && (fp.text[fp.pos] == ' ' || fp.text[fp.pos] == '\t') {
+		fp.pos++
+	}
+}
+
+func (fp *yamlFlowParser) parseValue() (interface{}, error) {
+
+	fp.skipSpace()
+
+	if fp.pos >= len(fp.text) {
+		return nil, errors.New("missing value")
+	}
+
+	switch fp.text[fp.pos] {
+	case '[':
+		return fp.parseFlowSequence()
+	case '{':
+		return fp.parseFlowMapping()
+	case '"':
+		return fp.parseDoubleQuoted()
+	case '\'':
+		return fp.parseSingleQuoted()
+	}
+
+	start := fp.pos
+
+	for fp.pos < len(fp.text) && !strings.ContainsRune(",]}", rune(fp.text[fp.pos])) {
+
+		if fp.text[fp.pos] == ':' && (fp.pos+1 == len(fp.text) || strings.ContainsRune(" ,]}", rune(fp.text[fp.pos+1]))) {
+			break
+		}
+
+		fp.pos++
+	}
+
+	return plainYamlValue(strings.TrimSpace(fp.text[start:fp.pos])), nil
+}
+
+func (fp *yamlFlowParser) parseFlowSequence() (interface{}, error) {
+
+	fp.pos++
+
+	s := make([]interface{}, 0)
+
+	for {
+		fp.skipSpace()
+
+		if fp.pos >= len(fp.text) {
+			return nil, errors.New("unterminated flow sequence (flow sequences must be on a single line)")
+		}
+
+		if fp.text[fp.pos] == ']' {
+			fp.pos++
+			return s, nil
+		}
+
+		v, err := fp.parseValue()
+
+		if err != nil {
+			return nil, err
+		}
+
+		s = append(s, v)
+
+		if err := fp.endOfFlowItem(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (fp *yamlFlowParser) parseFlowMapping() (interface{}, error) {
+
+	fp.pos++
+
+	m := make(map[string]interface{})
+
+	for {
+		fp.skipSpace()
+
+		if fp.pos >= len(fp.text) {
+			return nil, errors.New("unterminated flow mapping (flow mappings must be on a single line)")
+		}
+
+		if fp.text[fp.pos] == '}' {
+			fp.pos++
+			return m, nil
+		}
+
+		k, err := fp.parseValue()
+
+		if err != nil {
+			return nil, err
+		}
+
+		key, found := k.(string)
+
+		if !found {
+			key = fmt.Sprint(k)
+		}
+
+		fp.skipSpace()
+
+		var v interface{}
+
+		if fp.pos < len(fp.text) && fp.text[fp.pos] == ':' {
+			fp.pos++
+
+			fp.skipSpace()
+
+			if fp.pos < len(fp.text) && !strings.ContainsRune(",}", rune(fp.text[fp.pos])) {
+				if v, err = fp.parseValue(); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		m[key] = v
+
+		if err := fp.endOfFlowItem('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (fp *yamlFlowParser) endOfFlowItem(closing byte) error {
+
+	fp.skipSpace()
+
+	if fp.pos >= len(fp.text) {
+		return nil
+	}
+
+	switch fp.text[fp.pos] {
+	case ',':
+		fp.pos++
+		return nil
+	case closing:
+		return nil
+	}
+
+	return errors.New(fmt.Sprintf("expected , or %c but found %c", closing, fp.text[fp.pos]))
+}
+
+func (fp *yamlFlowParser) parseSingleQuoted() (interface{}, error) {
+
+	var b bytes.Buffer
+
+	for fp.pos++; fp.pos < len(fp.text); fp.pos++ {
+
+		c := fp.text[fp.pos]
+
+		if c == '\'' {
+			if fp.pos+1 < len(fp.text) && fp.text[fp.pos+1] == '\'' {
+				b.WriteByte('\'')
+				fp.pos++
+				continue
+			}
+
+			fp.pos++
+			return b.String(), nil
+		}
+
+		b.WriteByte(c)
+	}
+
+	return nil, errors.New("unterminated single-quoted string")
+}
+
+func (fp *yamlFlowParser) parseDoubleQuoted() (interface{}, error) {
+
+	var b bytes.Buffer
+
+	for fp.pos++; fp.pos < len(fp.text); fp.pos++ {
+
+		c := fp.text[fp.pos]
+
+		switch c {
+		case '"':
+			fp.pos++
+			return b.String(), nil
+
+		case '\\':
+			fp.pos++
+
+			if fp.pos >= len(fp.text) {
+				return nil, errors.New("unterminated double-quoted string")
+			}
+
+			r, size, err := unescape(fp.text[fp.pos:])
+
+			if err != nil {
+				return nil, err
+			}
+
+			b.WriteString(r)
+			fp.pos += size - 1
+
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return nil, errors.New("unterminated double-quoted string")
+}
+
+// Interprets the escape sequence at the start of s (the character after a \). Returns the unescaped text and the
+// number of bytes of s consumed. Shared by the YAML and TOML decoders.
+func unescape(s string) (string, int, error) {
+
+	switch s[0] {
+	case 'n':
+		return "\n", 1, nil
+	case 't':
+		return "\t", 1, nil
+	case 'r':
+		return "\r", 1, nil
+	case 'b':
+		return "\b", 1, nil
+	case 'f':
+		return "\f", 1, nil
+	case '0':
+		return "\x00", 1, nil
+	case '"', '\\', '/', '\'':
+		return s[:1], 1, nil
+	case 'u', 'U':
+		l := 4
+
+		if s[0] == 'U' {
+			l = 8
+		}
+
+		if len(s) < l+1 {
+			return "", 0, errors.New(fmt.Sprintf("invalid unicode escape \\%s", s))
+		}
+
+		cp, err := strconv.ParseUint(s[1:l+1], 16, 32)
+
+		if err != nil {
+			return "", 0, errors.New(fmt.Sprintf("invalid unicode escape \\%s", s[:l+1]))
+		}
+
+		return string(rune(cp)), l + 1, nil
+	}
+
+	return "", 0, errors.New(fmt.Sprintf("unsupported escape sequence \\%c", s[0]))
+}
@@ -0,0 +1,182 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"github.com/graniticio/granitic/logging"
+	"github.com/graniticio/granitic/test"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func testMerger() *JsonMerger {
+	return NewJsonMerger(logging.CreateComponentLoggerManager(logging.Fatal, map[string]interface{}{}, []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter()))
+}
+
+func TestYamlDecoding(t *testing.T) {
+
+	doc := `# Database settings
+Db:
+  Host: localhost
+  Port: 5432
+  Enabled: true
+  Ratio: 0.5
+  Missing: ~
+  Quoted: "a: b # not a comment"
+  Single: 'it''s'
+  SingleHash: 'it''s # x' # a comment
+  DoubleHash: "say \"hi\" # x" # a comment
+Hosts:
+  - alpha
+  - beta
+Servers:
+  - Name: one
+    Port: 80
+  - Name: two
+    Port: 81
+Flow: {A: 1, B: [x, y]}
+Text: |
+  line one
+  line two
+Folded: >
+  folded
+  text
+`
+
+	m, err := new(YamlDecoder).Decode([]byte(doc))
+	test.ExpectNil(t, err)
+
+	ca := &ConfigAccessor{m, new(logging.ConsoleErrorLogger)}
+
+	h, _ := ca.StringVal("Db.Host")
+	test.ExpectString(t, h, "localhost")
+
+	p, _ := ca.Float64Val("Db.Port")
+	test.ExpectFloat(t, p, 5432)
+
+	e, _ := ca.BoolVal("Db.Enabled")
+	test.ExpectBool(t, e, true)
+
+	r, _ := ca.Float64Val("Db.Ratio")
+	test.ExpectFloat(t, r, 0.5)
+
+	test.ExpectBool(t, ca.Value("Db.Missing") == nil, true)
+
+	q, _ := ca.StringVal("Db.Quoted")
+	test.ExpectString(t, q, "a: b # not a comment")
+
+	s, _ := ca.StringVal("Db.Single")
+	test.ExpectString(t, s, "it's")
+
+	s, _ = ca.StringVal("Db.SingleHash")
+	test.ExpectString(t, s, "it's # x")
+
+	s, _ = ca.StringVal("Db.DoubleHash")
+	test.ExpectString(t, s, `say "hi" # x`)
+
+	hosts, _ := ca.Array("Hosts")
+	test.ExpectInt(t, len(hosts), 2)
+	test.ExpectString(t, hosts[1].(string), "beta")
+
+	servers, _ := ca.Array("Servers")
+	test.ExpectInt(t, len(servers), 2)
+	test.ExpectFloat(t, servers[1].(map[string]interface{})["Port"].(float64), 81)
+
+	a, _ := ca.Float64Val("Flow.A")
+	test.ExpectFloat(t, a, 1)
+
+	b, _ := ca.Array("Flow.B")
+	test.ExpectInt(t, len(b), 2)
+
+	text, _ := ca.StringVal("Text")
+	test.ExpectString(t, text, "line one\nline two\n")
+
+	folded, _ := ca.StringVal("Folded")
+	test.ExpectString(t, folded, "folded text\n")
+}
+
+func TestYamlErrorsHaveLineNumbers(t *testing.T) {
+
+	doc := `A:
+  B: 1
+ C: 2
+`
+
+	_, err := new(YamlDecoder).Decode([]byte(doc))
+	test.ExpectNotNil(t, err)
+
+	pe, found := err.(*ParseError)
+	test.ExpectBool(t, found, true)
+	test.ExpectInt(t, pe.Line, 3)
+
+	_, err = new(YamlDecoder).Decode([]byte("A: [1, 2\n"))
+	test.ExpectNotNil(t, err)
+
+	_, err = new(YamlDecoder).Decode([]byte("- a\n- b\n"))
+	test.ExpectNotNil(t, err)
+
+	_, err = new(YamlDecoder).Decode([]byte("A: 1\nB: c: d\n"))
+	test.ExpectNotNil(t, err)
+
+	pe, found = err.(*ParseError)
+	test.ExpectBool(t, found, true)
+	test.ExpectInt(t, pe.Line, 2)
+
+	m, err := new(YamlDecoder).Decode([]byte("A: 'c: d'\nB: http://example.com\n"))
+	test.ExpectNil(t, err)
+	test.ExpectString(t, m["A"].(string), "c: d")
+	test.ExpectString(t, m["B"].(string), "http://example.com")
+}
+
+func TestMixedFormatsMerged(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "formats")
+	test.ExpectNil(t, err)
+	defer os.RemoveAll(dir)
+
+	j := writeTempConfig(t, dir, "a.json", `{"Db": {"Host": "json", "Port": 1}}`)
+	y := writeTempConfig(t, dir, "b.yaml", "Db:\n  Host: yaml\n")
+	tm := writeTempConfig(t, dir, "c.toml", "[Db]\nPort = 3\n")
+	writeTempConfig(t, dir, "ignored.txt", "not configuration")
+
+	found, err := FindConfigFilesInDir(dir)
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(found), 3)
+
+	merged, err := testMerger().LoadAndMergeConfig([]string{j, y, tm})
+	test.ExpectNil(t, err)
+
+	ca := &ConfigAccessor{merged, new(logging.ConsoleErrorLogger)}
+
+	h, _ := ca.StringVal("Db.Host")
+	test.ExpectString(t, h, "yaml")
+
+	p, _ := ca.Float64Val("Db.Port")
+	test.ExpectFloat(t, p, 3)
+
+	bad := writeTempConfig(t, dir, "bad.yml", "A:\n  - 1\n  B: 2\n")
+
+	_, err = testMerger().LoadAndMergeConfig([]string{bad})
+	test.ExpectNotNil(t, err)
+}
+
+func TestURLDecoderChosenByContentType(t *testing.T) {
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-yaml; charset=utf-8")
+		w.Write([]byte("Remote:\n  Enabled: true\n"))
+	}))
+	defer s.Close()
+
+	merged, err := testMerger().LoadAndMergeConfig([]string{s.URL + "/config"})
+	test.ExpectNil(t, err)
+
+	ca := &ConfigAccessor{merged, new(logging.ConsoleErrorLogger)}
+
+	e, _ := ca.BoolVal("Remote.Enabled")
+	test.ExpectBool(t, e, true)
+}
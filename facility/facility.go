@@ -69,6 +69,13 @@ change the port on which your application's HTTP server listens on, you could ad
     }
   }
 
+The settings each facility supports (and their types) are described by a schema in:
+
+	$GRANITIC_HOME/resource/facility-schema/facilityname.json
+
+Your application will fail to start if its configuration contains a setting for a facility that is not in the schema or
+has the wrong type.
+
 */
 package facility
 
@@ -58,6 +58,8 @@ const (
 	puery
 	processTimeMicro
 	processTime
+	protocol
+	bytesSent
 )
 
 type logLineTokenType int
@@ -379,12 +381,16 @@ func (alw *AccessLogWriter) mapPlaceholder(ph string) logFormatPlaceHolder {
 		return processTimeMicro
 	case "h":
 		return remoteHost
+	case "H":
+		return protocol
 	case "i":
 		return requestHeader
 	case "l":
 		return clientId
 	case "m":
 		return method
+	case "O":
+		return bytesSent
 	case "q":
 		return puery
 	case "r":
@@ -448,9 +454,15 @@ func (alw *AccessLogWriter) findValue(element *logLineToken, req *http.Request,
 	case bytesReturned:
 		return (strconv.Itoa(res.BytesServed))
 
+	case bytesSent:
+		return (strconv.Itoa(res.BytesSent))
+
 	case remoteHost:
 		return req.RemoteAddr
 
+	case protocol:
+		return req.Proto
+
 	case clientId:
 		return hyphen
 
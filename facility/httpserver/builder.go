@@ -4,10 +4,14 @@
 package httpserver
 
 import (
+	"errors"
+	"fmt"
 	"github.com/graniticio/granitic/config"
 	"github.com/graniticio/granitic/instance"
 	"github.com/graniticio/granitic/ioc"
 	"github.com/graniticio/granitic/logging"
+	"regexp"
+	"sort"
 )
 
 // The name of the HttpServer component as stored in the IoC framework.
@@ -22,28 +26,125 @@ const HttpServerComponentName = instance.FrameworkPrefix + "HttpServer"
 const HttpServerAbnormalStatusFieldName = "AbnormalStatusWriter"
 const accessLogWriterName = instance.FrameworkPrefix + "AccessLogWriter"
 
-// Creates the components that make up the HttpServer facility (the server and an access log writer).
+// The name of the server configured directly under HttpServer in configuration. Handlers that do not declare which servers
+// they should be registered with (see httpendpoint.ServerNamesProvider) are registered with this server.
+const DefaultServerName = "default"
+
+// The configuration path of additional servers, keyed by server name.
+const additionalServersPath = "HttpServer.AdditionalServers"
+
+var validServerName = regexp.MustCompile("^[a-zA-Z0-9_]+$")
+
+// ServerComponentName returns the name of the HttpServer component in the IoC framework for the server with the supplied
+// name (HttpServerComponentName for the default server).
+func ServerComponentName(server string) string {
+
+	if server == DefaultServerName {
+		return HttpServerComponentName
+	}
+
+	return HttpServerComponentName + "-" + server
+}
+
+// ServerNames returns the names of all of the servers declared in the supplied configuration, starting with DefaultServerName.
+func ServerNames(ca *config.ConfigAccessor) []string {
+
+	names := []string{DefaultServerName}
+
+	if additional, err := ca.ObjectVal(additionalServersPath); err == nil {
+
+		others := make([]string, 0, len(additional))
+
+		for n := range additional {
+			others = append(others, n)
+		}
+
+		sort.Strings(others)
+		names = append(names, others...)
+	}
+
+	return names
+}
+
+// Creates the components that make up the HttpServer facility (one or more servers and their access log writers).
+//
+// The server configured under HttpServer is always created. Additional servers (for example an internal admin port) can
+// be declared under HttpServer.AdditionalServers:
+//
+//	{
+//	  "HttpServer": {
+//	    "Port": 8080,
+//	    "AdditionalServers": {
+//	      "admin": {
+//	        "Port": 8081,
+//	        "Address": "127.0.0.1",
+//	        "AccessLog": {"LogPath": "./admin-access.log"}
+//	      }
+//	    }
+//	  }
+//	}
+//
+// Each additional server starts with the settings of the default server and overrides them with its own. Handlers choose
+// the servers they are registered with (see handler.WsHandler.HttpServers).
 type HttpServerFacilityBuilder struct {
 }
 
 // See FacilityBuilder.BuildAndRegister
 func (hsfb *HttpServerFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cn *ioc.ComponentContainer) error {
 
-	httpServer := new(HttpServer)
-	ca.Populate("HttpServer", httpServer)
+	names := ServerNames(ca)
+	logPaths := make(map[string]string)
 
-	cn.WrapAndAddProto(HttpServerComponentName, httpServer)
+	for _, name := range names {
 
-	if !httpServer.AccessLogging {
-		return nil
-	}
+		if !validServerName.MatchString(name) {
+			m := fmt.Sprintf("%s is not a valid name for an HTTP server (names may only contain letters, numbers and _)", name)
+			return errors.New(m)
+		}
+
+		httpServer := new(HttpServer)
+		ca.Populate("HttpServer", httpServer)
+
+		var serverPath string
+
+		if name != DefaultServerName {
+			serverPath = additionalServersPath + "." + name
+			ca.Populate(serverPath, httpServer)
+		}
+
+		httpServer.Name = name
+		httpServer.knownServers = names
 
-	accessLogWriter := new(AccessLogWriter)
-	ca.Populate("HttpServer.AccessLog", accessLogWriter)
+		cn.WrapAndAddProto(ServerComponentName(name), httpServer)
 
-	httpServer.AccessLogWriter = accessLogWriter
+		if !httpServer.AccessLogging {
+			continue
+		}
 
-	cn.WrapAndAddProto(accessLogWriterName, accessLogWriter)
+		accessLogWriter := new(AccessLogWriter)
+		ca.Populate("HttpServer.AccessLog", accessLogWriter)
+
+		if serverPath != "" && ca.PathExists(serverPath+".AccessLog") {
+			ca.Populate(serverPath+".AccessLog", accessLogWriter)
+		}
+
+		if other, found := logPaths[accessLogWriter.LogPath]; found {
+			m := fmt.Sprintf("HTTP servers %s and %s are both configured to write an access log to %s", other, name, accessLogWriter.LogPath)
+			return errors.New(m)
+		}
+
+		logPaths[accessLogWriter.LogPath] = name
+
+		httpServer.AccessLogWriter = accessLogWriter
+
+		writerName := accessLogWriterName
+
+		if name != DefaultServerName {
+			writerName += "-" + name
+		}
+
+		cn.WrapAndAddProto(writerName, accessLogWriter)
+	}
 
 	return nil
 
@@ -0,0 +1,109 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"encoding/json"
+	"github.com/graniticio/granitic/config"
+	"github.com/graniticio/granitic/instance"
+	"github.com/graniticio/granitic/ioc"
+	"github.com/graniticio/granitic/logging"
+	"github.com/graniticio/granitic/test"
+	"testing"
+)
+
+func buildServers(t *testing.T, conf string) (*ioc.ComponentContainer, error) {
+
+	var data map[string]interface{}
+	test.ExpectNil(t, json.Unmarshal([]byte(conf), &data))
+
+	ca := new(config.ConfigAccessor)
+	ca.JsonData = data
+	ca.FrameworkLogger = new(logging.ConsoleErrorLogger)
+
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, map[string]interface{}{}, []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter())
+	cc := ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+	return cc, new(HttpServerFacilityBuilder).BuildAndRegister(lm, ca, cc)
+}
+
+func server(cc *ioc.ComponentContainer, name string) *HttpServer {
+
+	p := cc.ProtoComponents()[ServerComponentName(name)]
+
+	if p == nil {
+		return nil
+	}
+
+	return p.Component.Instance.(*HttpServer)
+}
+
+func TestAdditionalServers(t *testing.T) {
+
+	conf := `{"HttpServer": {"Port": 8080, "MaxConcurrent": 10, "AccessLogging": true, "AccessLog": {"LogPath": "a.log", "LogLinePreset": "framework"},
+		"AdditionalServers": {"admin": {"Port": 8081, "Address": "127.0.0.1", "AccessLog": {"LogPath": "admin.log"}}, "metrics": {"Port": 8082, "AccessLogging": false}}}}`
+
+	cc, err := buildServers(t, conf)
+	test.ExpectNil(t, err)
+
+	def := server(cc, DefaultServerName)
+	admin := server(cc, "admin")
+	metrics := server(cc, "metrics")
+
+	test.ExpectString(t, def.Name, DefaultServerName)
+	test.ExpectInt(t, def.Port, 8080)
+	test.ExpectString(t, def.AccessLogWriter.LogPath, "a.log")
+
+	test.ExpectString(t, admin.Name, "admin")
+	test.ExpectInt(t, admin.Port, 8081)
+	test.ExpectString(t, admin.Address, "127.0.0.1")
+	test.ExpectInt(t, int(admin.MaxConcurrent), 10)
+	test.ExpectString(t, admin.AccessLogWriter.LogPath, "admin.log")
+	test.ExpectString(t, admin.AccessLogWriter.LogLinePreset, "framework")
+
+	test.ExpectInt(t, metrics.Port, 8082)
+	test.ExpectBool(t, metrics.AccessLogWriter == nil, true)
+
+	test.ExpectNotNil(t, cc.ProtoComponents()[accessLogWriterName+"-admin"])
+
+	_, err = buildServers(t, `{"HttpServer": {"AccessLogging": true, "AccessLog": {"LogPath": "a.log"}, "AdditionalServers": {"admin": {"Port": 1}}}}`)
+	test.ExpectNotNil(t, err)
+
+	_, err = buildServers(t, `{"HttpServer": {"AdditionalServers": {"ad.min": {"Port": 1}}}}`)
+	test.ExpectNotNil(t, err)
+}
+
+type assignedProvider struct {
+	*testProvider
+	servers []string
+}
+
+func (ap *assignedProvider) ServerNames() []string {
+	return ap.servers
+}
+
+func TestServerAssignment(t *testing.T) {
+
+	unassigned := template("unassigned", "/a", "GET")
+	adminOnly := &assignedProvider{template("adminOnly", "/b", "GET"), []string{"admin"}}
+	both := &assignedProvider{template("both", "/c", "GET"), []string{"admin", DefaultServerName}}
+	unknown := &assignedProvider{template("unknown", "/d", "GET"), []string{"nope"}}
+
+	def := &HttpServer{Name: DefaultServerName, knownServers: []string{DefaultServerName, "admin"}}
+	admin := &HttpServer{Name: "admin"}
+	unnamed := new(HttpServer)
+
+	test.ExpectBool(t, def.assignedTo(unassigned), true)
+	test.ExpectBool(t, def.assignedTo(adminOnly), false)
+	test.ExpectBool(t, def.assignedTo(both), true)
+	test.ExpectBool(t, unnamed.assignedTo(unassigned), true)
+
+	test.ExpectBool(t, admin.assignedTo(unassigned), false)
+	test.ExpectBool(t, admin.assignedTo(adminOnly), true)
+	test.ExpectBool(t, admin.assignedTo(both), true)
+
+	test.ExpectNil(t, def.checkServerNames(both))
+	test.ExpectNotNil(t, def.checkServerNames(unknown))
+	test.ExpectNil(t, admin.checkServerNames(unknown))
+}
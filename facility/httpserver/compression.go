@@ -0,0 +1,284 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/httpendpoint"
+	"github.com/graniticio/granitic/logging"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Values for CompressionSettings.Encodings
+const (
+	GzipEncoding    = "gzip"
+	DeflateEncoding = "deflate"
+)
+
+// The name under which the compression filter is registered with a server.
+const compressionFilterName = "compression"
+
+/*
+CompressionSettings controls whether an HttpServer compresses responses for clients that accept compressed responses
+(via the Accept-Encoding header). Normally set in configuration:
+
+	{
+	  "HttpServer": {
+	    "Compression": {
+	      "Enabled": true,
+	      "MinSizeBytes": 1024,
+	      "ContentTypes": ["application/json", "text/"]
+	    }
+	  }
+	}
+
+A response is only compressed if its body is at least MinSizeBytes long, its Content-Type matches one of ContentTypes
+and it does not already have a Content-Encoding. gzip and deflate are supported (brotli is not, as Go's standard
+library has no brotli encoder).
+
+The access log's %b and %B placeholders record the size of the response before compression and the %O placeholder
+records the number of bytes actually sent.
+*/
+type CompressionSettings struct {
+	// Whether or not responses should be compressed.
+	Enabled bool
+
+	// The smallest response body (in bytes) that will be compressed.
+	MinSizeBytes int
+
+	// The content types that may be compressed. An entry ending in / (e.g. text/) matches all subtypes.
+	ContentTypes []string
+
+	// The encodings the server supports in order of preference (gzip, deflate).
+	Encodings []string
+
+	// The compression level from -2 (Huffman only) and -1 (default) to 9 (best compression). See compress/flate
+	Level int
+}
+
+func (cs *CompressionSettings) validate() error {
+
+	if len(cs.Encodings) == 0 {
+		return errors.New("HttpServer.Compression.Encodings must contain at least one encoding")
+	}
+
+	for _, e := range cs.Encodings {
+		if e != GzipEncoding && e != DeflateEncoding {
+			return errors.New(fmt.Sprintf("HttpServer.Compression.Encodings contains unsupported encoding %s (use %s or %s)", e, GzipEncoding, DeflateEncoding))
+		}
+	}
+
+	if cs.Level < flate.HuffmanOnly || cs.Level > flate.BestCompression {
+		return errors.New(fmt.Sprintf("HttpServer.Compression.Level must be between %d and %d (was %d)", flate.HuffmanOnly, flate.BestCompression, cs.Level))
+	}
+
+	return nil
+}
+
+// Returns true if the supplied Content-Type header may be compressed.
+func (cs *CompressionSettings) compressible(contentType string) bool {
+
+	mt := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+
+	for _, ct := range cs.ContentTypes {
+
+		ct = strings.ToLower(ct)
+
+		if mt == ct || (strings.HasSuffix(ct, "/") && strings.HasPrefix(mt, ct)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Chooses the server's most preferred encoding that is acceptable to the client (or an empty string if none are).
+func (cs *CompressionSettings) negotiate(acceptEncoding string) string {
+
+	accepted := make(map[string]bool)
+	wildcard := false
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+
+		for _, p := range fields[1:] {
+
+			p = strings.TrimSpace(p)
+
+			if strings.HasPrefix(p, "q=") {
+				if v, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		if coding == "*" {
+			wildcard = q > 0
+		} else if coding != "" {
+			accepted[coding] = q > 0
+		}
+	}
+
+	for _, e := range cs.Encodings {
+
+		if ok, listed := accepted[e]; (listed && ok) || (!listed && wildcard) {
+			return e
+		}
+	}
+
+	return ""
+}
+
+// Compresses responses if the client accepts a supported encoding.
+type compressionFilter struct {
+	settings *CompressionSettings
+	log      logging.Logger
+}
+
+// Filter implements httpendpoint.HttpFilter
+func (cf *compressionFilter) Filter(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request, next httpendpoint.FilterChain) context.Context {
+
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	encoding := cf.settings.negotiate(req.Header.Get("Accept-Encoding"))
+
+	if encoding == "" {
+		return next(ctx, w, req)
+	}
+
+	var cw *compressingWriter
+
+	w.Wrap(func(rw http.ResponseWriter) http.ResponseWriter {
+		cw = &compressingWriter{rw: rw, settings: cf.settings, encoding: encoding}
+		return cw
+	})
+
+	ctx = next(ctx, w, req)
+
+	if err := cw.close(); err != nil {
+		cf.log.LogDebugf("Unable to complete compressed response: %s", err.Error())
+	}
+
+	return ctx
+}
+
+// Buffers the start of a response until it is known whether or not the response should be compressed.
+type compressingWriter struct {
+	rw         http.ResponseWriter
+	settings   *CompressionSettings
+	encoding   string
+	status     int
+	buffer     []byte
+	decided    bool
+	compressor io.WriteCloser
+}
+
+func (cw *compressingWriter) Header() http.Header {
+	return cw.rw.Header()
+}
+
+func (cw *compressingWriter) WriteHeader(status int) {
+
+	if cw.decided || cw.status != 0 {
+		return
+	}
+
+	cw.status = status
+
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressingWriter) Write(b []byte) (int, error) {
+
+	if !cw.decided {
+
+		cw.buffer = append(cw.buffer, b...)
+
+		if len(cw.buffer) >= cw.settings.MinSizeBytes {
+			if err := cw.decide(true); err != nil {
+				return 0, err
+			}
+		}
+
+		return len(b), nil
+	}
+
+	if cw.compressor != nil {
+		return cw.compressor.Write(b)
+	}
+
+	return cw.rw.Write(b)
+}
+
+// Starts compressing (if allowed) and sends the headers and any buffered data.
+func (cw *compressingWriter) decide(compress bool) error {
+
+	cw.decided = true
+
+	h := cw.rw.Header()
+
+	if compress && h.Get("Content-Encoding") == "" {
+
+		ct := h.Get("Content-Type")
+
+		if ct == "" {
+			ct = http.DetectContentType(cw.buffer)
+		}
+
+		if cw.settings.compressible(ct) {
+
+			h.Set("Content-Encoding", cw.encoding)
+			h.Del("Content-Length")
+
+			if cw.encoding == GzipEncoding {
+				cw.compressor, _ = gzip.NewWriterLevel(cw.rw, cw.settings.Level)
+			} else {
+				cw.compressor, _ = flate.NewWriter(cw.rw, cw.settings.Level)
+			}
+		}
+	}
+
+	if cw.status != 0 {
+		cw.rw.WriteHeader(cw.status)
+	}
+
+	if len(cw.buffer) == 0 {
+		return nil
+	}
+
+	buffered := cw.buffer
+	cw.buffer = nil
+
+	_, err := cw.Write(buffered)
+
+	return err
+}
+
+// Sends anything still buffered and finishes the compressed stream.
+func (cw *compressingWriter) close() error {
+
+	if !cw.decided {
+		if err := cw.decide(false); err != nil {
+			return err
+		}
+	}
+
+	if cw.compressor != nil {
+		return cw.compressor.Close()
+	}
+
+	return nil
+}
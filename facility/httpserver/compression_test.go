@@ -0,0 +1,123 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"github.com/graniticio/granitic/httpendpoint"
+	"github.com/graniticio/granitic/test"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sizedProvider struct {
+	*testProvider
+	contentType string
+	body        string
+}
+
+func (sp *sizedProvider) ServeHttp(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request) context.Context {
+	w.Header().Set("Content-Type", sp.contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(sp.body))
+
+	return ctx
+}
+
+func compressionSettings() *CompressionSettings {
+	return &CompressionSettings{Enabled: true, MinSizeBytes: 100, ContentTypes: []string{"application/json", "text/"},
+		Encodings: []string{GzipEncoding, DeflateEncoding}, Level: -1}
+}
+
+func TestResponseCompression(t *testing.T) {
+
+	big := strings.Repeat(`{"a": "b"}`, 100)
+
+	json := &sizedProvider{template("json", "/json", "GET"), "application/json; charset=utf-8", big}
+	small := &sizedProvider{template("small", "/small", "GET"), "application/json", "{}"}
+	image := &sizedProvider{template("image", "/image", "GET"), "image/png", big}
+
+	h := filteredServer(t, nil, json, small, image)
+	h.Compression = compressionSettings()
+	test.ExpectNil(t, h.findFilters())
+
+	get := func(path, acceptEncoding string) (*httptest.ResponseRecorder, *httpendpoint.HttpResponseWriter) {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+
+		rec := httptest.NewRecorder()
+		wrw := httpendpoint.NewHttpResponseWriter(rec)
+
+		chainFrom(h.filters, 0, h.dispatch)(context.Background(), wrw, req)
+
+		return rec, wrw
+	}
+
+	rec, wrw := get("/json", "gzip, deflate")
+	test.ExpectString(t, rec.Header().Get("Content-Encoding"), GzipEncoding)
+	test.ExpectString(t, rec.Header().Get("Vary"), "Accept-Encoding")
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+
+	gr, err := gzip.NewReader(rec.Body)
+	test.ExpectNil(t, err)
+	b, _ := ioutil.ReadAll(gr)
+	test.ExpectString(t, string(b), big)
+
+	test.ExpectInt(t, wrw.BytesServed, len(big))
+	test.ExpectBool(t, wrw.BytesSent > 0 && wrw.BytesSent < wrw.BytesServed, true)
+
+	alw := new(AccessLogWriter)
+	test.ExpectNil(t, alw.parseFormat("%B %O"))
+
+	now := time.Now()
+	line := strings.TrimSpace(alw.buildLine(httptest.NewRequest("GET", "/json", nil), wrw, &now, &now, context.Background()))
+	test.ExpectString(t, line, fmt.Sprintf("%d %d", len(big), wrw.BytesSent))
+
+	rec, _ = get("/json", "gzip;q=0, deflate")
+	test.ExpectString(t, rec.Header().Get("Content-Encoding"), DeflateEncoding)
+
+	b, _ = ioutil.ReadAll(flate.NewReader(rec.Body))
+	test.ExpectString(t, string(b), big)
+
+	rec, wrw = get("/small", "gzip")
+	test.ExpectString(t, rec.Header().Get("Content-Encoding"), "")
+	test.ExpectString(t, rec.Body.String(), "{}")
+	test.ExpectInt(t, wrw.BytesSent, 2)
+
+	rec, _ = get("/image", "gzip")
+	test.ExpectString(t, rec.Header().Get("Content-Encoding"), "")
+	test.ExpectInt(t, rec.Body.Len(), len(big))
+
+	rec, _ = get("/json", "br")
+	test.ExpectString(t, rec.Header().Get("Content-Encoding"), "")
+}
+
+func TestEncodingNegotiation(t *testing.T) {
+
+	cs := compressionSettings()
+
+	test.ExpectString(t, cs.negotiate(""), "")
+	test.ExpectString(t, cs.negotiate("deflate, gzip"), GzipEncoding)
+	test.ExpectString(t, cs.negotiate("deflate"), DeflateEncoding)
+	test.ExpectString(t, cs.negotiate("*"), GzipEncoding)
+	test.ExpectString(t, cs.negotiate("*, gzip;q=0"), DeflateEncoding)
+	test.ExpectString(t, cs.negotiate("identity"), "")
+
+	test.ExpectBool(t, cs.compressible("text/html; charset=utf-8"), true)
+	test.ExpectBool(t, cs.compressible("application/xml"), false)
+
+	cs.Encodings = []string{"br"}
+	test.ExpectNotNil(t, cs.validate())
+
+	cs = compressionSettings()
+	cs.Level = 10
+	test.ExpectNotNil(t, cs.validate())
+}
@@ -0,0 +1,84 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"context"
+	"github.com/graniticio/granitic/httpendpoint"
+	"github.com/graniticio/granitic/ws"
+	"net/http"
+)
+
+// The name under which the CORS filter is registered with a server.
+const corsFilterName = "cors"
+
+// Applies the server's CORS policy (or an endpoint's own policy). Answers preflight requests for paths that have been
+// registered with the server and adds CORS headers to other responses via ws.AddContextHeaders.
+type corsFilter struct {
+	server *HttpServer
+}
+
+// Filter implements httpendpoint.HttpFilter
+func (cf *corsFilter) Filter(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request, next httpendpoint.FilterChain) context.Context {
+
+	origin := req.Header.Get(httpendpoint.OriginHeader)
+
+	if origin == "" {
+		return next(ctx, w, req)
+	}
+
+	h := cf.server
+	path := req.URL.Path
+	rr := h.routeFor(ctx, req)
+
+	if httpendpoint.IsPreflight(req) {
+
+		provider, supported := rr.preflightProvider, rr.preflightAllowed
+
+		if provider != nil {
+			supported = provider.SupportedHttpMethods()
+		}
+
+		policy := h.corsPolicy(provider)
+
+		if policy == nil || (provider == nil && len(supported) == 0) {
+			return next(ctx, w, req)
+		}
+
+		if headers := policy.PreflightHeaders(req, supported); headers != nil {
+			for k, v := range headers {
+				w.Header().Set(k, v)
+			}
+		} else {
+			h.FrameworkLogger.LogDebugf("Rejected CORS preflight request from %s for %s %s", origin, req.Header.Get(httpendpoint.AccessControlRequestMethodHeader), path)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+		return ctx
+	}
+
+	if policy := h.corsPolicy(rr.provider); policy != nil {
+		if headers := policy.ResponseHeaders(origin); headers != nil {
+			ctx = ws.AddContextHeaders(ctx, headers)
+		}
+	}
+
+	return next(ctx, w, req)
+}
+
+// Returns the CORS policy of the supplied provider (which may be nil) or, if it does not have one, the server's policy.
+// Returns nil if neither policy is enabled.
+func (h *HttpServer) corsPolicy(p httpendpoint.HttpEndpointProvider) *httpendpoint.CORSPolicy {
+
+	if cpp, found := p.(httpendpoint.CORSPolicyProvider); found && cpp.CORSPolicy() != nil {
+		return cpp.CORSPolicy()
+	}
+
+	if h.CORS != nil && h.CORS.Enabled {
+		return h.CORS
+	}
+
+	return nil
+}
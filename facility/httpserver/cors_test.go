@@ -0,0 +1,110 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"context"
+	"github.com/graniticio/granitic/httpendpoint"
+	"github.com/graniticio/granitic/test"
+	"github.com/graniticio/granitic/ws"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type corsProvider struct {
+	*testProvider
+	policy *httpendpoint.CORSPolicy
+}
+
+func (cp *corsProvider) CORSPolicy() *httpendpoint.CORSPolicy {
+	return cp.policy
+}
+
+func (cp *corsProvider) ServeHttp(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request) context.Context {
+	ws.WriteHeaders(w, ws.ContextHeaders(ctx))
+	w.WriteHeader(http.StatusOK)
+
+	return ctx
+}
+
+func corsRequest(method, path, origin string) *http.Request {
+
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set(httpendpoint.OriginHeader, origin)
+
+	return req
+}
+
+func TestCORSFilter(t *testing.T) {
+
+	public := &httpendpoint.CORSPolicy{Enabled: true, AllowedOrigins: []string{"*"}}
+	test.ExpectNil(t, public.Compile())
+
+	record := &corsProvider{testProvider: template("record", "/record/{id:int}", "GET", "PUT")}
+	open := &corsProvider{testProvider: template("open", "/open", "GET"), policy: public}
+
+	h := filteredServer(t, nil, record, open)
+	h.CORS = &httpendpoint.CORSPolicy{Enabled: true, AllowedOrigins: []string{"https://*.example.com"}, MaxAgeSeconds: 60}
+	test.ExpectNil(t, h.findFilters())
+
+	// Preflight
+	req := corsRequest("OPTIONS", "/record/1", "https://app.example.com")
+	req.Header.Set(httpendpoint.AccessControlRequestMethodHeader, "PUT")
+
+	rec := httptest.NewRecorder()
+	h.handleAll(rec, req)
+
+	test.ExpectInt(t, rec.Code, http.StatusNoContent)
+	test.ExpectString(t, rec.Header().Get(httpendpoint.AccessControlAllowOriginHeader), "https://app.example.com")
+	test.ExpectString(t, rec.Header().Get(httpendpoint.AccessControlAllowMethodsHeader), "GET, PUT")
+	test.ExpectString(t, rec.Header().Get(httpendpoint.AccessControlMaxAgeHeader), "60")
+	test.ExpectInt(t, record.served, 0)
+
+	// Preflight from an origin that is not allowed
+	req = corsRequest("OPTIONS", "/record/1", "https://evil.org")
+	req.Header.Set(httpendpoint.AccessControlRequestMethodHeader, "PUT")
+
+	rec = httptest.NewRecorder()
+	h.handleAll(rec, req)
+
+	test.ExpectInt(t, rec.Code, http.StatusNoContent)
+	test.ExpectString(t, rec.Header().Get(httpendpoint.AccessControlAllowOriginHeader), "")
+
+	// Preflight for an unregistered path
+	req = corsRequest("OPTIONS", "/nothing", "https://app.example.com")
+	req.Header.Set(httpendpoint.AccessControlRequestMethodHeader, "GET")
+
+	rec = httptest.NewRecorder()
+	h.handleAll(rec, req)
+
+	test.ExpectInt(t, rec.Code, http.StatusNotFound)
+
+	// Actual request
+	rec = httptest.NewRecorder()
+	h.handleAll(rec, corsRequest("GET", "/record/1", "https://app.example.com"))
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectString(t, rec.Header().Get(httpendpoint.AccessControlAllowOriginHeader), "https://app.example.com")
+
+	// Endpoint's own policy
+	rec = httptest.NewRecorder()
+	h.handleAll(rec, corsRequest("GET", "/open", "https://elsewhere.org"))
+
+	test.ExpectString(t, rec.Header().Get(httpendpoint.AccessControlAllowOriginHeader), "*")
+}
+
+func TestEndpointCORSWithoutServerPolicy(t *testing.T) {
+
+	public := &httpendpoint.CORSPolicy{Enabled: true, AllowedOrigins: []string{"*"}}
+	test.ExpectNil(t, public.Compile())
+
+	h := filteredServer(t, nil, &corsProvider{testProvider: template("open", "/open", "GET"), policy: public})
+
+	test.ExpectInt(t, len(h.filters), 1)
+	test.ExpectString(t, h.filters[0].name, corsFilterName)
+
+	h = filteredServer(t, nil, template("plain", "/plain", "GET"))
+	test.ExpectInt(t, len(h.filters), 0)
+}
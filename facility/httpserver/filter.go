@@ -0,0 +1,82 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/httpendpoint"
+	"net/http"
+	"regexp"
+	"sort"
+)
+
+// An httpendpoint.HttpFilter registered with a server.
+type registeredFilter struct {
+	name    string
+	filter  httpendpoint.HttpFilter
+	order   int
+	pattern *regexp.Regexp
+}
+
+// Returns true if the filter should be applied to a request for the supplied path.
+func (rf *registeredFilter) appliesTo(path string) bool {
+	return rf.pattern == nil || rf.pattern.MatchString(path)
+}
+
+func newRegisteredFilter(name string, f httpendpoint.HttpFilter) (*registeredFilter, error) {
+
+	rf := new(registeredFilter)
+	rf.name = name
+	rf.filter = f
+
+	if of, found := f.(httpendpoint.OrderedFilter); found {
+		rf.order = of.FilterOrder()
+	}
+
+	if pp, found := f.(httpendpoint.FilterPatternProvider); found && pp.FilterPattern() != "" {
+
+		re, err := regexp.Compile(pp.FilterPattern())
+
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("%s: unable to compile filter pattern %s: %s", name, pp.FilterPattern(), err.Error()))
+		}
+
+		rf.pattern = re
+	}
+
+	return rf, nil
+}
+
+// Sorts filters by order then by name so the sequence in which filters are applied does not depend on the order in which
+// components were found.
+func sortFilters(filters []*registeredFilter) {
+	sort.SliceStable(filters, func(i, j int) bool {
+
+		if filters[i].order != filters[j].order {
+			return filters[i].order < filters[j].order
+		}
+
+		return filters[i].name < filters[j].name
+	})
+}
+
+// Returns a chain that applies the filters from position i onwards (skipping those that do not apply to the request's
+// path) before passing the request to final.
+func chainFrom(filters []*registeredFilter, i int, final httpendpoint.FilterChain) httpendpoint.FilterChain {
+
+	return func(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request) context.Context {
+
+		for i < len(filters) && !filters[i].appliesTo(req.URL.Path) {
+			i++
+		}
+
+		if i == len(filters) {
+			return final(ctx, w, req)
+		}
+
+		return filters[i].filter.Filter(ctx, w, req, chainFrom(filters, i+1, final))
+	}
+}
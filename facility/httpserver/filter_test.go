@@ -0,0 +1,208 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"context"
+	"github.com/graniticio/granitic/httpendpoint"
+	"github.com/graniticio/granitic/ioc"
+	"github.com/graniticio/granitic/logging"
+	"github.com/graniticio/granitic/test"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testFilter struct {
+	name    string
+	order   int
+	pattern string
+	calls   *[]string
+	block   bool
+}
+
+func (tf *testFilter) Filter(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request, next httpendpoint.FilterChain) context.Context {
+
+	*tf.calls = append(*tf.calls, tf.name)
+
+	if tf.block {
+		w.WriteHeader(http.StatusForbidden)
+		return ctx
+	}
+
+	ctx = next(ctx, w, req)
+
+	*tf.calls = append(*tf.calls, "/"+tf.name)
+
+	return ctx
+}
+
+func (tf *testFilter) FilterOrder() int {
+	return tf.order
+}
+
+func (tf *testFilter) FilterPattern() string {
+	return tf.pattern
+}
+
+type upperWriter struct {
+	http.ResponseWriter
+}
+
+func (uw *upperWriter) Write(b []byte) (int, error) {
+	return uw.ResponseWriter.Write([]byte(strings.ToUpper(string(b))))
+}
+
+type wrappingFilter struct{}
+
+func (wf *wrappingFilter) Filter(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request, next httpendpoint.FilterChain) context.Context {
+
+	w.Wrap(func(rw http.ResponseWriter) http.ResponseWriter {
+		return &upperWriter{rw}
+	})
+
+	return next(ctx, w, req)
+}
+
+type bodyProvider struct {
+	*testProvider
+}
+
+func (bp *bodyProvider) ServeHttp(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request) context.Context {
+	w.Write([]byte("hello"))
+
+	return ctx
+}
+
+func filteredServer(t *testing.T, filters map[string]httpendpoint.HttpFilter, providers ...httpendpoint.HttpEndpointProvider) *HttpServer {
+
+	h := new(HttpServer)
+	h.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	h.AbnormalStatusWriter = new(statusWriter)
+	h.router = newRouter()
+	h.state = ioc.RunningState
+
+	for _, p := range providers {
+		test.ExpectNil(t, h.registerProvider(p))
+	}
+
+	h.SetFiltersManually(filters)
+	test.ExpectNil(t, h.findFilters())
+
+	return h
+}
+
+func TestFilterOrderAndPatterns(t *testing.T) {
+
+	calls := make([]string, 0)
+
+	filters := map[string]httpendpoint.HttpFilter{
+		"b":     &testFilter{name: "b", calls: &calls},
+		"a":     &testFilter{name: "a", calls: &calls},
+		"first": &testFilter{name: "first", order: -10, calls: &calls},
+		"admin": &testFilter{name: "admin", order: 5, pattern: "^/admin", calls: &calls, block: true},
+	}
+
+	p := template("p", "/record/{id:int}", "GET")
+	h := filteredServer(t, filters, p)
+
+	rec := httptest.NewRecorder()
+	h.handleAll(rec, httptest.NewRequest("GET", "/record/1", nil))
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectInt(t, p.served, 1)
+	test.ExpectString(t, strings.Join(calls, ","), "first,a,b,/b,/a,/first")
+
+	// Filters are applied to requests that do not match an endpoint
+	calls = calls[:0]
+	rec = httptest.NewRecorder()
+	h.handleAll(rec, httptest.NewRequest("GET", "/other", nil))
+
+	test.ExpectInt(t, rec.Code, http.StatusNotFound)
+	test.ExpectString(t, strings.Join(calls, ","), "first,a,b,/b,/a,/first")
+
+	// Short-circuit
+	calls = calls[:0]
+	rec = httptest.NewRecorder()
+	h.handleAll(rec, httptest.NewRequest("GET", "/admin/x", nil))
+
+	test.ExpectInt(t, rec.Code, http.StatusForbidden)
+	test.ExpectString(t, strings.Join(calls, ","), "first,a,b,admin,/b,/a,/first")
+}
+
+func TestFilterWrapsResponse(t *testing.T) {
+
+	filters := map[string]httpendpoint.HttpFilter{"upper": new(wrappingFilter)}
+
+	h := filteredServer(t, filters, &bodyProvider{template("p", "/", "GET")})
+
+	rec := httptest.NewRecorder()
+	h.handleAll(rec, httptest.NewRequest("GET", "/", nil))
+
+	test.ExpectString(t, rec.Body.String(), "HELLO")
+}
+
+func TestInvalidFilterPattern(t *testing.T) {
+
+	calls := make([]string, 0)
+
+	h := new(HttpServer)
+	h.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	h.SetFiltersManually(map[string]httpendpoint.HttpFilter{"bad": &testFilter{name: "bad", pattern: "(", calls: &calls}})
+
+	test.ExpectNotNil(t, h.findFilters())
+}
+
+type countingExtractor struct {
+	calls int
+}
+
+func (ce *countingExtractor) Extract(req *http.Request) httpendpoint.RequiredVersion {
+	ce.calls++
+
+	return httpendpoint.RequiredVersion{"v": req.Header.Get("X-Version")}
+}
+
+type rewritingFilter struct{}
+
+func (rf *rewritingFilter) Filter(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request, next httpendpoint.FilterChain) context.Context {
+
+	if req.URL.Path == "/old" {
+		req.URL.Path = "/new"
+	}
+
+	return next(ctx, w, req)
+}
+
+func TestRequestsRoutedOnce(t *testing.T) {
+
+	v1 := &testProvider{name: "v1", template: "/a", methods: []string{"GET"}, versions: true}
+	moved := template("new", "/new", "GET")
+
+	h := filteredServer(t, map[string]httpendpoint.HttpFilter{"rewrite": new(rewritingFilter)}, v1, moved)
+
+	ce := new(countingExtractor)
+	h.VersionExtractor = ce
+	h.CORS = &httpendpoint.CORSPolicy{Enabled: true, AllowedOrigins: []string{"*"}}
+	h.RateLimit = &RateLimitSettings{RateLimitPolicy: httpendpoint.RateLimitPolicy{Enabled: true, RequestsPerSecond: 100, Burst: 100, Key: httpendpoint.IPRateLimitKey}}
+	test.ExpectNil(t, h.findFilters())
+
+	req := corsRequest("GET", "/a", "https://app.example.com")
+	req.Header.Set("X-Version", "v1")
+
+	rec := httptest.NewRecorder()
+	h.handleAll(rec, req)
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectInt(t, v1.served, 1)
+	test.ExpectInt(t, ce.calls, 1)
+
+	// A filter that changes the path causes the request to be routed again
+	rec = httptest.NewRecorder()
+	h.handleAll(rec, httptest.NewRequest("GET", "/old", nil))
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectInt(t, moved.served, 1)
+}
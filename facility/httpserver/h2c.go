@@ -0,0 +1,23 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+//go:build go1.24
+// +build go1.24
+
+package httpserver
+
+import (
+	"net/http"
+)
+
+// Makes the supplied server accept HTTP/2 requests over unencrypted connections as well as HTTP/1.x requests.
+func allowH2C(sv *http.Server) error {
+
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	p.SetUnencryptedHTTP2(true)
+
+	sv.Protocols = p
+
+	return nil
+}
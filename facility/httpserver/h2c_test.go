@@ -0,0 +1,58 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+//go:build go1.24
+// +build go1.24
+
+package httpserver
+
+import (
+	"context"
+	"github.com/graniticio/granitic/httpendpoint"
+	"github.com/graniticio/granitic/test"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestH2C(t *testing.T) {
+
+	h := new(HttpServer)
+	h.AllowH2C = true
+
+	alw := new(AccessLogWriter)
+	test.ExpectNil(t, alw.parseFormat("%m %U %H"))
+
+	var line string
+
+	sv := new(http.Server)
+	sv.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		line = strings.TrimSpace(alw.buildLine(r, httpendpoint.NewHttpResponseWriter(w), &now, &now, context.Background()))
+	})
+
+	test.ExpectNil(t, h.configureServer(sv))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	test.ExpectNil(t, err)
+
+	go sv.Serve(ln)
+	defer sv.Close()
+
+	p := new(http.Protocols)
+	p.SetUnencryptedHTTP2(true)
+
+	c := &http.Client{Transport: &http.Transport{Protocols: p}}
+
+	r, err := c.Get("http://" + ln.Addr().String() + "/h2c")
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, r.ProtoMajor, 2)
+	test.ExpectString(t, line, "GET /h2c HTTP/2.0")
+
+	// HTTP/1.1 still accepted
+	r, err = http.Get("http://" + ln.Addr().String() + "/h1")
+	test.ExpectNil(t, err)
+	test.ExpectString(t, line, "GET /h1 HTTP/1.1")
+}
@@ -0,0 +1,19 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+//go:build !go1.24
+// +build !go1.24
+
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+)
+
+// h2c support relies on http.Protocols, which was added in Go 1.24.
+func allowH2C(sv *http.Server) error {
+	return errors.New(fmt.Sprintf("HttpServer.AllowH2C requires an application built with Go 1.24 or later (built with %s)", runtime.Version()))
+}
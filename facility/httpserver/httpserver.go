@@ -13,11 +13,78 @@ and mapping of requests to instances of ws.Handler. AccessLogWriter supports Apa
 Most applications will only need to enable this facility (probably changing the listen Port) and define mappings between incoming paths and application logic in their
 component definition files. See handler.WsHandler for more details.
 
+Routing
+
+Each request is handled by at most one endpoint. Endpoints that declare a path template (see httpendpoint.PathTemplate)
+are considered before endpoints that only declare a regular expression and, if more than one template matches, the most
+specific template is used. If no endpoint supports the request's method but endpoints supporting other methods match the
+path, a 405 response is sent with an Allow header listing those methods.
+
+The server will not start if two endpoints handle the same method with the same template (or identical regular
+expression) unless both endpoints are version aware, or if a template or regular expression is invalid.
+
+Timeouts and protocols
+
+The time allowed to read requests and write responses, how long idle connections are kept open and the maximum size of
+request headers can be set with the ReadTimeoutMS, ReadHeaderTimeoutMS, WriteTimeoutMS, IdleTimeoutMS and MaxHeaderBytes
+fields and the size of request bodies can be limited with MaxRequestBodyBytes. Setting AllowH2C accepts HTTP/2 requests
+over unencrypted connections (this requires an application built with Go 1.24 or later). The protocol of each request
+can be recorded in the access log with the %H placeholder.
+
+Multiple servers
+
+Additional servers (for example an internal admin port) can be declared under HttpServer.AdditionalServers. Handlers are
+registered with the default server unless they name the servers they should be registered with. See
+HttpServerFacilityBuilder for details.
+
+Filters
+
+Components implementing httpendpoint.HttpFilter are found automatically (unless AutoFindFilters is false) and applied,
+in order, to every request before it is routed to an endpoint. Filters can act before and after the endpoint, respond
+without calling the endpoint or wrap the response. A filter can be limited to paths matching a regular expression or to
+named servers. See httpendpoint.HttpFilter for details.
+
+CORS
+
+Cross-origin requests are supported by setting a policy (see httpendpoint.CORSPolicy) under HttpServer.CORS:
+
+	{
+	  "HttpServer": {
+	    "CORS": {
+	      "Enabled": true,
+	      "AllowedOrigins": ["https://*.example.com"],
+	      "AllowedHeaders": ["Content-Type", "Authorization"],
+	      "AllowCredentials": true,
+	      "MaxAgeSeconds": 600
+	    }
+	  }
+	}
+
+Preflight (OPTIONS) requests for paths registered with the server are answered automatically and CORS headers are
+added to other responses by the JsonWs and XmlWs response writers. Individual handlers can use a different policy (see
+handler.WsHandler.CORS).
+
+Rate limiting
+
+The number of requests each client can make can be limited, with clients identified by IP address, user or a header
+such as an API key. Individual endpoints can have their own limits. See RateLimitSettings for details.
+
+Compression
+
+Responses can be compressed with gzip or deflate for clients that accept compressed responses. See
+CompressionSettings for details.
+
+TLS
+
+The server can accept HTTPS connections, optionally requiring clients to present a certificate signed by a trusted CA
+(mutual TLS). Certificates are reloaded automatically when their files change. See TLSSettings for details.
+
 */
 package httpserver
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"github.com/graniticio/granitic/httpendpoint"
@@ -26,24 +93,32 @@ import (
 	"github.com/graniticio/granitic/ws"
 	"net"
 	"net/http"
-	"regexp"
+	"sort"
+	"strings"
 	"sync/atomic"
 	"time"
 )
 
-type registeredProvider struct {
-	Provider httpendpoint.HttpEndpointProvider
-	Pattern  *regexp.Regexp
-}
-
 type HttpServer struct {
-	registeredProvidersByMethod map[string][]*registeredProvider
-	unregisteredProviders       map[string]httpendpoint.HttpEndpointProvider
-	componentContainer          *ioc.ComponentContainer
+	router                *router
+	unregisteredProviders map[string]httpendpoint.HttpEndpointProvider
+	filters               []*registeredFilter
+	endpointCORS          bool
+	endpointRateLimit     bool
+	userRateLimit         bool
+	unregisteredFilters   map[string]httpendpoint.HttpFilter
+	componentContainer    *ioc.ComponentContainer
 
 	// Logger used by Granitic framework components. Automatically injected.
 	FrameworkLogger logging.Logger
 
+	// The name of this server (DefaultServerName unless the server was declared under HttpServer.AdditionalServers). Set
+	// by this facility's builder.
+	Name string
+
+	// The names of all servers created by this facility's builder
+	knownServers []string
+
 	// A component able to write an access log. Automatically added by this facility's builder, is access log support is enabled.
 	AccessLogWriter *AccessLogWriter
 
@@ -54,6 +129,10 @@ type HttpServer struct {
 	// registered with this server
 	AutoFindHandlers bool
 
+	// Whether or not instances of httpendpoint.HttpFilter found in the IoC container should be automatically applied to
+	// requests to this server
+	AutoFindFilters bool
+
 	// The TCP port on which the HTTP server should listen for requests.
 	Port int
 
@@ -76,10 +155,51 @@ type HttpServer struct {
 	// The HTTP status code returned with 'too busy responses'. Normally 503
 	TooBusyStatus int
 
+	// The CORS policy applied to requests to this server's endpoints (unless an endpoint has its own policy). See
+	// httpendpoint.CORSPolicy
+	CORS *httpendpoint.CORSPolicy
+
+	// Settings for limiting the rate at which clients can make requests. See RateLimitSettings.
+	RateLimit *RateLimitSettings
+
+	// Settings for compressing responses. See CompressionSettings.
+	Compression *CompressionSettings
+
+	// Settings for accepting TLS (HTTPS) connections. See TLSSettings.
+	TLS *TLSSettings
+
+	// The maximum time (in milliseconds) allowed to read an entire request, including the body. Zero means no limit.
+	ReadTimeoutMS int
+
+	// The maximum time (in milliseconds) allowed to read the headers of a request. Zero means ReadTimeoutMS is used.
+	ReadHeaderTimeoutMS int
+
+	// The maximum time (in milliseconds) allowed to write a response, measured from the end of reading the request
+	// headers. Zero means no limit.
+	WriteTimeoutMS int
+
+	// The maximum time (in milliseconds) an idle keep-alive connection is kept open. Zero means ReadTimeoutMS is used.
+	IdleTimeoutMS int
+
+	// The maximum size (in bytes) of a request's headers. Zero means Go's default (1MB).
+	MaxHeaderBytes int
+
+	// The maximum size (in bytes) of a request's body. Zero means no limit. Endpoints can set their own limit (see
+	// httpendpoint.BodyLimitProvider). Requests that declare a larger body receive a 413 response and reading beyond
+	// the limit returns an error (see ws.IsBodyTooLarge).
+	MaxRequestBodyBytes int
+
+	// Whether or not HTTP/2 requests are accepted over unencrypted connections (h2c) as well as HTTP/1.x requests. Intended
+	// for internal traffic (e.g. behind a service mesh) and cannot be used with TLS. Requires an application built with
+	// Go 1.24 or later (the server will not start otherwise).
+	AllowH2C bool
+
 	// A component able to examine an incoming request and determine which version of functionality is being requested.
 	VersionExtractor httpendpoint.RequestedVersionExtractor
 	state            ioc.ComponentState
 	server           *http.Server
+	tlsConfig        *tls.Config
+	certificates     *certificateLoader
 }
 
 // Implements ioc.ContainerAccessor
@@ -87,33 +207,73 @@ func (h *HttpServer) Container(container *ioc.ComponentContainer) {
 	h.componentContainer = container
 }
 
-func (h *HttpServer) registerProvider(endPointProvider httpendpoint.HttpEndpointProvider) {
+func (h *HttpServer) registerProvider(endPointProvider httpendpoint.HttpEndpointProvider) error {
+
+	h.FrameworkLogger.LogTracef("Registering %s for %v", describeProvider(endPointProvider), endPointProvider.SupportedHttpMethods())
+
+	if cpp, found := endPointProvider.(httpendpoint.CORSPolicyProvider); found && cpp.CORSPolicy() != nil {
+		h.endpointCORS = true
+	}
+
+	if rpp, found := endPointProvider.(httpendpoint.RateLimitPolicyProvider); found && rpp.RateLimitPolicy() != nil {
+
+		rp := rpp.RateLimitPolicy()
+
+		h.endpointRateLimit = true
+		h.userRateLimit = h.userRateLimit || (rp.Enabled && rp.Key == httpendpoint.UserRateLimitKey)
+	}
+
+	return h.router.add(endPointProvider)
+}
+
+// Returns true if the supplied provider (or filter) should be registered with this server.
+func (h *HttpServer) assignedTo(p interface{}) bool {
 
-	for _, method := range endPointProvider.SupportedHttpMethods() {
-		var compiledRegex *regexp.Regexp
-		var err error
+	name := h.Name
 
-		pattern := endPointProvider.RegexPattern()
+	if name == "" {
+		name = DefaultServerName
+	}
+
+	snp, found := p.(httpendpoint.ServerNamesProvider)
+
+	if !found || len(snp.ServerNames()) == 0 {
+		return name == DefaultServerName
+	}
 
-		if compiledRegex, err = regexp.Compile(pattern); err != nil {
-			h.FrameworkLogger.LogErrorf("Unable to compile regular expression from pattern %s: %s", pattern, err.Error())
+	for _, s := range snp.ServerNames() {
+		if s == name {
+			return true
 		}
+	}
 
-		h.FrameworkLogger.LogTracef("Registering %s %s", pattern, method)
+	return false
+}
 
-		rp := registeredProvider{endPointProvider, compiledRegex}
+// Checks that the servers a provider wants to be registered with exist. Only performed by the default server so
+// each problem is only reported once.
+func (h *HttpServer) checkServerNames(p interface{}) error {
 
-		providersForMethod := h.registeredProvidersByMethod[method]
+	snp, found := p.(httpendpoint.ServerNamesProvider)
 
-		if providersForMethod == nil {
-			providersForMethod = make([]*registeredProvider, 1)
-			providersForMethod[0] = &rp
-			h.registeredProvidersByMethod[method] = providersForMethod
-		} else {
-			h.registeredProvidersByMethod[method] = append(providersForMethod, &rp)
+	if !found || h.Name != DefaultServerName || h.knownServers == nil {
+		return nil
+	}
+
+	for _, s := range snp.ServerNames() {
+
+		known := false
+
+		for _, k := range h.knownServers {
+			known = known || k == s
+		}
+
+		if !known {
+			return errors.New(fmt.Sprintf("%s: no HTTP server named %s has been configured", describeProvider(p), s))
 		}
 	}
 
+	return nil
 }
 
 // StartComponent Finds and registers any available components that implement httpendpoint.HttpEndpointProvider (normally instances of
@@ -126,7 +286,9 @@ func (h *HttpServer) StartComponent() error {
 	}
 
 	h.state = ioc.StartingState
-	h.registeredProvidersByMethod = make(map[string][]*registeredProvider)
+	h.router = newRouter()
+
+	problems := make([]string, 0)
 
 	if h.AutoFindHandlers {
 		for _, component := range h.componentContainer.AllComponents() {
@@ -134,26 +296,69 @@ func (h *HttpServer) StartComponent() error {
 			name := component.Name
 
 			if provider, found := component.Instance.(httpendpoint.HttpEndpointProvider); found && provider.AutoWireable() {
+
+				if err := h.checkServerNames(provider); err != nil {
+					problems = append(problems, err.Error())
+				}
+
+				if !h.assignedTo(provider) {
+					continue
+				}
+
 				h.FrameworkLogger.LogDebugf("Found HttpEndpointProvider %s", name)
-				h.registerProvider(provider)
+
+				if err := h.registerProvider(provider); err != nil {
+					problems = append(problems, err.Error())
+				}
 			}
 		}
 	} else if h.unregisteredProviders != nil {
 
 		for _, provider := range h.unregisteredProviders {
 
-			h.registerProvider(provider)
-
+			if err := h.registerProvider(provider); err != nil {
+				problems = append(problems, err.Error())
+			}
 		}
 
 	} else {
 		return errors.New("Auto finding of handlers is disabled, but handlers have not been set manually.")
 	}
 
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.New(fmt.Sprintf("Unable to route requests to all handlers:\n%s", strings.Join(problems, "\n")))
+	}
+
+	if err := h.findFilters(); err != nil {
+		return err
+	}
+
 	if h.AbnormalStatusWriter == nil {
 		return errors.New("No AbnormalStatusWriter set.")
 	}
 
+	if h.TLS != nil && h.TLS.Enabled {
+
+		if h.AllowH2C {
+			return errors.New("HttpServer.AllowH2C cannot be used when TLS is enabled (HTTP/2 is negotiated automatically over TLS)")
+		}
+
+		h.certificates = newCertificateLoader(h.TLS, h.FrameworkLogger)
+
+		if err := h.certificates.load(); err != nil {
+			return err
+		}
+
+		tc, err := buildTLSConfig(h.TLS, h.certificates)
+
+		if err != nil {
+			return err
+		}
+
+		h.tlsConfig = tc
+	}
+
 	h.state = ioc.AwaitingAccessState
 
 	return nil
@@ -196,6 +401,10 @@ func (h *HttpServer) AllowAccess() error {
 	sv := new(http.Server)
 	sv.Handler = sm
 
+	if err := h.configureServer(sv); err != nil {
+		return err
+	}
+
 	listenAddress := fmt.Sprintf("%s:%d", h.Address, h.Port)
 
 	//Check if the address is already in use
@@ -207,22 +416,159 @@ func (h *HttpServer) AllowAccess() error {
 
 	sv.Addr = listenAddress
 
-	go sv.ListenAndServe()
+	if h.tlsConfig != nil {
+		sv.TLSConfig = h.tlsConfig
 
-	h.server = sv
+		// Certificates are supplied by the TLS config
+		go sv.ListenAndServeTLS("", "")
+
+		h.FrameworkLogger.LogInfof("Listening on %d (TLS)", h.Port)
 
-	h.FrameworkLogger.LogInfof("Listening on %d", h.Port)
+	} else {
+		go sv.ListenAndServe()
+
+		h.FrameworkLogger.LogInfof("Listening on %d", h.Port)
+	}
+
+	h.server = sv
 
 	h.state = ioc.RunningState
 
 	return nil
 }
 
+// Applies the timeouts, limits and protocols set on this HttpServer to the underlying http.Server
+func (h *HttpServer) configureServer(sv *http.Server) error {
+
+	ms := func(i int) time.Duration {
+		return time.Duration(i) * time.Millisecond
+	}
+
+	sv.ReadTimeout = ms(h.ReadTimeoutMS)
+	sv.ReadHeaderTimeout = ms(h.ReadHeaderTimeoutMS)
+	sv.WriteTimeout = ms(h.WriteTimeoutMS)
+	sv.IdleTimeout = ms(h.IdleTimeoutMS)
+	sv.MaxHeaderBytes = h.MaxHeaderBytes
+
+	if h.AllowH2C {
+		return allowH2C(sv)
+	}
+
+	return nil
+}
+
+// ReloadCertificates immediately reloads the TLS certificate and key files (they are also reloaded automatically when
+// modified, see TLSSettings). Returns an error, and continues to use the previous certificate, if the files cannot be loaded.
+func (h *HttpServer) ReloadCertificates() error {
+
+	if h.certificates == nil {
+		return errors.New("TLS is not enabled for this server")
+	}
+
+	return h.certificates.load()
+}
+
 // SetProvidersManually manually injects a set of httpendpoint.HttpEndpointProviders when auto finding is disabled.
 func (h *HttpServer) SetProvidersManually(p map[string]httpendpoint.HttpEndpointProvider) {
 	h.unregisteredProviders = p
 }
 
+// SetFiltersManually manually injects a set of httpendpoint.HttpFilters when auto finding of filters is disabled.
+func (h *HttpServer) SetFiltersManually(f map[string]httpendpoint.HttpFilter) {
+	h.unregisteredFilters = f
+}
+
+// Finds (or uses manually set) filters and orders them.
+func (h *HttpServer) findFilters() error {
+
+	candidates := h.unregisteredFilters
+	problems := make([]string, 0)
+
+	if h.AutoFindFilters {
+
+		candidates = make(map[string]httpendpoint.HttpFilter)
+
+		for _, component := range h.componentContainer.AllComponents() {
+
+			if f, found := component.Instance.(httpendpoint.HttpFilter); found {
+
+				if err := h.checkServerNames(f); err != nil {
+					problems = append(problems, err.Error())
+				}
+
+				if h.assignedTo(f) {
+					candidates[component.Name] = f
+				}
+			}
+		}
+	}
+
+	h.filters = make([]*registeredFilter, 0, len(candidates))
+
+	for name, f := range candidates {
+
+		rf, err := newRegisteredFilter(name, f)
+
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+
+		h.filters = append(h.filters, rf)
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.New(fmt.Sprintf("Unable to apply all filters:\n%s", strings.Join(problems, "\n")))
+	}
+
+	sortFilters(h.filters)
+
+	if h.CORS != nil {
+		if err := h.CORS.Compile(); err != nil {
+			return err
+		}
+	}
+
+	builtIn := make([]*registeredFilter, 0)
+
+	if h.endpointCORS || (h.CORS != nil && h.CORS.Enabled) {
+		// Applied before any other filter so that preflight requests are not rejected by (for example) authentication filters
+		builtIn = append(builtIn, &registeredFilter{name: corsFilterName, filter: &corsFilter{server: h}})
+	}
+
+	if h.Compression != nil && h.Compression.Enabled {
+
+		if err := h.Compression.validate(); err != nil {
+			return err
+		}
+
+		// Applied before application filters so that anything they write is also compressed
+		cf := &compressionFilter{settings: h.Compression, log: h.FrameworkLogger}
+		builtIn = append(builtIn, &registeredFilter{name: compressionFilterName, filter: cf})
+	}
+
+	if h.endpointRateLimit || (h.RateLimit != nil && h.RateLimit.Enabled) {
+
+		rf, err := h.newRateLimitFilter()
+
+		if err != nil {
+			return err
+		}
+
+		// Applied before application filters so that limited requests are rejected as cheaply as possible
+		builtIn = append(builtIn, &registeredFilter{name: rateLimitFilterName, filter: rf})
+	}
+
+	h.filters = append(builtIn, h.filters...)
+
+	for _, rf := range h.filters {
+		h.FrameworkLogger.LogDebugf("Applying HttpFilter %s (order %d)", rf.name, rf.order)
+	}
+
+	return nil
+}
+
 func (h *HttpServer) handleAll(res http.ResponseWriter, req *http.Request) {
 
 	wrw := httpendpoint.NewHttpResponseWriter(res)
@@ -249,40 +595,136 @@ func (h *HttpServer) handleAll(res http.ResponseWriter, req *http.Request) {
 	}
 
 	received := time.Now()
-	matched := false
 
-	providersByMethod := h.registeredProvidersByMethod[req.Method]
+	ctx = context.WithValue(ctx, resolvedRouteKey{}, h.resolveRoute(req))
+	ctx = chainFrom(h.filters, 0, h.dispatch)(ctx, wrw, req)
+
+	if h.AccessLogging {
+		finished := time.Now()
+		h.AccessLogWriter.LogRequest(req, wrw, &received, &finished, ctx)
+	}
+
+}
+
+// Passes the request to the matching HttpEndpointProvider or writes a 404 or 405 response if there is no match. Called
+// at the end of the filter chain.
+func (h *HttpServer) dispatch(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request) context.Context {
 
 	path := req.URL.Path
 
-	h.FrameworkLogger.LogTracef("Finding provider to handle %s %s from %d providers", path, req.Method, len(providersByMethod))
+	rr := h.routeFor(ctx, req)
+	provider, allowed := rr.provider, rr.allowed
+
+	if provider != nil {
+		h.FrameworkLogger.LogTracef("%s %s matches %s", req.Method, path, describeProvider(provider))
+
+		if !h.limitBody(ctx, w, req, provider) {
+			return ctx
+		}
+
+		return provider.ServeHttp(ctx, w, req)
+	}
+
+	status := http.StatusNotFound
+
+	if len(allowed) > 0 {
+		status = http.StatusMethodNotAllowed
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+	}
+
+	state := ws.NewAbnormalState(status, w)
 
-	for _, handlerPattern := range providersByMethod {
+	if err := h.AbnormalStatusWriter.WriteAbnormalStatus(ctx, state); err != nil {
+		h.FrameworkLogger.LogErrorfCtx(ctx, err.Error())
+	}
+
+	return ctx
+}
 
-		pattern := handlerPattern.Pattern
+type resolvedRouteKey struct{}
 
-		h.FrameworkLogger.LogTracef("Testing %s", pattern.String())
+// The result of routing a request. Requests are routed once (before any filters are applied) and the result shared by
+// the built-in filters and dispatch.
+type resolvedRoute struct {
+	// The path and method the request was routed with.
+	path   string
+	method string
 
-		if pattern.MatchString(path) && h.versionMatch(req, handlerPattern.Provider) {
-			h.FrameworkLogger.LogTracef("Matches %s", pattern.String())
-			matched = true
-			ctx = handlerPattern.Provider.ServeHttp(ctx, wrw, req)
+	// The provider that matched the request (nil if none) and, if none matched, the methods supported by other providers
+	// matching the path.
+	provider httpendpoint.HttpEndpointProvider
+	allowed  []string
+
+	// For CORS preflight requests only, the provider (of any version) that matches the method in the
+	// Access-Control-Request-Method header and, if none matches, the methods supported by providers matching the path.
+	preflightProvider httpendpoint.HttpEndpointProvider
+	preflightAllowed  []string
+}
+
+// Finds the provider that will handle the supplied request.
+func (h *HttpServer) resolveRoute(req *http.Request) *resolvedRoute {
+
+	rr := new(resolvedRoute)
+	rr.path = req.URL.Path
+	rr.method = req.Method
+
+	accept := func(p httpendpoint.HttpEndpointProvider) bool {
+		return h.versionMatch(req, p)
+	}
+
+	rr.provider, rr.allowed = h.router.find(rr.path, rr.method, accept)
+
+	if httpendpoint.IsPreflight(req) {
+
+		anyVersion := func(p httpendpoint.HttpEndpointProvider) bool {
+			return true
 		}
+
+		rr.preflightProvider, rr.preflightAllowed = h.router.find(rr.path, req.Header.Get(httpendpoint.AccessControlRequestMethodHeader), anyVersion)
 	}
 
-	if !matched {
-		state := ws.NewAbnormalState(http.StatusNotFound, wrw)
+	return rr
+}
+
+// Returns the route resolved for the request when it was received, or routes the request again if a filter has passed
+// on a request with a different path or method.
+func (h *HttpServer) routeFor(ctx context.Context, req *http.Request) *resolvedRoute {
+
+	if rr, found := ctx.Value(resolvedRouteKey{}).(*resolvedRoute); found && rr.path == req.URL.Path && rr.method == req.Method {
+		return rr
+	}
+
+	return h.resolveRoute(req)
+}
+
+// Applies the server's (or the provider's) limit on the size of the request body. Returns false (after writing a 413
+// response) if the request declares a body larger than the limit.
+func (h *HttpServer) limitBody(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request, p httpendpoint.HttpEndpointProvider) bool {
+
+	limit := h.MaxRequestBodyBytes
+
+	if blp, found := p.(httpendpoint.BodyLimitProvider); found && blp.BodyLimit() != 0 {
+		limit = blp.BodyLimit()
+	}
+
+	if limit <= 0 || req.Body == nil {
+		return true
+	}
+
+	if req.ContentLength > int64(limit) {
+
+		state := ws.NewAbnormalState(http.StatusRequestEntityTooLarge, w)
 
 		if err := h.AbnormalStatusWriter.WriteAbnormalStatus(ctx, state); err != nil {
-			h.FrameworkLogger.LogErrorfCtx(ctx, err.Error())
+			h.FrameworkLogger.LogErrorfCtx(ctx, "Unable to write response to oversized request: %s", err.Error())
 		}
-	}
 
-	if h.AccessLogging {
-		finished := time.Now()
-		h.AccessLogWriter.LogRequest(req, wrw, &received, &finished, ctx)
+		return false
 	}
 
+	req.Body = http.MaxBytesReader(w, req.Body, int64(limit))
+
+	return true
 }
 
 func (h *HttpServer) versionMatch(r *http.Request, p httpendpoint.HttpEndpointProvider) bool {
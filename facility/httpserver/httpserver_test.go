@@ -4,9 +4,18 @@
 package httpserver
 
 import (
+	"context"
 	"fmt"
+	"github.com/graniticio/granitic/httpendpoint"
+	"github.com/graniticio/granitic/test"
+	"github.com/graniticio/granitic/ws"
+	"io/ioutil"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestPortBinding(t *testing.T) {
@@ -36,3 +45,81 @@ func TestPortBinding(t *testing.T) {
 	fmt.Println(err)
 
 }
+
+func TestServerTimeouts(t *testing.T) {
+
+	h := new(HttpServer)
+	h.ReadHeaderTimeoutMS = 500
+	h.IdleTimeoutMS = 2000
+	h.MaxHeaderBytes = 4096
+
+	sv := new(http.Server)
+
+	test.ExpectNil(t, h.configureServer(sv))
+
+	test.ExpectBool(t, sv.ReadHeaderTimeout == 500*time.Millisecond, true)
+	test.ExpectBool(t, sv.IdleTimeout == 2*time.Second, true)
+	test.ExpectBool(t, sv.ReadTimeout == 0, true)
+	test.ExpectInt(t, sv.MaxHeaderBytes, 4096)
+}
+
+func TestH2CNotAllowedWithTLS(t *testing.T) {
+
+	h := new(HttpServer)
+	h.AutoFindHandlers = false
+	h.unregisteredProviders = map[string]httpendpoint.HttpEndpointProvider{}
+	h.AbnormalStatusWriter = new(statusWriter)
+	h.AllowH2C = true
+	h.TLS = &TLSSettings{Enabled: true}
+
+	test.ExpectNotNil(t, h.StartComponent())
+}
+
+type bodyLimitProvider struct {
+	*testProvider
+	limit int
+	body  []byte
+	err   error
+}
+
+func (bp *bodyLimitProvider) BodyLimit() int {
+	return bp.limit
+}
+
+func (bp *bodyLimitProvider) ServeHttp(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request) context.Context {
+	bp.body, bp.err = ioutil.ReadAll(req.Body)
+	w.WriteHeader(http.StatusOK)
+
+	return ctx
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+
+	small := &bodyLimitProvider{testProvider: template("small", "/small", "POST")}
+	large := &bodyLimitProvider{testProvider: template("large", "/large", "POST"), limit: 100}
+	unlimited := &bodyLimitProvider{testProvider: template("unlimited", "/unlimited", "POST"), limit: -1}
+
+	h := filteredServer(t, nil, small, large, unlimited)
+	h.MaxRequestBodyBytes = 10
+
+	body := strings.Repeat("x", 50)
+
+	rec := httptest.NewRecorder()
+	h.handleAll(rec, httptest.NewRequest("POST", "/small", strings.NewReader(body)))
+
+	test.ExpectInt(t, rec.Code, http.StatusRequestEntityTooLarge)
+	test.ExpectBool(t, small.body == nil, true)
+
+	// Length not declared
+	req := httptest.NewRequest("POST", "/small", strings.NewReader(body))
+	req.ContentLength = -1
+
+	h.handleAll(httptest.NewRecorder(), req)
+	test.ExpectBool(t, ws.IsBodyTooLarge(small.err), true)
+
+	h.handleAll(httptest.NewRecorder(), httptest.NewRequest("POST", "/large", strings.NewReader(body)))
+	test.ExpectInt(t, len(large.body), 50)
+
+	h.handleAll(httptest.NewRecorder(), httptest.NewRequest("POST", "/unlimited", strings.NewReader(body)))
+	test.ExpectInt(t, len(unlimited.body), 50)
+}
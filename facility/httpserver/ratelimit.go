@@ -0,0 +1,334 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/httpendpoint"
+	"github.com/graniticio/granitic/ioc"
+	"github.com/graniticio/granitic/ws"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The name under which the rate limiting filter is registered with a server.
+const rateLimitFilterName = "rate-limit"
+
+/*
+RateLimitSettings controls per-client rate limiting for an HttpServer. Normally set in configuration:
+
+	{
+	  "HttpServer": {
+	    "RateLimit": {
+	      "Enabled": true,
+	      "RequestsPerSecond": 5,
+	      "Burst": 20,
+	      "Key": "header",
+	      "KeyHeader": "X-Api-Key"
+	    }
+	  }
+	}
+
+See httpendpoint.RateLimitPolicy for the meaning of the policy fields. Endpoints can override the server's policy (see
+handler.WsHandler.RateLimit).
+
+Limited requests receive an ExceededStatus (normally 429) response with a Retry-After header. All limited endpoints'
+responses carry RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers.
+
+If any policy uses the user key, IdentifierName must be the name of a component implementing ws.WsIdentifier that will
+be used to identify callers (normally the same component used as handlers' UserIdentifier).
+
+Counts are held in memory by default, so each instance of an application limits clients separately. Set StoreName to
+the name of a component implementing RateLimitStore to share counts between instances.
+*/
+type RateLimitSettings struct {
+	httpendpoint.RateLimitPolicy
+
+	// The name of a component implementing ws.WsIdentifier used to identify callers when a policy's Key is user.
+	IdentifierName string
+
+	// The name of a component implementing RateLimitStore. If not set, counts are held in memory.
+	StoreName string
+
+	// The HTTP status code sent when a client has exceeded its limit.
+	ExceededStatus int
+}
+
+// The outcome of an attempt to take a token from a client's bucket.
+type RateLimitDecision struct {
+	// Whether or not the request should be allowed.
+	Allowed bool
+
+	// The number of whole tokens left in the bucket.
+	Remaining int
+
+	// How long until the bucket is full again.
+	Reset time.Duration
+
+	// If the request was not allowed, how long until a token will be available.
+	RetryAfter time.Duration
+}
+
+// RateLimitStore is implemented by components able to record how many requests clients have made.
+type RateLimitStore interface {
+	// Take removes a token from the bucket identified by key if one is available. Buckets that do not exist are treated
+	// as full. Tokens are replenished at rate per second up to a maximum of burst.
+	Take(key string, rate float64, burst int, now time.Time) (RateLimitDecision, error)
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+// MemoryRateLimitStore is a RateLimitStore that keeps buckets in memory. Buckets that have refilled are discarded
+// periodically.
+type MemoryRateLimitStore struct {
+	mutex     sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+
+	// How often full buckets are discarded.
+	SweepInterval time.Duration
+}
+
+// NewMemoryRateLimitStore creates an empty MemoryRateLimitStore.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+
+	ms := new(MemoryRateLimitStore)
+	ms.buckets = make(map[string]*tokenBucket)
+	ms.SweepInterval = time.Minute
+
+	return ms
+}
+
+// Take implements RateLimitStore.Take
+func (ms *MemoryRateLimitStore) Take(key string, rate float64, burst int, now time.Time) (RateLimitDecision, error) {
+
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	ms.sweep(now)
+
+	b := ms.buckets[key]
+
+	if b == nil {
+		b = &tokenBucket{tokens: float64(burst), updated: now}
+		ms.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	var d RateLimitDecision
+
+	if b.tokens >= 1 {
+		b.tokens--
+		d.Allowed = true
+	} else {
+		d.RetryAfter = secondsToDuration((1 - b.tokens) / rate)
+	}
+
+	missing := float64(burst) - b.tokens
+
+	d.Remaining = int(b.tokens)
+	d.Reset = secondsToDuration(missing / rate)
+	b.full = now.Add(d.Reset)
+
+	return d, nil
+}
+
+func (ms *MemoryRateLimitStore) sweep(now time.Time) {
+
+	if now.Sub(ms.lastSweep) < ms.SweepInterval {
+		return
+	}
+
+	ms.lastSweep = now
+
+	for k, b := range ms.buckets {
+		if !now.Before(b.full) {
+			delete(ms.buckets, k)
+		}
+	}
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Limits requests according to the server's policy or the requested endpoint's own policy.
+type rateLimitFilter struct {
+	server     *HttpServer
+	store      RateLimitStore
+	identifier ws.WsIdentifier
+}
+
+// Filter implements httpendpoint.HttpFilter
+func (rf *rateLimitFilter) Filter(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request, next httpendpoint.FilterChain) context.Context {
+
+	h := rf.server
+
+	policy, scope := h.rateLimitPolicy(h.routeFor(ctx, req).provider)
+
+	if policy == nil || !policy.Enabled {
+		return next(ctx, w, req)
+	}
+
+	key := scope + "|" + rf.clientKey(ctx, req, policy)
+
+	d, err := rf.store.Take(key, policy.RequestsPerSecond, policy.Burst, time.Now())
+
+	if err != nil {
+		h.FrameworkLogger.LogErrorfCtx(ctx, "Unable to check rate limit (allowing request): %s", err.Error())
+		return next(ctx, w, req)
+	}
+
+	hd := w.Header()
+	hd.Set("RateLimit-Limit", strconv.Itoa(policy.Burst))
+	hd.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	hd.Set("RateLimit-Reset", ceilSeconds(d.Reset))
+
+	if d.Allowed {
+		return next(ctx, w, req)
+	}
+
+	hd.Set("Retry-After", ceilSeconds(d.RetryAfter))
+
+	state := ws.NewAbnormalState(h.RateLimit.ExceededStatus, w)
+
+	if err := h.AbnormalStatusWriter.WriteAbnormalStatus(ctx, state); err != nil {
+		h.FrameworkLogger.LogErrorfCtx(ctx, "Unable to write rate limited response: %s", err.Error())
+	}
+
+	return ctx
+}
+
+// Identifies the caller according to the policy's key, falling back to the caller's IP address.
+func (rf *rateLimitFilter) clientKey(ctx context.Context, req *http.Request, policy *httpendpoint.RateLimitPolicy) string {
+
+	switch policy.Key {
+	case httpendpoint.HeaderRateLimitKey:
+		if v := req.Header.Get(policy.KeyHeader); v != "" {
+			return "h:" + v
+		}
+
+	case httpendpoint.UserRateLimitKey:
+		if i, _ := rf.identifier.Identify(ctx, req); i != nil && i.Authenticated() && i.LoggableUserId() != "" {
+			return "u:" + i.LoggableUserId()
+		}
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+
+	if err != nil {
+		host = req.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// Returns the rate limit policy of the supplied provider (which may be nil) or, if it does not have one, the server's
+// policy, along with a string identifying the policy's buckets.
+func (h *HttpServer) rateLimitPolicy(p httpendpoint.HttpEndpointProvider) (*httpendpoint.RateLimitPolicy, string) {
+
+	if rpp, found := p.(httpendpoint.RateLimitPolicyProvider); found && rpp.RateLimitPolicy() != nil {
+		return rpp.RateLimitPolicy(), h.Name + "|" + describeProvider(p)
+	}
+
+	if h.RateLimit != nil {
+		return &h.RateLimit.RateLimitPolicy, h.Name
+	}
+
+	return nil, ""
+}
+
+// Creates the rate limiting filter, finding the components named in RateLimitSettings.
+func (h *HttpServer) newRateLimitFilter() (*rateLimitFilter, error) {
+
+	rl := h.RateLimit
+
+	if rl == nil {
+		rl = new(RateLimitSettings)
+		h.RateLimit = rl
+	}
+
+	if err := rl.Validate(); err != nil {
+		return nil, err
+	}
+
+	if rl.ExceededStatus == 0 {
+		rl.ExceededStatus = http.StatusTooManyRequests
+	}
+
+	rf := &rateLimitFilter{server: h}
+	rf.store = NewMemoryRateLimitStore()
+
+	if rl.StoreName != "" {
+
+		c, err := h.namedComponent("HttpServer.RateLimit.StoreName", rl.StoreName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		s, found := c.(RateLimitStore)
+
+		if !found {
+			return nil, errors.New(fmt.Sprintf("HttpServer.RateLimit.StoreName: component %s does not implement httpserver.RateLimitStore", rl.StoreName))
+		}
+
+		rf.store = s
+	}
+
+	if rl.IdentifierName != "" {
+
+		c, err := h.namedComponent("HttpServer.RateLimit.IdentifierName", rl.IdentifierName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		i, found := c.(ws.WsIdentifier)
+
+		if !found {
+			return nil, errors.New(fmt.Sprintf("HttpServer.RateLimit.IdentifierName: component %s does not implement ws.WsIdentifier", rl.IdentifierName))
+		}
+
+		rf.identifier = i
+	}
+
+	if rf.identifier == nil && (h.userRateLimit || (rl.Enabled && rl.Key == httpendpoint.UserRateLimitKey)) {
+		return nil, errors.New(fmt.Sprintf("HttpServer.RateLimit.IdentifierName must be set if requests are limited by %s", httpendpoint.UserRateLimitKey))
+	}
+
+	return rf, nil
+}
+
+// Returns the instance of the named component.
+func (h *HttpServer) namedComponent(field, name string) (interface{}, error) {
+
+	var c *ioc.Component
+
+	if h.componentContainer != nil {
+		c = h.componentContainer.ComponentByName(name)
+	}
+
+	if c == nil {
+		return nil, errors.New(fmt.Sprintf("%s: no component named %s", field, name))
+	}
+
+	return c.Instance, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"context"
+	"github.com/graniticio/granitic/httpendpoint"
+	"github.com/graniticio/granitic/iam"
+	"github.com/graniticio/granitic/test"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+
+	ms := NewMemoryRateLimitStore()
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		d, _ := ms.Take("a", 1, 3, now)
+		test.ExpectBool(t, d.Allowed, true)
+		test.ExpectInt(t, d.Remaining, 2-i)
+	}
+
+	d, _ := ms.Take("a", 1, 3, now)
+	test.ExpectBool(t, d.Allowed, false)
+	test.ExpectBool(t, d.RetryAfter == time.Second, true)
+	test.ExpectBool(t, d.Reset == 3*time.Second, true)
+
+	d, _ = ms.Take("b", 1, 3, now)
+	test.ExpectBool(t, d.Allowed, true)
+
+	d, _ = ms.Take("a", 1, 3, now.Add(1500*time.Millisecond))
+	test.ExpectBool(t, d.Allowed, true)
+	test.ExpectInt(t, d.Remaining, 0)
+
+	// Full buckets are discarded
+	ms.Take("c", 1, 3, now.Add(time.Hour))
+	test.ExpectInt(t, len(ms.buckets), 1)
+}
+
+type limitedProvider struct {
+	*testProvider
+	policy *httpendpoint.RateLimitPolicy
+}
+
+func (lp *limitedProvider) RateLimitPolicy() *httpendpoint.RateLimitPolicy {
+	return lp.policy
+}
+
+type headerIdentifier struct{}
+
+func (hi *headerIdentifier) Identify(ctx context.Context, req *http.Request) (iam.ClientIdentity, context.Context) {
+
+	if u := req.Header.Get("X-User"); u != "" {
+		return iam.NewAuthenticatedIdentity(u), ctx
+	}
+
+	return iam.NewAnonymousIdentity(), ctx
+}
+
+func limitedRequest(h *HttpServer, path, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+
+	req := httptest.NewRequest("GET", path, nil)
+	req.RemoteAddr = remoteAddr
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	rec := httptest.NewRecorder()
+	h.handleAll(rec, req)
+
+	return rec
+}
+
+func TestRateLimitFilter(t *testing.T) {
+
+	unlimited := &httpendpoint.RateLimitPolicy{Enabled: false}
+	perKey := &httpendpoint.RateLimitPolicy{Enabled: true, RequestsPerSecond: 0.001, Burst: 1, Key: httpendpoint.HeaderRateLimitKey, KeyHeader: "X-Api-Key"}
+
+	h := filteredServer(t, nil,
+		template("a", "/a", "GET"),
+		&limitedProvider{template("free", "/free", "GET"), unlimited},
+		&limitedProvider{template("keyed", "/keyed", "GET"), perKey})
+
+	h.RateLimit = &RateLimitSettings{RateLimitPolicy: httpendpoint.RateLimitPolicy{Enabled: true, RequestsPerSecond: 0.001, Burst: 2, Key: httpendpoint.IPRateLimitKey}}
+	test.ExpectNil(t, h.findFilters())
+
+	rec := limitedRequest(h, "/a", "10.0.0.1:1000", nil)
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectString(t, rec.Header().Get("RateLimit-Limit"), "2")
+	test.ExpectString(t, rec.Header().Get("RateLimit-Remaining"), "1")
+
+	limitedRequest(h, "/a", "10.0.0.1:1001", nil)
+
+	rec = limitedRequest(h, "/a", "10.0.0.1:1002", nil)
+	test.ExpectInt(t, rec.Code, http.StatusTooManyRequests)
+	test.ExpectString(t, rec.Header().Get("Retry-After"), "1000")
+
+	// Other clients and unlimited endpoints
+	test.ExpectInt(t, limitedRequest(h, "/a", "10.0.0.2:1000", nil).Code, http.StatusOK)
+	test.ExpectInt(t, limitedRequest(h, "/free", "10.0.0.1:1000", nil).Code, http.StatusOK)
+	test.ExpectString(t, limitedRequest(h, "/free", "10.0.0.1:1000", nil).Header().Get("RateLimit-Limit"), "")
+
+	// Endpoint policy keyed on a header
+	key := map[string]string{"X-Api-Key": "k1"}
+	test.ExpectInt(t, limitedRequest(h, "/keyed", "10.0.0.1:1000", key).Code, http.StatusOK)
+	test.ExpectInt(t, limitedRequest(h, "/keyed", "10.0.0.3:1000", key).Code, http.StatusTooManyRequests)
+	test.ExpectInt(t, limitedRequest(h, "/keyed", "10.0.0.3:1000", map[string]string{"X-Api-Key": "k2"}).Code, http.StatusOK)
+}
+
+func TestUserRateLimit(t *testing.T) {
+
+	h := filteredServer(t, nil, template("a", "/a", "GET"))
+	h.RateLimit = &RateLimitSettings{RateLimitPolicy: httpendpoint.RateLimitPolicy{Enabled: true, RequestsPerSecond: 0.001, Burst: 1, Key: httpendpoint.UserRateLimitKey}}
+
+	test.ExpectNotNil(t, h.findFilters())
+
+	rf, err := h.newRateLimitFilter()
+	test.ExpectNotNil(t, err)
+	test.ExpectBool(t, rf == nil, true)
+
+	h.RateLimit.IdentifierName = "missing"
+	test.ExpectNotNil(t, h.findFilters())
+
+	rf = &rateLimitFilter{server: h, store: NewMemoryRateLimitStore(), identifier: new(headerIdentifier)}
+	h.filters = []*registeredFilter{{name: rateLimitFilterName, filter: rf}}
+
+	alice := map[string]string{"X-User": "alice"}
+
+	test.ExpectInt(t, limitedRequest(h, "/a", "10.0.0.1:1000", alice).Code, http.StatusOK)
+	test.ExpectInt(t, limitedRequest(h, "/a", "10.0.0.2:1000", alice).Code, http.StatusTooManyRequests)
+	test.ExpectInt(t, limitedRequest(h, "/a", "10.0.0.1:1000", map[string]string{"X-User": "bob"}).Code, http.StatusOK)
+
+	// Anonymous callers are limited by IP address
+	test.ExpectInt(t, limitedRequest(h, "/a", "10.0.0.1:1000", nil).Code, http.StatusOK)
+	test.ExpectInt(t, limitedRequest(h, "/a", "10.0.0.1:1000", nil).Code, http.StatusTooManyRequests)
+}
+
+func TestRateLimitPolicyValidation(t *testing.T) {
+
+	invalid := []*httpendpoint.RateLimitPolicy{
+		{Enabled: true, Burst: 1, Key: httpendpoint.IPRateLimitKey},
+		{Enabled: true, RequestsPerSecond: 1, Key: httpendpoint.IPRateLimitKey},
+		{Enabled: true, RequestsPerSecond: 1, Burst: 1, Key: "cookie"},
+		{Enabled: true, RequestsPerSecond: 1, Burst: 1, Key: httpendpoint.HeaderRateLimitKey},
+	}
+
+	for _, p := range invalid {
+		test.ExpectNotNil(t, p.Validate())
+	}
+
+	test.ExpectNil(t, (&httpendpoint.RateLimitPolicy{}).Validate())
+}
@@ -0,0 +1,325 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/httpendpoint"
+	"github.com/graniticio/granitic/ioc"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+)
+
+/*
+A router maps the path and method of a request to a single httpendpoint.HttpEndpointProvider.
+
+Providers are stored in a tree where each node represents one segment of a path. Providers that implement
+httpendpoint.RouteTemplateProvider are stored at the node reached by following the segments of their template. Providers
+that only supply a regular expression are stored at the node reached by following the complete /-separated literal text
+at the start of their (^ anchored) expression, so only expressions that could possibly match a path are tested.
+
+When finding a provider for a path, templates are considered first, most specific segment first (see
+httpendpoint.PathTemplate). Regular expressions are then considered, those with the longest literal prefix first, then in
+alphabetical order of their pattern.
+*/
+type router struct {
+	root *routeNode
+
+	// The number of routes added, used to keep the order of providers that share a node stable.
+	count int
+}
+
+type routeNode struct {
+	segment  *httpendpoint.TemplateSegment
+	children []*routeNode
+
+	// Providers whose template ends at this node
+	templates []*route
+
+	// Providers whose regular expression has the literal prefix represented by this node
+	regexes []*route
+}
+
+type route struct {
+	provider httpendpoint.HttpEndpointProvider
+	methods  map[string]bool
+	regex    *regexp.Regexp
+	template string
+	order    int
+}
+
+func newRouter() *router {
+	return &router{root: new(routeNode)}
+}
+
+// add registers a provider, returning an error if its template or regular expression is invalid or if another provider
+// already handles exactly the same paths with the same method (unless both providers are version aware).
+func (r *router) add(p httpendpoint.HttpEndpointProvider) error {
+
+	rt := new(route)
+	rt.provider = p
+	rt.methods = make(map[string]bool)
+	rt.order = r.count
+
+	r.count++
+
+	for _, m := range p.SupportedHttpMethods() {
+		rt.methods[m] = true
+	}
+
+	if tp, found := p.(httpendpoint.RouteTemplateProvider); found && tp.RouteTemplate() != "" {
+
+		pt, err := httpendpoint.ParsePathTemplate(tp.RouteTemplate())
+
+		if err != nil {
+			return errors.New(fmt.Sprintf("%s: %s", describeProvider(p), err.Error()))
+		}
+
+		rt.template = pt.Template
+
+		n := r.root
+
+		for _, s := range pt.Segments {
+			n = n.child(s)
+		}
+
+		if err := checkAmbiguous(rt, n.templates); err != nil {
+			return err
+		}
+
+		n.templates = append(n.templates, rt)
+
+		return nil
+	}
+
+	pattern := p.RegexPattern()
+
+	re, err := regexp.Compile(pattern)
+
+	if err != nil {
+		return errors.New(fmt.Sprintf("%s: unable to compile regular expression from pattern %s: %s", describeProvider(p), pattern, err.Error()))
+	}
+
+	rt.regex = re
+
+	n := r.root
+
+	for _, s := range literalSegments(pattern) {
+		n = n.child(&httpendpoint.TemplateSegment{Literal: s})
+	}
+
+	if err := checkAmbiguous(rt, n.regexes); err != nil {
+		return err
+	}
+
+	n.regexes = append(n.regexes, rt)
+
+	sort.SliceStable(n.regexes, func(i, j int) bool {
+		return n.regexes[i].regex.String() < n.regexes[j].regex.String()
+	})
+
+	return nil
+}
+
+func checkAmbiguous(rt *route, existing []*route) error {
+
+	for _, e := range existing {
+
+		if rt.regex != nil && rt.regex.String() != e.regex.String() {
+			continue
+		}
+
+		if rt.provider.VersionAware() && e.provider.VersionAware() {
+			continue
+		}
+
+		for m := range rt.methods {
+
+			if e.methods[m] {
+
+				path := rt.template
+
+				if rt.regex != nil {
+					path = rt.regex.String()
+				}
+
+				return errors.New(fmt.Sprintf("%s and %s both handle %s %s (routes can only be shared by version aware providers)",
+					describeProvider(e.provider), describeProvider(rt.provider), m, path))
+			}
+		}
+	}
+
+	return nil
+}
+
+// find returns the provider that should handle a request with the supplied path and method. The accept function is used
+// to reject providers that otherwise match (e.g. because they do not support the requested version). If no provider
+// supports the method but providers for other methods match the path, those methods are returned in allowed.
+func (r *router) find(path, method string, accept func(httpendpoint.HttpEndpointProvider) bool) (p httpendpoint.HttpEndpointProvider, allowed []string) {
+
+	segments := httpendpoint.SplitPath(path)
+	matching := make([]*route, 0)
+
+	r.root.matchTemplates(segments, &matching)
+
+	literal := []*routeNode{r.root}
+	n := r.root
+
+	for _, s := range segments {
+
+		if n = n.literalChild(s); n == nil {
+			break
+		}
+
+		literal = append(literal, n)
+	}
+
+	for i := len(literal) - 1; i >= 0; i-- {
+		for _, rt := range literal[i].regexes {
+			if rt.regex.MatchString(path) {
+				matching = append(matching, rt)
+			}
+		}
+	}
+
+	methodMatched := false
+	other := make(map[string]bool)
+
+	for _, rt := range matching {
+
+		if !rt.methods[method] {
+			for m := range rt.methods {
+				other[m] = true
+			}
+
+			continue
+		}
+
+		methodMatched = true
+
+		if accept(rt.provider) {
+			return rt.provider, nil
+		}
+	}
+
+	if methodMatched || len(other) == 0 {
+		return nil, nil
+	}
+
+	allowed = make([]string, 0, len(other))
+
+	for m := range other {
+		allowed = append(allowed, m)
+	}
+
+	sort.Strings(allowed)
+
+	return nil, allowed
+}
+
+// Adds the routes of all templates that match the remaining segments of the path, most specific first.
+func (n *routeNode) matchTemplates(remaining []string, matching *[]*route) {
+
+	if len(remaining) == 0 {
+		*matching = append(*matching, n.templates...)
+		return
+	}
+
+	for _, c := range n.children {
+
+		s := c.segment
+
+		if s.Type == httpendpoint.PathParam {
+			*matching = append(*matching, c.templates...)
+			continue
+		}
+
+		if s.Matches(remaining[0]) {
+			c.matchTemplates(remaining[1:], matching)
+		}
+	}
+}
+
+// Returns the child node for the supplied segment, creating it if necessary. Children are kept in order of specificity.
+func (n *routeNode) child(s *httpendpoint.TemplateSegment) *routeNode {
+
+	key := s.Key()
+
+	for _, c := range n.children {
+		if c.segment.Key() == key {
+			return c
+		}
+	}
+
+	c := &routeNode{segment: s}
+
+	n.children = append(n.children, c)
+
+	sort.SliceStable(n.children, func(i, j int) bool {
+		return n.children[i].segment.Rank() < n.children[j].segment.Rank()
+	})
+
+	return c
+}
+
+func (n *routeNode) literalChild(s string) *routeNode {
+
+	for _, c := range n.children {
+		if !c.segment.IsParam() && c.segment.Literal == s {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// Finds the complete /-separated segments of literal text at the start of a ^ anchored regular expression.
+func literalSegments(pattern string) []string {
+
+	re, err := syntax.Parse(pattern, syntax.Perl)
+
+	if err != nil {
+		return nil
+	}
+
+	re = re.Simplify()
+
+	if re.Op != syntax.OpConcat || len(re.Sub) < 2 || re.Sub[0].Op != syntax.OpBeginText {
+		return nil
+	}
+
+	var prefix []rune
+
+	for _, sub := range re.Sub[1:] {
+
+		if sub.Op != syntax.OpLiteral || sub.Flags&syntax.FoldCase != 0 {
+			break
+		}
+
+		prefix = append(prefix, sub.Rune...)
+	}
+
+	p := string(prefix)
+
+	if !strings.HasPrefix(p, "/") {
+		return nil
+	}
+
+	// Only text followed by a / is a complete segment
+	last := strings.LastIndex(p, "/")
+
+	return httpendpoint.SplitPath(p[:last])
+}
+
+func describeProvider(p interface{}) string {
+
+	if n, found := p.(ioc.ComponentNamer); found && n.ComponentName() != "" {
+		return n.ComponentName()
+	}
+
+	return fmt.Sprintf("%T", p)
+}
@@ -0,0 +1,223 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"context"
+	"github.com/graniticio/granitic/httpendpoint"
+	"github.com/graniticio/granitic/ioc"
+	"github.com/graniticio/granitic/logging"
+	"github.com/graniticio/granitic/test"
+	"github.com/graniticio/granitic/ws"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testProvider struct {
+	name     string
+	methods  []string
+	pattern  string
+	template string
+	versions bool
+	served   int
+}
+
+func (tp *testProvider) SupportedHttpMethods() []string {
+	return tp.methods
+}
+
+func (tp *testProvider) RegexPattern() string {
+	return tp.pattern
+}
+
+func (tp *testProvider) RouteTemplate() string {
+	return tp.template
+}
+
+func (tp *testProvider) ServeHttp(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request) context.Context {
+	tp.served++
+	w.WriteHeader(http.StatusOK)
+
+	return ctx
+}
+
+func (tp *testProvider) VersionAware() bool {
+	return tp.versions
+}
+
+func (tp *testProvider) SupportsVersion(version httpendpoint.RequiredVersion) bool {
+	return version["v"] == tp.name
+}
+
+func (tp *testProvider) AutoWireable() bool {
+	return true
+}
+
+func (tp *testProvider) ComponentName() string {
+	return tp.name
+}
+
+func (tp *testProvider) SetComponentName(name string) {
+	tp.name = name
+}
+
+func template(name, t string, methods ...string) *testProvider {
+	return &testProvider{name: name, template: t, methods: methods}
+}
+
+func pattern(name, p string, methods ...string) *testProvider {
+	return &testProvider{name: name, pattern: p, methods: methods}
+}
+
+func acceptAll(p httpendpoint.HttpEndpointProvider) bool {
+	return true
+}
+
+func findName(r *router, path, method string) string {
+
+	p, _ := r.find(path, method, acceptAll)
+
+	if p == nil {
+		return ""
+	}
+
+	return p.(*testProvider).name
+}
+
+func TestMostSpecificTemplateWins(t *testing.T) {
+
+	r := newRouter()
+
+	providers := []*testProvider{
+		template("rest", "/artist/{rest:path}", "GET"),
+		template("any", "/artist/{name}", "GET"),
+		template("alnum", "/artist/{code:alnum}", "GET"),
+		template("id", "/artist/{id:int}", "GET"),
+		template("latest", "/artist/latest", "GET"),
+		template("albums", "/artist/{id:int}/albums", "GET"),
+		template("root", "/", "GET"),
+		pattern("regex", "^/artist/[a-z]+-[a-z]+$", "GET"),
+		pattern("legacy", "^/legacy/([\\d]+)$", "GET"),
+	}
+
+	for _, p := range providers {
+		test.ExpectNil(t, r.add(p))
+	}
+
+	test.ExpectString(t, findName(r, "/artist/latest", "GET"), "latest")
+	test.ExpectString(t, findName(r, "/artist/12", "GET"), "id")
+	test.ExpectString(t, findName(r, "/artist/12/", "GET"), "id")
+	test.ExpectString(t, findName(r, "/artist/a12", "GET"), "alnum")
+	test.ExpectString(t, findName(r, "/artist/a-b", "GET"), "any")
+	test.ExpectString(t, findName(r, "/artist/12/albums", "GET"), "albums")
+	test.ExpectString(t, findName(r, "/artist/12/singles", "GET"), "rest")
+	test.ExpectString(t, findName(r, "/", "GET"), "root")
+	test.ExpectString(t, findName(r, "/legacy/1", "GET"), "legacy")
+	test.ExpectString(t, findName(r, "/legacy/x", "GET"), "")
+	test.ExpectString(t, findName(r, "/other", "GET"), "")
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+
+	r := newRouter()
+
+	test.ExpectNil(t, r.add(template("get", "/record/{id:int}", "GET")))
+	test.ExpectNil(t, r.add(template("delete", "/record/{id:int}", "DELETE")))
+	test.ExpectNil(t, r.add(pattern("put", "^/record/\\d+$", "PUT", "PATCH")))
+
+	p, allowed := r.find("/record/1", "POST", acceptAll)
+	test.ExpectBool(t, p == nil, true)
+	test.ExpectString(t, strings.Join(allowed, ","), "DELETE,GET,PATCH,PUT")
+
+	p, allowed = r.find("/record/x", "POST", acceptAll)
+	test.ExpectBool(t, p == nil, true)
+	test.ExpectInt(t, len(allowed), 0)
+
+	test.ExpectString(t, findName(r, "/record/1", "PATCH"), "put")
+}
+
+func TestAmbiguousRoutes(t *testing.T) {
+
+	r := newRouter()
+
+	test.ExpectNil(t, r.add(template("a", "/record/{id:int}", "GET", "POST")))
+	test.ExpectNil(t, r.add(template("b", "/record/{key:int}", "PUT")))
+	test.ExpectNil(t, r.add(template("c", "/record/{key}", "POST")))
+
+	err := r.add(template("d", "/record/{key:int}/", "POST"))
+	test.ExpectNotNil(t, err)
+	test.ExpectBool(t, strings.Contains(err.Error(), "a and d"), true)
+
+	test.ExpectNil(t, r.add(pattern("e", "^/x$", "GET")))
+	test.ExpectNotNil(t, r.add(pattern("f", "^/x$", "GET")))
+	test.ExpectNil(t, r.add(pattern("g", "^/x$", "PUT")))
+
+	test.ExpectNotNil(t, r.add(pattern("h", "^/x[$", "GET")))
+	test.ExpectNotNil(t, r.add(template("i", "/x/{y:nope}", "GET")))
+
+	v1 := template("1", "/v/{id}", "GET")
+	v2 := template("2", "/v/{id}", "GET")
+	v1.versions = true
+	v2.versions = true
+
+	test.ExpectNil(t, r.add(v1))
+	test.ExpectNil(t, r.add(v2))
+
+	p, _ := r.find("/v/a", "GET", func(p httpendpoint.HttpEndpointProvider) bool {
+		return p.SupportsVersion(httpendpoint.RequiredVersion{"v": "2"})
+	})
+
+	test.ExpectString(t, p.(*testProvider).name, "2")
+}
+
+func TestLiteralSegments(t *testing.T) {
+
+	test.ExpectString(t, strings.Join(literalSegments("^/artist/album/([\\d]+)$"), ","), "artist,album")
+	test.ExpectString(t, strings.Join(literalSegments("^/artist"), ","), "")
+	test.ExpectString(t, strings.Join(literalSegments("/artist/"), ","), "")
+	test.ExpectString(t, strings.Join(literalSegments("^(?i)/artist/"), ","), "")
+}
+
+type statusWriter struct{}
+
+func (sw *statusWriter) WriteAbnormalStatus(ctx context.Context, state *ws.WsProcessState) error {
+	state.HttpResponseWriter.WriteHeader(state.Status)
+
+	return nil
+}
+
+func TestSingleDispatch(t *testing.T) {
+
+	a := template("a", "/record/{id:int}", "GET")
+	b := pattern("b", "^/record/.*$", "GET")
+
+	h := new(HttpServer)
+	h.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	h.AbnormalStatusWriter = new(statusWriter)
+	h.router = newRouter()
+	h.state = ioc.RunningState
+
+	test.ExpectNil(t, h.registerProvider(a))
+	test.ExpectNil(t, h.registerProvider(b))
+
+	rec := httptest.NewRecorder()
+	h.handleAll(rec, httptest.NewRequest("GET", "/record/1", nil))
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectInt(t, a.served, 1)
+	test.ExpectInt(t, b.served, 0)
+
+	rec = httptest.NewRecorder()
+	h.handleAll(rec, httptest.NewRequest("DELETE", "/record/1", nil))
+
+	test.ExpectInt(t, rec.Code, http.StatusMethodNotAllowed)
+	test.ExpectString(t, rec.Header().Get("Allow"), "GET")
+
+	rec = httptest.NewRecorder()
+	h.handleAll(rec, httptest.NewRequest("GET", "/other", nil))
+
+	test.ExpectInt(t, rec.Code, http.StatusNotFound)
+}
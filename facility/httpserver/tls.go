@@ -0,0 +1,359 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/logging"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Values for TLSSettings.ClientAuth
+const (
+	// Client certificates are not requested.
+	NoClientAuth = "none"
+
+	// Client certificates are requested and, if supplied, must be signed by a CA in TLSSettings.ClientCAFile.
+	OptionalClientAuth = "optional"
+
+	// Clients must supply a certificate signed by a CA in TLSSettings.ClientCAFile.
+	RequiredClientAuth = "require"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+/*
+TLSSettings controls whether an HttpServer accepts HTTPS rather than HTTP connections. Normally set in configuration:
+
+	{
+	  "HttpServer": {
+	    "TLS": {
+	      "Enabled": true,
+	      "CertFile": "/etc/myapp/server.crt",
+	      "KeyFile": "/etc/myapp/server.key",
+	      "MinVersion": "1.2",
+	      "ClientCAFile": "/etc/myapp/clients.pem",
+	      "ClientAuth": "require"
+	    }
+	  }
+	}
+
+The certificate, key and client CA files are checked for changes every CertificateCheckIntervalMS milliseconds (while
+the server is handling new connections) and reloaded if they have been modified, so certificates can be renewed without
+restarting the application. If modified files cannot be loaded, an error is logged and the previous certificate and
+client CAs continue to be used.
+
+When client certificates are verified, handlers' ws.WsIdentifier components can use ws.VerifiedPeerCertificate to find
+the certificate presented by the caller.
+*/
+type TLSSettings struct {
+	// Whether or not the server should accept TLS (HTTPS) connections instead of plain HTTP connections.
+	Enabled bool
+
+	// Path to a PEM encoded certificate (optionally followed by intermediate certificates).
+	CertFile string
+
+	// Path to the PEM encoded private key for the certificate.
+	KeyFile string
+
+	// The lowest version of TLS accepted (1.0, 1.1, 1.2 or 1.3).
+	MinVersion string
+
+	// The names of the cipher suites that may be negotiated for TLS 1.2 and earlier (e.g.
+	// TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256). If empty, Go's default suites are used.
+	CipherSuites []string
+
+	// Path to a PEM encoded bundle of the CA certificates used to verify client certificates.
+	ClientCAFile string
+
+	// Whether client certificates are requested and verified (none, optional or require).
+	ClientAuth string
+
+	// How often (in milliseconds) the certificate, key and client CA files are checked for changes. Zero disables reloading.
+	CertificateCheckIntervalMS int
+}
+
+// Builds a tls.Config from the supplied settings.
+func buildTLSConfig(s *TLSSettings, cl *certificateLoader) (*tls.Config, error) {
+
+	if s.CertFile == "" || s.KeyFile == "" {
+		return nil, errors.New("HttpServer.TLS.CertFile and HttpServer.TLS.KeyFile must be set when TLS is enabled")
+	}
+
+	tc := new(tls.Config)
+	tc.GetCertificate = cl.certificate
+
+	if s.MinVersion != "" {
+
+		v, found := tlsVersions[s.MinVersion]
+
+		if !found {
+			return nil, errors.New(fmt.Sprintf("HttpServer.TLS.MinVersion %s is not supported (use 1.0, 1.1, 1.2 or 1.3)", s.MinVersion))
+		}
+
+		tc.MinVersion = v
+	}
+
+	if len(s.CipherSuites) > 0 {
+
+		ids, err := cipherSuiteIDs(s.CipherSuites)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tc.CipherSuites = ids
+	}
+
+	switch s.ClientAuth {
+	case "", NoClientAuth:
+		tc.ClientAuth = tls.NoClientCert
+	case OptionalClientAuth:
+		tc.ClientAuth = tls.VerifyClientCertIfGiven
+	case RequiredClientAuth:
+		tc.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, errors.New(fmt.Sprintf("HttpServer.TLS.ClientAuth must be %s, %s or %s (was %s)", NoClientAuth, OptionalClientAuth, RequiredClientAuth, s.ClientAuth))
+	}
+
+	if tc.ClientAuth != tls.NoClientCert {
+
+		if s.ClientCAFile == "" {
+			return nil, errors.New("HttpServer.TLS.ClientCAFile must be set if client certificates are to be verified")
+		}
+
+		// Each connection uses a copy of this configuration with the most recently loaded client CAs
+		cl.verifyClients(tc)
+		tc.GetConfigForClient = cl.configForClient
+	}
+
+	return tc, nil
+}
+
+// Whether the settings request that client certificates are verified.
+func verifiesClients(s *TLSSettings) bool {
+	return s.ClientAuth == OptionalClientAuth || s.ClientAuth == RequiredClientAuth
+}
+
+func cipherSuiteIDs(names []string) ([]uint16, error) {
+
+	known := make(map[string]uint16)
+
+	for _, cs := range tls.CipherSuites() {
+		known[cs.Name] = cs.ID
+	}
+
+	for _, cs := range tls.InsecureCipherSuites() {
+		known[cs.Name] = cs.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	unknown := make([]string, 0)
+
+	for _, n := range names {
+
+		if id, found := known[n]; found {
+			ids = append(ids, id)
+		} else {
+			unknown = append(unknown, n)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return nil, errors.New(fmt.Sprintf("Unknown cipher suite(s) in HttpServer.TLS.CipherSuites: %s", strings.Join(unknown, ", ")))
+	}
+
+	return ids, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+
+	b, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to read client CA file: %s", err.Error()))
+	}
+
+	pool := x509.NewCertPool()
+
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, errors.New(fmt.Sprintf("No PEM encoded certificates found in client CA file %s", path))
+	}
+
+	return pool, nil
+}
+
+// Loads a certificate and key pair (and the CAs used to verify client certificates) and reloads them if any of the files
+// is modified.
+type certificateLoader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	interval     time.Duration
+	log          logging.Logger
+
+	mutex     sync.Mutex
+	current   *tls.Certificate
+	clientCAs *x509.CertPool
+	modified  time.Time
+	lastCheck time.Time
+
+	// The configuration that per-connection configurations are copied from, and the copy using the current client CAs.
+	clientBase   *tls.Config
+	clientConfig *tls.Config
+}
+
+func newCertificateLoader(s *TLSSettings, log logging.Logger) *certificateLoader {
+
+	cl := new(certificateLoader)
+	cl.certFile = s.CertFile
+	cl.keyFile = s.KeyFile
+	cl.interval = time.Duration(s.CertificateCheckIntervalMS) * time.Millisecond
+	cl.log = log
+
+	if verifiesClients(s) {
+		cl.clientCAFile = s.ClientCAFile
+	}
+
+	return cl
+}
+
+// load reads the certificate, key and client CA files, replacing the current certificate and client CAs if they can all
+// be loaded.
+func (cl *certificateLoader) load() error {
+
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	return cl.loadLocked()
+}
+
+func (cl *certificateLoader) loadLocked() error {
+
+	modified := cl.lastModified()
+
+	cert, err := tls.LoadX509KeyPair(cl.certFile, cl.keyFile)
+
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to load TLS certificate %s and key %s: %s", cl.certFile, cl.keyFile, err.Error()))
+	}
+
+	var pool *x509.CertPool
+
+	if cl.clientCAFile != "" {
+
+		if pool, err = loadCertPool(cl.clientCAFile); err != nil {
+			return err
+		}
+	}
+
+	cl.current = &cert
+	cl.clientCAs = pool
+	cl.modified = modified
+	cl.lastCheck = time.Now()
+
+	cl.buildClientConfig()
+
+	return nil
+}
+
+// verifyClients records the configuration that the configurations returned by configForClient are copied from.
+func (cl *certificateLoader) verifyClients(base *tls.Config) {
+
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	cl.clientBase = base.Clone()
+	cl.clientBase.GetConfigForClient = nil
+
+	base.ClientCAs = cl.clientCAs
+
+	cl.buildClientConfig()
+}
+
+// Creates a copy of the base configuration that verifies client certificates with the current client CAs.
+func (cl *certificateLoader) buildClientConfig() {
+
+	if cl.clientBase == nil {
+		return
+	}
+
+	c := cl.clientBase.Clone()
+	c.ClientCAs = cl.clientCAs
+
+	cl.clientConfig = c
+}
+
+// configForClient is used as tls.Config.GetConfigForClient when client certificates are verified.
+func (cl *certificateLoader) configForClient(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	cl.reloadIfModified()
+
+	return cl.clientConfig, nil
+}
+
+// certificate is used as tls.Config.GetCertificate
+func (cl *certificateLoader) certificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	cl.reloadIfModified()
+
+	return cl.current, nil
+}
+
+// Reloads the certificate and client CAs if the check interval has passed and any of the files have been modified. Must
+// be called while holding the mutex.
+func (cl *certificateLoader) reloadIfModified() {
+
+	if cl.interval > 0 && time.Since(cl.lastCheck) >= cl.interval {
+
+		cl.lastCheck = time.Now()
+
+		if m := cl.lastModified(); !m.Equal(cl.modified) {
+
+			if err := cl.loadLocked(); err != nil {
+				cl.log.LogErrorf("%s. Continuing to use the previous certificate and client CAs", err.Error())
+				cl.modified = m
+			} else {
+				cl.log.LogInfof("Reloaded TLS certificate %s", cl.certFile)
+			}
+		}
+	}
+}
+
+// The most recent modification time of the certificate, key and client CA files.
+func (cl *certificateLoader) lastModified() time.Time {
+
+	var latest time.Time
+
+	for _, f := range []string{cl.certFile, cl.keyFile, cl.clientCAFile} {
+
+		if f == "" {
+			continue
+		}
+
+		if info, err := os.Stat(f); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest
+}
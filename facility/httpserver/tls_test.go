@@ -0,0 +1,213 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/graniticio/granitic/logging"
+	"github.com/graniticio/granitic/test"
+	"github.com/graniticio/granitic/ws"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+var serial int64
+
+func newTestCert(t *testing.T, cn string, parent *testCert) *testCert {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.ExpectNil(t, err)
+
+	serial++
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	signer, signerKey := tmpl, key
+
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	test.ExpectNil(t, err)
+
+	c, err := x509.ParseCertificate(der)
+	test.ExpectNil(t, err)
+
+	return &testCert{c, key}
+}
+
+func (tc *testCert) write(t *testing.T, dir, name string) (string, string) {
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+
+	kb, err := x509.MarshalECPrivateKey(tc.key)
+	test.ExpectNil(t, err)
+
+	test.ExpectNil(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tc.cert.Raw}), 0600))
+	test.ExpectNil(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kb}), 0600))
+
+	return certFile, keyFile
+}
+
+func (tc *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{tc.cert.Raw}, PrivateKey: tc.key}
+}
+
+func TestMutualTLS(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "httpstls")
+	test.ExpectNil(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, "ca", nil)
+	caFile, _ := ca.write(t, dir, "ca")
+
+	server := newTestCert(t, "server", ca)
+	certFile, keyFile := server.write(t, dir, "server")
+
+	s := &TLSSettings{Enabled: true, CertFile: certFile, KeyFile: keyFile, MinVersion: "1.2", ClientCAFile: caFile,
+		ClientAuth: RequiredClientAuth, CertificateCheckIntervalMS: 1}
+
+	cl := newCertificateLoader(s, new(logging.ConsoleErrorLogger))
+	test.ExpectNil(t, cl.load())
+
+	tc, err := buildTLSConfig(s, cl)
+	test.ExpectNil(t, err)
+
+	var caller string
+
+	hs := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c := ws.VerifiedPeerCertificate(r); c != nil {
+			caller = c.Subject.CommonName
+		}
+	}))
+
+	hs.Listener = tls.NewListener(hs.Listener, tc)
+	hs.Start()
+	defer hs.Close()
+
+	url := "https://" + hs.Listener.Addr().String()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	client := newTestCert(t, "client", ca)
+
+	clientConfig := &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{client.tlsCertificate()}}
+	c := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}
+
+	_, err = c.Get(url)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, caller, "client")
+
+	// No client certificate
+	noCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+
+	_, err = noCert.Get(url)
+	test.ExpectNotNil(t, err)
+
+	// Certificate replaced on disk
+	renewed := newTestCert(t, "renewed", ca)
+	renewed.write(t, dir, "server")
+
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	time.Sleep(5 * time.Millisecond)
+
+	fresh := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}
+
+	r, err := fresh.Get(url)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, r.TLS.PeerCertificates[0].Subject.CommonName, "renewed")
+
+	// An unreadable replacement keeps the previous certificate
+	test.ExpectNil(t, ioutil.WriteFile(certFile, []byte("not a cert"), 0600))
+
+	later = later.Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	time.Sleep(5 * time.Millisecond)
+
+	fresh = &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}
+
+	r, err = fresh.Get(url)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, r.TLS.PeerCertificates[0].Subject.CommonName, "renewed")
+
+	// Client CAs replaced on disk
+	newCA := newTestCert(t, "newca", nil)
+	newClient := newTestCert(t, "newclient", newCA)
+	newClientConfig := &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{newClient.tlsCertificate()}}
+
+	_, err = (&http.Client{Transport: &http.Transport{TLSClientConfig: newClientConfig}}).Get(url)
+	test.ExpectNotNil(t, err)
+
+	// The certificate, key and client CAs are reloaded together, so the certificate must also be valid
+	renewed.write(t, dir, "server")
+	newCA.write(t, dir, "ca")
+
+	later = later.Add(time.Minute)
+	os.Chtimes(caFile, later, later)
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = (&http.Client{Transport: &http.Transport{TLSClientConfig: newClientConfig}}).Get(url)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, caller, "newclient")
+
+	_, err = (&http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}).Get(url)
+	test.ExpectNotNil(t, err)
+}
+
+func TestInvalidTLSSettings(t *testing.T) {
+
+	cl := new(certificateLoader)
+
+	invalid := []*TLSSettings{
+		{CertFile: "a"},
+		{CertFile: "a", KeyFile: "b", MinVersion: "2.0"},
+		{CertFile: "a", KeyFile: "b", CipherSuites: []string{"TLS_NOT_REAL"}},
+		{CertFile: "a", KeyFile: "b", ClientAuth: RequiredClientAuth},
+		{CertFile: "a", KeyFile: "b", ClientAuth: "sometimes"},
+	}
+
+	for _, s := range invalid {
+		_, err := buildTLSConfig(s, cl)
+		test.ExpectNotNil(t, err)
+	}
+
+	tc, err := buildTLSConfig(&TLSSettings{CertFile: "a", KeyFile: "b", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}}, cl)
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(tc.CipherSuites), 1)
+	test.ExpectBool(t, tc.ClientAuth == tls.NoClientCert, true)
+}
@@ -66,11 +66,28 @@ func (alfb *ApplicationLoggingFacilityBuilder) BuildAndRegister(lm *logging.Comp
 
 	cn.WrapAndAddProto(applicationLoggingDecoratorName, ald)
 
+	alfb.addReloadListener(ca, alm, cn)
 	alfb.addRuntimeCommands(ca, alm, lm, cn)
 
 	return nil
 }
 
+// Registers a component to apply changes to application log levels, if configuration can be reloaded while the application is running.
+func (alfb *ApplicationLoggingFacilityBuilder) addReloadListener(ca *config.ConfigAccessor, alm *logging.ComponentLoggerManager, cn *ioc.ComponentContainer) {
+
+	if reload, _ := ca.BoolVal("System.AllowConfigReload"); !reload {
+		return
+	}
+
+	lll := new(logLevelListener)
+	lll.manager = alm
+
+	lp := ioc.CreateProtoComponent(lll, applicationLogLevelListenerName)
+	lp.AddConfigPromise("GlobalLogLevel", "ApplicationLogger.GlobalLogLevel")
+	lp.AddConfigPromise("ComponentLogLevels", "ApplicationLogger.ComponentLogLevels")
+	cn.AddProto(lp)
+}
+
 func (alfb *ApplicationLoggingFacilityBuilder) addRuntimeCommands(ca *config.ConfigAccessor, alm *logging.ComponentLoggerManager, flm *logging.ComponentLoggerManager, cn *ioc.ComponentContainer) {
 
 	if !runtimectl.RuntimeCtlEnabled(ca) {
@@ -0,0 +1,50 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package logger
+
+import (
+	"github.com/graniticio/granitic/config"
+	"github.com/graniticio/granitic/instance"
+	"github.com/graniticio/granitic/logging"
+)
+
+const applicationLogLevelListenerName = instance.FrameworkPrefix + "ApplicationLogLevelListener"
+
+// Applies changes to the global and component log levels of application loggers when configuration is reloaded.
+type logLevelListener struct {
+	GlobalLogLevel     string
+	ComponentLogLevels map[string]interface{}
+	manager            *logging.ComponentLoggerManager
+}
+
+// ConfigChanged implements config.ConfigChangeListener
+func (ll *logLevelListener) ConfigChanged(changes []*config.PromiseChange, ca *config.ConfigAccessor) error {
+
+	for _, c := range changes {
+
+		if err := ca.SetField(c.Field, c.Path, ll); err != nil {
+			return err
+		}
+	}
+
+	global, err := logging.LogLevelFromLabel(ll.GlobalLogLevel)
+
+	if err != nil {
+		return err
+	}
+
+	for _, v := range ll.ComponentLogLevels {
+
+		label, _ := v.(string)
+
+		if _, err := logging.LogLevelFromLabel(label); err != nil {
+			return err
+		}
+	}
+
+	ll.manager.SetGlobalThreshold(global)
+	ll.manager.SetInitialLogLevels(ll.ComponentLogLevels)
+
+	return nil
+}
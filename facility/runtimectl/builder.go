@@ -78,6 +78,8 @@ const (
 	stopCommandComp            = instance.FrameworkPrefix + "CommandStop"
 	suspendCommandComp         = instance.FrameworkPrefix + "CommandSuspend"
 	resumeCommandComp          = instance.FrameworkPrefix + "CommandResume"
+	reloadCommandComp          = instance.FrameworkPrefix + "CommandReload"
+	configCommandComp          = instance.FrameworkPrefix + "CommandConfig"
 	defaultValidationCode      = "INV_CTL_REQUEST"
 )
 
@@ -187,7 +189,7 @@ func (fb *RuntimeCtlFacilityBuilder) BuildAndRegister(lm *logging.ComponentLogge
 	cd.FrameworkLogger = lm.CreateLogger(runtimeCtlCommandDecorator)
 	cc.WrapAndAddProto(runtimeCtlCommandDecorator, cd)
 
-	fb.createBuiltinCommands(lm, cc, cm)
+	fb.createBuiltinCommands(lm, ca, cc, cm)
 
 	//Command logic
 	cl := new(ctl.CommandLogic)
@@ -198,7 +200,7 @@ func (fb *RuntimeCtlFacilityBuilder) BuildAndRegister(lm *logging.ComponentLogge
 	return nil
 }
 
-func (fb *RuntimeCtlFacilityBuilder) createBuiltinCommands(lm *logging.ComponentLoggerManager, cc *ioc.ComponentContainer, cm *ctl.CommandManager) {
+func (fb *RuntimeCtlFacilityBuilder) createBuiltinCommands(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cc *ioc.ComponentContainer, cm *ctl.CommandManager) {
 
 	sd := new(shutdownCommand)
 	fb.addCommand(cc, shutdownCommandComp, sd)
@@ -222,6 +224,13 @@ func (fb *RuntimeCtlFacilityBuilder) createBuiltinCommands(lm *logging.Component
 	resumec := NewResumeCommand()
 	fb.addCommand(cc, resumeCommandName, resumec)
 
+	reloadc := new(reloadCommand)
+	fb.addCommand(cc, reloadCommandComp, reloadc)
+
+	configc := new(configCommand)
+	configc.Accessor = ca
+	fb.addCommand(cc, configCommandComp, configc)
+
 }
 
 func (fb *RuntimeCtlFacilityBuilder) addCommand(cc *ioc.ComponentContainer, name string, c ctl.Command) {
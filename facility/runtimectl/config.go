@@ -0,0 +1,90 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package runtimectl
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/graniticio/granitic/config"
+	"github.com/graniticio/granitic/ctl"
+	"github.com/graniticio/granitic/ioc"
+	"github.com/graniticio/granitic/logging"
+	"github.com/graniticio/granitic/ws"
+)
+
+const (
+	configCommandName = "config"
+	configSummary     = "Shows the application's merged configuration."
+	configUsage       = "config [path]"
+	configHelp        = "Shows each value in the application's merged configuration (or only those values inside the supplied " +
+		"dot-delimited path) and the file or URL the value was loaded from. Values resolved from ${env:...} and ${file:...} placeholders are masked."
+	configHelpTwo = "Configuration is normally discarded after startup. Set System.FlushMergedConfig to false to use this command."
+)
+
+type configCommand struct {
+	FrameworkLogger logging.Logger
+	Accessor        *config.ConfigAccessor
+	container       *ioc.ComponentContainer
+}
+
+func (c *configCommand) Container(container *ioc.ComponentContainer) {
+	c.container = container
+}
+
+func (c *configCommand) ExecuteCommand(qualifiers []string, args map[string]string) (*ctl.CommandOutput, []*ws.CategorisedError) {
+
+	data := c.Accessor.CurrentJsonData()
+
+	if data == nil {
+		return nil, []*ws.CategorisedError{ctl.NewCommandLogicError("Configuration was discarded after startup (set System.FlushMergedConfig to false)")}
+	}
+
+	var jm *config.JsonMerger
+
+	if mc := c.container.ComponentByName(config.JsonMergerComponentName); mc != nil {
+		jm = mc.Instance.(*config.JsonMerger)
+	}
+
+	prefix := ""
+
+	if len(qualifiers) > 0 {
+		prefix = qualifiers[0]
+	}
+
+	values := config.EffectiveConfig(data, jm, prefix)
+
+	if len(values) == 0 {
+		m := fmt.Sprintf("No configuration found at %s", prefix)
+		return nil, []*ws.CategorisedError{ctl.NewCommandClientError(m)}
+	}
+
+	body := make([][]string, len(values))
+
+	for i, sv := range values {
+		v, _ := json.Marshal(sv.Value)
+		body[i] = []string{sv.Path, sv.Source, string(v)}
+	}
+
+	co := new(ctl.CommandOutput)
+	co.OutputBody = body
+	co.RenderHint = ctl.Columns
+
+	return co, nil
+}
+
+func (c *configCommand) Name() string {
+	return configCommandName
+}
+
+func (c *configCommand) Summmary() string {
+	return configSummary
+}
+
+func (c *configCommand) Usage() string {
+	return configUsage
+}
+
+func (c *configCommand) Help() []string {
+	return []string{configHelp, configHelpTwo}
+}
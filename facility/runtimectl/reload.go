@@ -0,0 +1,83 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package runtimectl
+
+import (
+	"github.com/graniticio/granitic/config"
+	"github.com/graniticio/granitic/ctl"
+	"github.com/graniticio/granitic/ioc"
+	"github.com/graniticio/granitic/logging"
+	"github.com/graniticio/granitic/ws"
+)
+
+const (
+	reloadCommandName = "reload"
+	reloadSummary     = "Reloads configuration and notifies components of any changes."
+	reloadUsage       = "reload"
+	reloadHelp        = "Re-loads and merges the configuration files and URLs the application was started with. Components implementing " +
+		"config.ConfigChangeListener are notified if configuration promised to their fields has changed."
+	reloadHelpTwo = "Configuration can only be reloaded if System.AllowConfigReload is set to true in configuration."
+)
+
+type reloadCommand struct {
+	FrameworkLogger logging.Logger
+	container       *ioc.ComponentContainer
+}
+
+func (c *reloadCommand) Container(container *ioc.ComponentContainer) {
+	c.container = container
+}
+
+func (c *reloadCommand) ExecuteCommand(qualifiers []string, args map[string]string) (*ctl.CommandOutput, []*ws.CategorisedError) {
+
+	rc := c.container.ComponentByName(config.ConfigReloaderComponentName)
+
+	if rc == nil {
+		return nil, []*ws.CategorisedError{ctl.NewCommandLogicError("Configuration reloading is not enabled (set System.AllowConfigReload to true)")}
+	}
+
+	c.FrameworkLogger.LogInfof("Reloading configuration (runtime command)")
+
+	changed, err := rc.Instance.(*config.ConfigReloader).Reload()
+
+	if err != nil {
+		return nil, []*ws.CategorisedError{ctl.NewCommandUnexpectedError(err.Error())}
+	}
+
+	co := new(ctl.CommandOutput)
+
+	if len(changed) == 0 {
+		co.OutputHeader = "Configuration reloaded. No changes found."
+		return co, nil
+	}
+
+	co.OutputHeader = "Configuration reloaded. Changed paths:"
+
+	paths := make([][]string, len(changed))
+
+	for i, p := range changed {
+		paths[i] = []string{p}
+	}
+
+	co.OutputBody = paths
+	co.RenderHint = ctl.Columns
+
+	return co, nil
+}
+
+func (c *reloadCommand) Name() string {
+	return reloadCommandName
+}
+
+func (c *reloadCommand) Summmary() string {
+	return reloadSummary
+}
+
+func (c *reloadCommand) Usage() string {
+	return reloadUsage
+}
+
+func (c *reloadCommand) Help() []string {
+	return []string{reloadHelp, reloadHelpTwo}
+}
@@ -27,6 +27,12 @@ type JsonWsFacilityBuilder struct {
 // See FacilityBuilder.BuildAndRegister
 func (fb *JsonWsFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cn *ioc.ComponentContainer) error {
 
+	mediaTypes, err := configuredMediaTypes(ca, "JsonWs.MediaTypes")
+
+	if err != nil {
+		return err
+	}
+
 	wc := buildAndRegisterWsCommon(lm, ca, cn)
 
 	um := new(json.StandardJSONUnmarshaller)
@@ -39,7 +45,10 @@ func (fb *JsonWsFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerMan
 	rw.StatusDeterminer = wc.StatusDeterminer
 	rw.FrameworkErrors = wc.FrameworkErrors
 
-	buildRegisterWsDecorator(cn, rw, um, wc, lm)
+	bindForms, _ := ca.BoolVal("JsonWs.BindForms")
+
+	f := &wsFormat{mediaTypes, um, rw, jsonResponseWriterComponentName, bindForms}
+	buildRegisterWsDecorator(cn, ca, f, wc, lm)
 
 	if !cn.ModifierExists(jsonResponseWriterComponentName, "ErrorFormatter") {
 		rw.ErrorFormatter = new(json.GraniticJSONErrorFormatter)
@@ -80,7 +89,7 @@ func (fb *JsonWsFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerMan
 		rw.MarshalingWriter = mw
 	}
 
-	offerAbnormalStatusWriter(rw, cn, jsonResponseWriterComponentName)
+	offerAbnormalStatusWriter(rw, ca, cn, jsonResponseWriterComponentName)
 
 	return nil
 }
@@ -54,16 +54,80 @@
 
 	Many aspects of the parsing and rendering process (including content types and formatting of errors) is configurable.
 	Refer to http://granitic.io/1.0/ref/xml for more details.
+
+	Content negotiation
+
+	The JsonWs and XmlWs facilities can be enabled at the same time. Handlers are then given a ws.NegotiatingUnmarshaller
+	and a ws.NegotiatingResponseWriter, which choose between JSON and XML using the Content-Type and Accept headers of
+	each request. The media types associated with each format are set in configuration:
+
+		{
+		  "JsonWs": {
+			"MediaTypes": ["application/json"]
+		  },
+		  "XmlWs": {
+			"MediaTypes": ["application/xml", "text/xml"]
+		  }
+		}
+
+	JSON is used for requests that do not specify a preference. Requests with bodies in formats other than JSON or XML
+	(or forms, see below) receive a 415 response and requests that do not accept either format receive a 406 response.
+
+	Forms
+
+	Handlers can also accept form bodies (application/x-www-form-urlencoded and multipart/form-data), which are bound onto
+	request bodies by a form.FormUnmarshaller (see the ws/form package). Form binding is disabled by default, as some
+	HTTP clients send JSON and XML bodies with a form Content-Type. It is enabled with the BindForms setting of either
+	facility:
+
+		{
+		  "JsonWs": {
+			"BindForms": true
+		  }
+		}
+
+	Handlers are then given a ws.NegotiatingUnmarshaller which chooses how to parse each request body using its
+	Content-Type header. If only one of the JsonWs and XmlWs facilities is enabled, bodies of every type other than a
+	form are parsed in that facility's format.
+
+	The limits applied to multipart form bodies by the facilities' form.FormUnmarshaller are set in configuration:
+
+		{
+		  "FormUnmarshaller": {
+			"MaxMemoryBytes": 33554432,
+			"MaxFileBytes": 1048576
+		  }
+		}
+
+	A MaxFileBytes of zero means uploaded files are only limited by the size of the request body.
+
+	Parameter binding
+
+	Query and path parameters, headers, cookies and form fields are converted and bound onto request bodies by a single
+	ws.ParamBinder shared by both facilities. The layouts used to parse times and the separator used to split the values
+	bound into slice fields are set in configuration:
+
+		{
+		  "ParamBinder": {
+			"TimeLayouts": ["2006-01-02T15:04:05Z07:00", "2006-01-02"],
+			"ValueSeparator": ","
+		  }
+		}
+
+	Set ValueSeparator to an empty string if values should never be split.
 */
 package ws
 
 import (
+	"errors"
+	"fmt"
 	"github.com/graniticio/granitic/config"
 	"github.com/graniticio/granitic/facility/httpserver"
 	"github.com/graniticio/granitic/instance"
 	"github.com/graniticio/granitic/ioc"
 	"github.com/graniticio/granitic/logging"
 	"github.com/graniticio/granitic/ws"
+	"github.com/graniticio/granitic/ws/form"
 	"github.com/graniticio/granitic/ws/handler"
 )
 
@@ -71,21 +135,35 @@ const wsHttpStatusDeterminerComponentName = instance.FrameworkPrefix + "HttpStat
 const wsParamBinderComponentName = instance.FrameworkPrefix + "ParamBinder"
 const wsFrameworkErrorGenerator = instance.FrameworkPrefix + "FrameworkErrorGenerator"
 const wsHandlerDecoratorName = instance.FrameworkPrefix + "WsHandlerDecorator"
+const wsNegotiatingUnmarshallerName = instance.FrameworkPrefix + "NegotiatingUnmarshaller"
+const wsNegotiatingResponseWriterName = instance.FrameworkPrefix + "NegotiatingResponseWriter"
+const wsFormUnmarshallerName = instance.FrameworkPrefix + "FormUnmarshaller"
+
+func offerAbnormalStatusWriter(arw ws.AbnormalStatusWriter, ca *config.ConfigAccessor, cc *ioc.ComponentContainer, name string) {
 
-func offerAbnormalStatusWriter(arw ws.AbnormalStatusWriter, cc *ioc.ComponentContainer, name string) {
+	for _, server := range httpserver.ServerNames(ca) {
 
-	if !cc.ModifierExists(httpserver.HttpServerComponentName, httpserver.HttpServerAbnormalStatusFieldName) {
-		//The HTTP server does not have an AbnormalStatusWriter defined
-		cc.AddModifier(httpserver.HttpServerComponentName, httpserver.HttpServerAbnormalStatusFieldName, name)
+		sc := httpserver.ServerComponentName(server)
+
+		if !cc.ModifierExists(sc, httpserver.HttpServerAbnormalStatusFieldName) {
+			//The HTTP server does not have an AbnormalStatusWriter defined
+			cc.AddModifier(sc, httpserver.HttpServerAbnormalStatusFieldName, name)
+		}
 	}
 }
 
 func buildAndRegisterWsCommon(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cn *ioc.ComponentContainer) *wsCommon {
 
+	if d := existingDecorator(cn); d != nil {
+		// Another web service facility is enabled and has already created the common components
+		return d.common
+	}
+
 	scd := new(ws.GraniticHttpStatusCodeDeterminer)
 	cn.WrapAndAddProto(wsHttpStatusDeterminerComponentName, scd)
 
 	pb := new(ws.ParamBinder)
+	ca.Populate("ParamBinder", pb)
 	cn.WrapAndAddProto(wsParamBinderComponentName, pb)
 
 	feg := new(ws.FrameworkErrorGenerator)
@@ -94,7 +172,16 @@ func buildAndRegisterWsCommon(lm *logging.ComponentLoggerManager, ca *config.Con
 
 	pb.FrameworkErrors = feg
 
-	return newWsCommon(pb, feg, scd)
+	wc := newWsCommon(pb, feg, scd)
+
+	fu := new(form.FormUnmarshaller)
+	ca.Populate("FormUnmarshaller", fu)
+	fu.ParamBinder = pb
+	cn.WrapAndAddProto(wsFormUnmarshallerName, fu)
+
+	wc.FormUnmarshaller = fu
+
+	return wc
 
 }
 
@@ -113,13 +200,75 @@ type wsCommon struct {
 	ParamBinder      *ws.ParamBinder
 	FrameworkErrors  *ws.FrameworkErrorGenerator
 	StatusDeterminer *ws.GraniticHttpStatusCodeDeterminer
+	FormUnmarshaller *form.FormUnmarshaller
+}
+
+// Records the components created by a web service facility to read and write a particular format (JSON, XML etc).
+type wsFormat struct {
+	// The media types (e.g. application/json) that the format is registered against when negotiating content.
+	mediaTypes []string
+
+	unmarshaller   ws.WsUnmarshaller
+	responseWriter ws.WsResponseWriter
+
+	// The component name of the response writer.
+	responseName string
+
+	// Whether the facility's BindForms setting is true.
+	bindForms bool
 }
 
-func buildRegisterWsDecorator(cc *ioc.ComponentContainer, rw ws.WsResponseWriter, um ws.WsUnmarshaller, wc *wsCommon, lm *logging.ComponentLoggerManager) {
+func buildRegisterWsDecorator(cc *ioc.ComponentContainer, ca *config.ConfigAccessor, f *wsFormat, wc *wsCommon, lm *logging.ComponentLoggerManager) {
+
+	if d := existingDecorator(cc); d != nil {
+		d.addFormat(cc, ca, f)
+		return
+	}
 
 	decoratorLogger := lm.CreateLogger(wsHandlerDecoratorName)
-	decorator := wsHandlerDecorator{decoratorLogger, rw, um, wc.ParamBinder, wc.FrameworkErrors}
+	decorator := wsHandlerDecorator{decoratorLogger, f.responseWriter, f.unmarshaller, wc.ParamBinder, wc.FrameworkErrors, wc, []*wsFormat{f}}
 	cc.WrapAndAddProto(wsHandlerDecoratorName, &decorator)
+
+	if f.bindForms {
+		decorator.bindForms(cc)
+	}
+}
+
+// Reads the list of media types a web service facility's format should be registered against when negotiating content.
+func configuredMediaTypes(ca *config.ConfigAccessor, path string) ([]string, error) {
+
+	a, err := ca.Array(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	types := make([]string, 0, len(a))
+
+	for _, v := range a {
+
+		if mt, found := v.(string); found && mt != "" {
+			types = append(types, mt)
+		} else {
+			return nil, errors.New(fmt.Sprintf("%s must only contain media types (found %v)", path, v))
+		}
+	}
+
+	if len(types) == 0 {
+		return nil, errors.New(fmt.Sprintf("%s must contain at least one media type", path))
+	}
+
+	return types, nil
+}
+
+// Returns the decorator registered by a web service facility that has already been built (or nil).
+func existingDecorator(cc *ioc.ComponentContainer) *wsHandlerDecorator {
+
+	if p := cc.ProtoComponents()[wsHandlerDecoratorName]; p != nil {
+		return p.Component.Instance.(*wsHandlerDecorator)
+	}
+
+	return nil
 }
 
 type wsHandlerDecorator struct {
@@ -128,6 +277,97 @@ type wsHandlerDecorator struct {
 	Unmarshaller    ws.WsUnmarshaller
 	QueryBinder     *ws.ParamBinder
 	FrameworkErrors *ws.FrameworkErrorGenerator
+	common          *wsCommon
+	formats         []*wsFormat
+}
+
+// Called when more than one web service facility is enabled. Handlers are given a negotiating unmarshaller and
+// response writer that choose between the formats of the enabled facilities and request bodies in formats other than
+// those of the enabled facilities (or forms, if either facility binds forms) are rejected. The format of the first
+// facility built is the default.
+func (jwhd *wsHandlerDecorator) addFormat(cc *ioc.ComponentContainer, ca *config.ConfigAccessor, f *wsFormat) {
+
+	nu := jwhd.negotiatingUnmarshaller(cc)
+	nu.Fallback = nil
+
+	jwhd.formats = append(jwhd.formats, f)
+
+	if f.bindForms {
+		jwhd.bindForms(cc)
+	}
+
+	nrw, isNegotiating := jwhd.ResponseWriter.(*ws.NegotiatingResponseWriter)
+
+	if !isNegotiating {
+		first := jwhd.formats[0]
+
+		nrw = new(ws.NegotiatingResponseWriter)
+		nrw.DefaultContentType = first.mediaTypes[0]
+		cc.WrapAndAddProto(wsNegotiatingResponseWriterName, nrw)
+
+		jwhd.registerFormat(nu, nrw, first)
+
+		jwhd.ResponseWriter = nrw
+
+		replaceAbnormalStatusWriter(ca, cc, first.responseName, wsNegotiatingResponseWriterName)
+	}
+
+	jwhd.registerFormat(nu, nrw, f)
+}
+
+// Makes handlers accept form bodies alongside the formats of the enabled facilities.
+func (jwhd *wsHandlerDecorator) bindForms(cc *ioc.ComponentContainer) {
+
+	nu := jwhd.negotiatingUnmarshaller(cc)
+
+	nu.Register(form.URLEncodedMediaType, jwhd.common.FormUnmarshaller)
+	nu.Register(form.MultipartMediaType, jwhd.common.FormUnmarshaller)
+}
+
+// Returns the negotiating unmarshaller given to handlers, first creating it from the formats added so far if handlers
+// are using the unmarshaller of a single format. Bodies that don't match a registered type are passed to that format's
+// unmarshaller until another format is added.
+func (jwhd *wsHandlerDecorator) negotiatingUnmarshaller(cc *ioc.ComponentContainer) *ws.NegotiatingUnmarshaller {
+
+	if nu, found := jwhd.Unmarshaller.(*ws.NegotiatingUnmarshaller); found {
+		return nu
+	}
+
+	first := jwhd.formats[0]
+
+	nu := new(ws.NegotiatingUnmarshaller)
+	nu.DefaultContentType = first.mediaTypes[0]
+	nu.Fallback = first.unmarshaller
+	cc.WrapAndAddProto(wsNegotiatingUnmarshallerName, nu)
+
+	for _, mt := range first.mediaTypes {
+		nu.Register(mt, first.unmarshaller)
+	}
+
+	jwhd.Unmarshaller = nu
+
+	return nu
+}
+
+func (jwhd *wsHandlerDecorator) registerFormat(nu *ws.NegotiatingUnmarshaller, nrw *ws.NegotiatingResponseWriter, f *wsFormat) {
+
+	for _, mt := range f.mediaTypes {
+		nu.Register(mt, f.unmarshaller)
+		nrw.Register(mt, f.responseWriter)
+	}
+}
+
+// Makes servers that were offered the AbnormalStatusWriter with the name 'previous' use the writer called 'name' instead.
+func replaceAbnormalStatusWriter(ca *config.ConfigAccessor, cc *ioc.ComponentContainer, previous, name string) {
+
+	for _, server := range httpserver.ServerNames(ca) {
+
+		sc := httpserver.ServerComponentName(server)
+
+		if cc.Modifiers(sc)[httpserver.HttpServerAbnormalStatusFieldName] == previous {
+			cc.AddModifier(sc, httpserver.HttpServerAbnormalStatusFieldName, name)
+		}
+	}
 }
 
 func (jwhd *wsHandlerDecorator) OfInterest(component *ioc.Component) bool {
@@ -137,10 +377,18 @@ func (jwhd *wsHandlerDecorator) OfInterest(component *ioc.Component) bool {
 		return false
 	case *handler.WsHandler:
 		return h.AutoWireable()
+	case *form.FormUnmarshaller:
+		return h.ParamBinder == nil
 	}
 }
 
 func (jwhd *wsHandlerDecorator) DecorateComponent(component *ioc.Component, container *ioc.ComponentContainer) {
+
+	if fu, found := component.Instance.(*form.FormUnmarshaller); found {
+		fu.ParamBinder = jwhd.QueryBinder
+		return
+	}
+
 	h := component.Instance.(*handler.WsHandler)
 	l := jwhd.FrameworkLogger
 	l.LogTracef("Decorating component %s", component.Name)
@@ -0,0 +1,53 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"encoding/json"
+	"github.com/graniticio/granitic/config"
+	"github.com/graniticio/granitic/instance"
+	"github.com/graniticio/granitic/ioc"
+	"github.com/graniticio/granitic/logging"
+	"github.com/graniticio/granitic/test"
+	"github.com/graniticio/granitic/ws"
+	"github.com/graniticio/granitic/ws/form"
+	wsjson "github.com/graniticio/granitic/ws/json"
+	"testing"
+)
+
+func buildJsonWs(t *testing.T, conf string) *wsHandlerDecorator {
+
+	var data map[string]interface{}
+	test.ExpectNil(t, json.Unmarshal([]byte(conf), &data))
+
+	ca := new(config.ConfigAccessor)
+	ca.JsonData = data
+	ca.FrameworkLogger = new(logging.ConsoleErrorLogger)
+
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, map[string]interface{}{}, []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter())
+	cc := ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+	test.ExpectNil(t, new(JsonWsFacilityBuilder).BuildAndRegister(lm, ca, cc))
+
+	return existingDecorator(cc)
+}
+
+func TestFormsNotBoundByDefault(t *testing.T) {
+
+	d := buildJsonWs(t, `{"JsonWs": {"MediaTypes": ["application/json"], "WrapMode": "BODY"}}`)
+
+	_, isJSON := d.Unmarshaller.(*wsjson.StandardJSONUnmarshaller)
+	test.ExpectBool(t, isJSON, true)
+
+	d = buildJsonWs(t, `{"JsonWs": {"MediaTypes": ["application/json"], "WrapMode": "BODY", "BindForms": true}}`)
+
+	nu, isNegotiating := d.Unmarshaller.(*ws.NegotiatingUnmarshaller)
+	test.ExpectBool(t, isNegotiating, true)
+
+	_, isForm := nu.Unmarshallers[form.URLEncodedMediaType].(*form.FormUnmarshaller)
+	test.ExpectBool(t, isForm, true)
+
+	_, isJSON = nu.Fallback.(*wsjson.StandardJSONUnmarshaller)
+	test.ExpectBool(t, isJSON, true)
+}
@@ -27,6 +27,12 @@ type XmlWsFacilityBuilder struct {
 // See FacilityBuilder.BuildAndRegister
 func (fb *XmlWsFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cc *ioc.ComponentContainer) error {
 
+	mediaTypes, err := configuredMediaTypes(ca, "XmlWs.MediaTypes")
+
+	if err != nil {
+		return err
+	}
+
 	wc := buildAndRegisterWsCommon(lm, ca, cc)
 
 	um := new(xml.StandardXmlUnmarshaller)
@@ -45,8 +51,11 @@ func (fb *XmlWsFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerMana
 		return errors.New("XmlWs.ResponseMode must be set to either TEMPLATE or MARSHAL")
 	}
 
-	buildRegisterWsDecorator(cc, rw, um, wc, lm)
-	offerAbnormalStatusWriter(rw.(ws.AbnormalStatusWriter), cc, xmlResponseWriterName)
+	bindForms, _ := ca.BoolVal("XmlWs.BindForms")
+
+	f := &wsFormat{mediaTypes, um, rw, xmlResponseWriterName, bindForms}
+	buildRegisterWsDecorator(cc, ca, f, wc, lm)
+	offerAbnormalStatusWriter(rw.(ws.AbnormalStatusWriter), ca, cc, xmlResponseWriterName)
 
 	return nil
 }
@@ -44,6 +44,10 @@ This folder can contain any number of files or sub-directories. This location ca
 when starting your application from the command line. This argument is expected to be a comma separated list of file paths,
 directories or HTTP URLs to JSON files or any mixture of the above.
 
+Authentication, timeouts, retries and local caching of configuration loaded from URLs are controlled with GRANITIC_CONFIG_*
+environment variables (see config.URLLoader). If a cache directory is set and the server cannot be reached at startup,
+the most recently cached copy of each file is used.
+
 Command line arguments
 
 When starting your application from the command, Granitic takes control of processing command line arguments. By
@@ -52,6 +56,9 @@ default your application will support the following arguments.
 	-c A comma separated list of files, directories or HTTP URIs in any combination (default resource/config)
 	-l The level of messages that will be logged by the framework while bootstrapping (before logging configuration is loaded; default INFO)
 	-i An optional string that can be used to uniquely identify this instance of your application
+	-p A comma separated list of profiles to activate (used to decide which conditional components are created)
+	-s A comma separated list of files, directories or HTTP URIs containing schemas for your application's configuration (used if System.ValidateConfig is true, see the config package)
+	-e Print the merged configuration, showing which file or URL supplied each value (with secrets masked), then exit
 
 If your application needs to perform command line processing and you want to prevent Granitic from attempting to parse command line arguments,
 you should start Granitic using the alternative:
@@ -67,6 +74,8 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/gob"
+	"encoding/json"
+	"fmt"
 	"github.com/graniticio/granitic/config"
 	"github.com/graniticio/granitic/facility"
 	"github.com/graniticio/granitic/instance"
@@ -75,7 +84,9 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 )
 
@@ -94,6 +105,7 @@ func StartGranitic(cs *ioc.ProtoComponents) {
 
 	is := config.InitialSettingsFromEnvironment()
 	is.BuiltInConfig = cs.FrameworkConfig
+	is.BuiltInSchema = cs.FrameworkSchema
 
 	StartGraniticWithSettings(cs, is)
 }
@@ -107,7 +119,9 @@ func StartGraniticWithSettings(cs *ioc.ProtoComponents, is *config.InitialSettin
 }
 
 type initiator struct {
-	logger logging.Logger
+	logger   logging.Logger
+	merger   *config.JsonMerger
+	reloader *config.ConfigReloader
 }
 
 func (i *initiator) Start(customComponents *ioc.ProtoComponents, is *config.InitialSettings) {
@@ -125,6 +139,10 @@ func (i *initiator) Start(customComponents *ioc.ProtoComponents, is *config.Init
 		instance.ExitNormal()
 	}()
 
+	if i.reloader != nil {
+		i.reloadOnHangup()
+	}
+
 	for {
 		time.Sleep(10 * time.Second)
 	}
@@ -141,18 +159,41 @@ func (i *initiator) buildContainer(ac *ioc.ProtoComponents, is *config.InitialSe
 	l.LogInfof("Starting components")
 
 	//Merge all configuration files and create a container
-	ca := i.createConfigAccessor(is.BuiltInConfig, is.Configuration, frameworkLoggingManager)
+	ca := i.createConfigAccessor(is, frameworkLoggingManager)
+
+	if is.ExplainConfig {
+		i.explainConfig(ca)
+		instance.ExitNormal()
+	}
 
 	//Load system settings from config
 	ss := i.loadSystemsSettings(ca)
+	ss.Profiles = append(ss.Profiles, is.Profiles...)
+
+	if len(ss.Profiles) > 0 {
+		l.LogInfof("Active profiles: %s", strings.Join(ss.Profiles, ", "))
+	}
+
+	//Check the merged configuration against the built-in and application schemas
+	var schema map[string]interface{}
+
+	if ss.ValidateConfig {
+		schema = i.validateConfig(is, ca, frameworkLoggingManager)
+	}
 
 	//Create the IoC container
 	cc := ioc.NewComponentContainer(frameworkLoggingManager, ca, ss)
 	cc.AddProto(logManageProto)
+	cc.WrapAndAddProto(config.JsonMergerComponentName, i.merger)
 
 	//Assign an identity to this instance of the application
 	i.createInstanceIdentifier(is, cc)
 
+	//Allow configuration to be reloaded while the application is running
+	if ss.AllowConfigReload {
+		i.createConfigReloader(is, ca, schema, cc, frameworkLoggingManager)
+	}
+
 	//Register user components with container
 	cc.AddProtos(ac.Components)
 	cc.AddModifiers(ac.FrameworkDependencies)
@@ -168,7 +209,7 @@ func (i *initiator) buildContainer(ac *ioc.ProtoComponents, is *config.InitialSe
 	i.shutdownIfError(err, cc)
 
 	//Proto components no longer needed
-	if ss.FlushMergedConfig {
+	if ss.FlushMergedConfig && !ss.AllowConfigReload {
 		ca.Flush()
 	}
 
@@ -186,6 +227,26 @@ func (i *initiator) buildContainer(ac *ioc.ProtoComponents, is *config.InitialSe
 	return cc
 }
 
+// Registers a component that can reload configuration on request (see config.ConfigReloader)
+func (i *initiator) createConfigReloader(is *config.InitialSettings, ca *config.ConfigAccessor, schema map[string]interface{}, cc *ioc.ComponentContainer, flm *logging.ComponentLoggerManager) {
+
+	cr := new(config.ConfigReloader)
+	cr.FrameworkLogger = flm.CreateLogger(config.ConfigReloaderComponentName)
+	cr.Accessor = ca
+	cr.Merger = i.merger
+	cr.Locations = is.Configuration
+	cr.Notifier = cc
+	cr.Schema = schema
+
+	cr.Base = func() (map[string]interface{}, error) {
+		return decodeBuiltInConfig(is.BuiltInConfig)
+	}
+
+	cc.WrapAndAddProto(config.ConfigReloaderComponentName, cr)
+
+	i.reloader = cr
+}
+
 func (i *initiator) createInstanceIdentifier(is *config.InitialSettings, cc *ioc.ComponentContainer) {
 	id := is.InstanceId
 
@@ -203,6 +264,23 @@ func (i *initiator) createInstanceIdentifier(is *config.InitialSettings, cc *ioc
 
 }
 
+// Reload configuration whenever the process receives a SIGHUP
+func (i *initiator) reloadOnHangup() {
+
+	h := make(chan os.Signal, 1)
+	signal.Notify(h, syscall.SIGHUP)
+
+	go func() {
+		for range h {
+			i.logger.LogInfof("Reloading configuration (system signal)")
+
+			if _, err := i.reloader.Reload(); err != nil {
+				i.logger.LogErrorf("Problem reloading configuration: %s", err.Error())
+			}
+		}
+	}()
+}
+
 // Cleanly stop the container and any running components in the event of an error
 // during startup.
 func (i *initiator) shutdownIfError(err error, cc *ioc.ComponentContainer) {
@@ -225,46 +303,117 @@ func (i *initiator) shutdown(cc *ioc.ComponentContainer) {
 
 // Merge together all of the local and remote JSON configuration files and wrap them in a *config.ConfigAccessor
 // which allows programmatic access to the merged config.
-func (i *initiator) createConfigAccessor(builtIn64 *string, configPaths []string, flm *logging.ComponentLoggerManager) *config.ConfigAccessor {
+func (i *initiator) createConfigAccessor(is *config.InitialSettings, flm *logging.ComponentLoggerManager) *config.ConfigAccessor {
 
-	builtIn := map[string]interface{}{}
+	configPaths := is.Configuration
 
-	bz, err := base64.StdEncoding.DecodeString(*builtIn64)
+	builtIn, err := decodeBuiltInConfig(is.BuiltInConfig)
 
 	if err != nil {
 		i.logger.LogFatalf("Unable to deserialize the copy of Grantic's configuration created by grnc-bind. Re-run grnc-bind and re-build: %s", err.Error())
 		instance.ExitError()
 	}
 
-	b := bytes.Buffer{}
-	b.Write(bz)
+	i.logConfigLocations(configPaths)
 
-	gob.Register(map[string]interface{}{})
-	gob.Register([]interface{}{})
+	fl := flm.CreateLogger(configAccessorComponentName)
 
-	dc := gob.NewDecoder(&b)
+	jm := config.NewJsonMerger(flm)
+	jm.URLLoader = is.URLLoader
+	i.merger = jm
 
-	err = dc.Decode(&builtIn)
+	mergedJson, err := jm.LoadAndMergeConfigWithBase(builtIn, configPaths)
 
 	if err != nil {
-		i.logger.LogFatalf("Unable to deserialize the copy of Grantic's configuration created by grnc-bind. Re-run grnc-bind and re-build: %s", err.Error())
+		i.logger.LogFatalf(err.Error())
 		instance.ExitError()
 	}
 
-	i.logConfigLocations(configPaths)
+	return &config.ConfigAccessor{mergedJson, fl}
+}
 
-	fl := flm.CreateLogger(configAccessorComponentName)
+// Prints every value in the merged configuration with the file or URL it was loaded from (values from placeholders are masked)
+func (i *initiator) explainConfig(ca *config.ConfigAccessor) {
 
-	jm := config.NewJsonMerger(flm)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
 
-	mergedJson, err := jm.LoadAndMergeConfigWithBase(builtIn, configPaths)
+	fmt.Fprintln(tw, "PATH\tSOURCE\tVALUE")
 
-	if err != nil {
-		i.logger.LogFatalf(err.Error())
+	for _, sv := range config.EffectiveConfig(ca.JsonData, i.merger, "") {
+		v, _ := json.Marshal(sv.Value)
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", sv.Path, sv.Source, v)
+	}
+
+	tw.Flush()
+}
+
+// Merges Granitic's built-in configuration schemas with any supplied by the application and exits if the merged configuration
+// does not match the resulting schema. Returns the merged schema so that reloaded configuration can also be checked.
+func (i *initiator) validateConfig(is *config.InitialSettings, ca *config.ConfigAccessor, flm *logging.ComponentLoggerManager) map[string]interface{} {
+
+	schema := map[string]interface{}{}
+
+	if is.BuiltInSchema != nil {
+
+		builtIn, err := decodeBuiltInConfig(is.BuiltInSchema)
+
+		if err != nil {
+			i.logger.LogFatalf("Unable to deserialize the copy of Grantic's configuration schemas created by grnc-bind. Re-run grnc-bind and re-build: %s", err.Error())
+			instance.ExitError()
+		}
+
+		schema = builtIn
+	}
+
+	if len(is.ConfigSchema) > 0 {
+
+		jm := config.NewJsonMerger(flm)
+		jm.MergeArrays = true
+		jm.SkipPlaceholders = true
+
+		merged, err := jm.LoadAndMergeConfigWithBase(schema, is.ConfigSchema)
+
+		if err != nil {
+			i.logger.LogFatalf("Problem loading configuration schemas: %s", err.Error())
+			instance.ExitError()
+		}
+
+		schema = merged
+	}
+
+	if err := config.ValidateAgainstSchema(ca.JsonData, schema); err != nil {
+		i.logger.LogFatalf("%s", err.Error())
 		instance.ExitError()
 	}
 
-	return &config.ConfigAccessor{mergedJson, fl}
+	return schema
+}
+
+// Decodes the copy of Granitic's built-in configuration serialised by grnc-bind. A new copy is created on each call as
+// merging modifies the base configuration.
+func decodeBuiltInConfig(builtIn64 *string) (map[string]interface{}, error) {
+
+	builtIn := map[string]interface{}{}
+
+	bz, err := base64.StdEncoding.DecodeString(*builtIn64)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b := bytes.Buffer{}
+	b.Write(bz)
+
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+
+	dc := gob.NewDecoder(&b)
+
+	if err = dc.Decode(&builtIn); err != nil {
+		return nil, err
+	}
+
+	return builtIn, nil
 }
 
 // Record the files and URLs used to create a merged configuration (in the order in which they will be merged)
@@ -0,0 +1,227 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpendpoint
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Headers used for cross-origin resource sharing (CORS).
+const (
+	OriginHeader                        = "Origin"
+	AccessControlRequestMethodHeader    = "Access-Control-Request-Method"
+	AccessControlRequestHeadersHeader   = "Access-Control-Request-Headers"
+	AccessControlAllowOriginHeader      = "Access-Control-Allow-Origin"
+	AccessControlAllowMethodsHeader     = "Access-Control-Allow-Methods"
+	AccessControlAllowHeadersHeader     = "Access-Control-Allow-Headers"
+	AccessControlAllowCredentialsHeader = "Access-Control-Allow-Credentials"
+	AccessControlExposeHeadersHeader    = "Access-Control-Expose-Headers"
+	AccessControlMaxAgeHeader           = "Access-Control-Max-Age"
+)
+
+/*
+CORSPolicy describes which cross-origin requests browsers should allow. A policy can be set for a whole server
+(under HttpServer.CORS in configuration) and overridden for individual endpoints (see handler.WsHandler.CORS).
+
+Origins are matched exactly (ignoring case) unless they contain a *, which matches any sequence of letters, numbers, dots
+and hyphens (e.g. https://*.example.com or http://localhost:*). An origin of * on its own allows any origin.
+*/
+type CORSPolicy struct {
+	// Whether or not CORS headers should be sent.
+	Enabled bool
+
+	// The origins from which cross-origin requests are allowed.
+	AllowedOrigins []string
+
+	// The methods that may be used in cross-origin requests. If empty, any method supported by the requested path is allowed.
+	AllowedMethods []string
+
+	// The request headers that may be sent in cross-origin requests (in addition to those browsers always allow). A single
+	// entry of * allows any header.
+	AllowedHeaders []string
+
+	// The response headers that browsers should make available to scripts.
+	ExposedHeaders []string
+
+	// Whether or not requests may include credentials (cookies, HTTP authentication or client certificates).
+	AllowCredentials bool
+
+	// How long (in seconds) browsers may cache the result of a preflight request. Zero means the header is not sent.
+	MaxAgeSeconds int
+
+	anyOrigin bool
+	origins   []*regexp.Regexp
+}
+
+// CORSPolicyProvider is implemented by an HttpEndpointProvider that has its own CORS policy. A nil policy means the
+// server's policy is used.
+type CORSPolicyProvider interface {
+	CORSPolicy() *CORSPolicy
+}
+
+// Compile checks the policy's allowed origins and prepares them for matching. Must be called before the policy is used.
+func (cp *CORSPolicy) Compile() error {
+
+	cp.origins = make([]*regexp.Regexp, 0, len(cp.AllowedOrigins))
+	cp.anyOrigin = false
+
+	for _, o := range cp.AllowedOrigins {
+
+		if o == "*" {
+			cp.anyOrigin = true
+			continue
+		}
+
+		parts := strings.Split(regexp.QuoteMeta(o), `\*`)
+
+		re, err := regexp.Compile("(?i)^" + strings.Join(parts, "[a-zA-Z0-9.-]*") + "$")
+
+		if err != nil {
+			return errors.New(fmt.Sprintf("Invalid CORS origin %s: %s", o, err.Error()))
+		}
+
+		cp.origins = append(cp.origins, re)
+	}
+
+	if cp.anyOrigin && cp.AllowCredentials {
+		return errors.New("A CORS policy that allows any origin (*) cannot also allow credentials")
+	}
+
+	return nil
+}
+
+// AllowsOrigin returns true if cross-origin requests from the supplied origin are allowed.
+func (cp *CORSPolicy) AllowsOrigin(origin string) bool {
+
+	if !cp.Enabled || origin == "" {
+		return false
+	}
+
+	if cp.anyOrigin {
+		return true
+	}
+
+	for _, re := range cp.origins {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResponseHeaders returns the headers that should be added to the response to a (non-preflight) request from the
+// supplied origin, or nil if the origin is not allowed.
+func (cp *CORSPolicy) ResponseHeaders(origin string) map[string]string {
+
+	if !cp.AllowsOrigin(origin) {
+		return nil
+	}
+
+	h := cp.originHeaders(origin)
+
+	if len(cp.ExposedHeaders) > 0 {
+		h[AccessControlExposeHeadersHeader] = strings.Join(cp.ExposedHeaders, ", ")
+	}
+
+	return h
+}
+
+// PreflightHeaders returns the headers that should be sent in response to a preflight (OPTIONS) request, or nil if the
+// request should not be allowed. supported is the list of methods the requested path supports.
+func (cp *CORSPolicy) PreflightHeaders(req *http.Request, supported []string) map[string]string {
+
+	origin := req.Header.Get(OriginHeader)
+
+	if !cp.AllowsOrigin(origin) {
+		return nil
+	}
+
+	methods := cp.AllowedMethods
+
+	if len(methods) == 0 {
+		methods = supported
+	}
+
+	if !containsFold(methods, req.Header.Get(AccessControlRequestMethodHeader)) {
+		return nil
+	}
+
+	h := cp.originHeaders(origin)
+	h[AccessControlAllowMethodsHeader] = strings.Join(methods, ", ")
+
+	if requested := splitHeaderList(req.Header.Get(AccessControlRequestHeadersHeader)); len(requested) > 0 {
+
+		if len(cp.AllowedHeaders) == 1 && cp.AllowedHeaders[0] == "*" {
+			h[AccessControlAllowHeadersHeader] = strings.Join(requested, ", ")
+		} else {
+
+			for _, r := range requested {
+				if !containsFold(cp.AllowedHeaders, r) {
+					return nil
+				}
+			}
+
+			h[AccessControlAllowHeadersHeader] = strings.Join(cp.AllowedHeaders, ", ")
+		}
+	}
+
+	if cp.MaxAgeSeconds > 0 {
+		h[AccessControlMaxAgeHeader] = strconv.Itoa(cp.MaxAgeSeconds)
+	}
+
+	return h
+}
+
+func (cp *CORSPolicy) originHeaders(origin string) map[string]string {
+
+	h := make(map[string]string)
+
+	if cp.anyOrigin {
+		h[AccessControlAllowOriginHeader] = "*"
+	} else {
+		h[AccessControlAllowOriginHeader] = origin
+		h["Vary"] = OriginHeader
+	}
+
+	if cp.AllowCredentials {
+		h[AccessControlAllowCredentialsHeader] = "true"
+	}
+
+	return h
+}
+
+// IsPreflight returns true if the supplied request is a CORS preflight request.
+func IsPreflight(req *http.Request) bool {
+	return req.Method == http.MethodOptions && req.Header.Get(OriginHeader) != "" && req.Header.Get(AccessControlRequestMethodHeader) != ""
+}
+
+func splitHeaderList(v string) []string {
+
+	l := make([]string, 0)
+
+	for _, h := range strings.Split(v, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			l = append(l, h)
+		}
+	}
+
+	return l
+}
+
+func containsFold(l []string, s string) bool {
+
+	for _, e := range l {
+		if strings.EqualFold(e, s) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpendpoint
+
+import (
+	"github.com/graniticio/granitic/test"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSOrigins(t *testing.T) {
+
+	cp := &CORSPolicy{Enabled: true, AllowedOrigins: []string{"https://*.example.com", "http://localhost:*", "https://other.org"}}
+	test.ExpectNil(t, cp.Compile())
+
+	test.ExpectBool(t, cp.AllowsOrigin("https://api.example.com"), true)
+	test.ExpectBool(t, cp.AllowsOrigin("https://a.b.example.com"), true)
+	test.ExpectBool(t, cp.AllowsOrigin("http://localhost:3000"), true)
+	test.ExpectBool(t, cp.AllowsOrigin("https://OTHER.org"), true)
+
+	test.ExpectBool(t, cp.AllowsOrigin("https://example.com.evil.org"), false)
+	test.ExpectBool(t, cp.AllowsOrigin("http://api.example.com"), false)
+	test.ExpectBool(t, cp.AllowsOrigin("https://evil.org/.example.com"), false)
+	test.ExpectBool(t, cp.AllowsOrigin(""), false)
+
+	h := cp.ResponseHeaders("https://api.example.com")
+	test.ExpectString(t, h[AccessControlAllowOriginHeader], "https://api.example.com")
+	test.ExpectString(t, h["Vary"], OriginHeader)
+
+	test.ExpectBool(t, cp.ResponseHeaders("https://evil.org") == nil, true)
+
+	cp.Enabled = false
+	test.ExpectBool(t, cp.AllowsOrigin("https://other.org"), false)
+
+	any := &CORSPolicy{Enabled: true, AllowedOrigins: []string{"*"}}
+	test.ExpectNil(t, any.Compile())
+	test.ExpectString(t, any.ResponseHeaders("https://x.org")[AccessControlAllowOriginHeader], "*")
+
+	any.AllowCredentials = true
+	test.ExpectNotNil(t, any.Compile())
+}
+
+func TestCORSPreflight(t *testing.T) {
+
+	cp := &CORSPolicy{Enabled: true, AllowedOrigins: []string{"https://app.example.com"}, AllowedHeaders: []string{"Content-Type"},
+		AllowCredentials: true, MaxAgeSeconds: 600}
+	test.ExpectNil(t, cp.Compile())
+
+	req := httptest.NewRequest("OPTIONS", "/artist/1", nil)
+	req.Header.Set(OriginHeader, "https://app.example.com")
+	req.Header.Set(AccessControlRequestMethodHeader, "PUT")
+	req.Header.Set(AccessControlRequestHeadersHeader, "content-type")
+
+	test.ExpectBool(t, IsPreflight(req), true)
+
+	h := cp.PreflightHeaders(req, []string{"GET", "PUT"})
+	test.ExpectNotNil(t, h)
+	test.ExpectString(t, h[AccessControlAllowMethodsHeader], "GET, PUT")
+	test.ExpectString(t, h[AccessControlAllowHeadersHeader], "Content-Type")
+	test.ExpectString(t, h[AccessControlAllowCredentialsHeader], "true")
+	test.ExpectString(t, h[AccessControlMaxAgeHeader], "600")
+
+	test.ExpectBool(t, cp.PreflightHeaders(req, []string{"GET"}) == nil, true)
+
+	req.Header.Set(AccessControlRequestHeadersHeader, "X-Custom")
+	test.ExpectBool(t, cp.PreflightHeaders(req, []string{"PUT"}) == nil, true)
+
+	cp.AllowedHeaders = []string{"*"}
+	test.ExpectString(t, cp.PreflightHeaders(req, []string{"PUT"})[AccessControlAllowHeadersHeader], "X-Custom")
+
+	cp.AllowedMethods = []string{"GET"}
+	test.ExpectBool(t, cp.PreflightHeaders(req, []string{"PUT"}) == nil, true)
+}
@@ -11,6 +11,9 @@ In most cases, user applications will not need to interact with the types in thi
 components of type handler.WsHandler (which already implements the key HttpEndpointProvider interface below) and the
 framework will automatically register them with the HttpServer facility.
 
+Applications that need to examine or modify every request (or every request to a set of paths) can define components
+implementing HttpFilter, which are also found and applied automatically by the HttpServer facility.
+
 */
 package httpendpoint
 
@@ -52,6 +55,22 @@ type HttpEndpointProvider interface {
 	AutoWireable() bool
 }
 
+// ServerNamesProvider is implemented by an HttpEndpointProvider that should only be automatically registered with
+// some of the HTTP servers in an application (for example an internal admin server). Providers that do not implement this
+// interface (or return an empty slice) are only registered with the default server.
+type ServerNamesProvider interface {
+	// ServerNames returns the names of the HTTP servers this endpoint should be registered with.
+	ServerNames() []string
+}
+
+// BodyLimitProvider is implemented by an HttpEndpointProvider that needs a different limit on the size of request
+// bodies than the server's limit (see httpserver.HttpServer.MaxRequestBodyBytes).
+type BodyLimitProvider interface {
+	// BodyLimit returns the largest request body (in bytes) the endpoint accepts. Zero means the server's limit is
+	// used and a negative value means there is no limit.
+	BodyLimit() int
+}
+
 // A semi-structured type to allow applications flexibility in defining what a 'version' is.
 type RequiredVersion map[string]interface{}
 
@@ -0,0 +1,50 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpendpoint
+
+import (
+	"context"
+	"net/http"
+)
+
+// FilterChain passes a request on to the next HttpFilter in a chain or, at the end of the chain, to the server's
+// routing of the request to an HttpEndpointProvider. Returns a context that may have been modified.
+type FilterChain func(ctx context.Context, w *HttpResponseWriter, req *http.Request) context.Context
+
+/*
+HttpFilter is implemented by components that perform cross-cutting work (CORS, request IDs, compression,
+authentication etc) on every request to an HTTP server, or every request whose path matches a pattern.
+
+Components implementing this interface are found automatically by HTTP servers (see httpserver.HttpServer.AutoFindFilters).
+A filter can:
+
+	Do work before the request is handled, then call next
+	Do work after the request has been handled (after next returns)
+	Short-circuit the request by writing a response and not calling next
+	Wrap the response (see HttpResponseWriter.Wrap) or pass a modified request or context to next
+
+Filters are applied in the order described by OrderedFilter. A filter that implements FilterPatternProvider is only
+applied to requests whose path matches its pattern and a filter that implements ServerNamesProvider is only applied by
+the named servers.
+*/
+type HttpFilter interface {
+	// Filter is called for each request the filter applies to. Implementations should normally call next and return the
+	// context it returns.
+	Filter(ctx context.Context, w *HttpResponseWriter, req *http.Request, next FilterChain) context.Context
+}
+
+// OrderedFilter is implemented by an HttpFilter that needs to run before or after other filters. Filters with a lower
+// order are applied first (and so see the response last). Filters that do not implement this interface have an order of
+// zero and filters with the same order are applied in order of their component names.
+type OrderedFilter interface {
+	// FilterOrder returns the position of this filter relative to other filters.
+	FilterOrder() int
+}
+
+// FilterPatternProvider is implemented by an HttpFilter that should only be applied to some requests.
+type FilterPatternProvider interface {
+	// FilterPattern returns an un-compiled regular expression that is matched against the path of each request. An empty
+	// string means the filter applies to all requests.
+	FilterPattern() string
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpendpoint
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Values for RateLimitPolicy.Key
+const (
+	// Requests are counted per client IP address.
+	IPRateLimitKey = "ip"
+
+	// Requests are counted per authenticated user (see iam.ClientIdentity.LoggableUserId). Requests from unauthenticated
+	// callers are counted by IP address.
+	UserRateLimitKey = "user"
+
+	// Requests are counted per value of the header named in RateLimitPolicy.KeyHeader (e.g. an API key). Requests
+	// without the header are counted by IP address.
+	HeaderRateLimitKey = "header"
+)
+
+/*
+RateLimitPolicy describes how many requests each client may make. Requests are limited with a token bucket: each client
+may make up to Burst requests in quick succession, after which requests are allowed at RequestsPerSecond.
+
+A policy can be set for a whole server (under HttpServer.RateLimit in configuration) and overridden for individual
+endpoints (see handler.WsHandler.RateLimit). An endpoint's policy that is not enabled means the endpoint is not limited.
+*/
+type RateLimitPolicy struct {
+	// Whether or not requests should be limited.
+	Enabled bool
+
+	// The rate at which a client's allowance of requests is replenished.
+	RequestsPerSecond float64
+
+	// The maximum number of requests a client can make without waiting.
+	Burst int
+
+	// How clients are identified (ip, user or header).
+	Key string
+
+	// The name of the header used to identify clients if Key is header.
+	KeyHeader string
+}
+
+// RateLimitPolicyProvider is implemented by an HttpEndpointProvider that has its own rate limit policy. A nil policy means
+// the server's policy is used.
+type RateLimitPolicyProvider interface {
+	RateLimitPolicy() *RateLimitPolicy
+}
+
+// Validate returns an error if the policy is enabled but incomplete or inconsistent.
+func (rp *RateLimitPolicy) Validate() error {
+
+	if !rp.Enabled {
+		return nil
+	}
+
+	if rp.RequestsPerSecond <= 0 || rp.Burst < 1 {
+		return errors.New("A rate limit policy must have a RequestsPerSecond greater than zero and a Burst of at least one")
+	}
+
+	switch rp.Key {
+	case IPRateLimitKey, UserRateLimitKey:
+	case HeaderRateLimitKey:
+		if rp.KeyHeader == "" {
+			return errors.New(fmt.Sprintf("A rate limit policy with a Key of %s must set KeyHeader", HeaderRateLimitKey))
+		}
+	default:
+		return errors.New(fmt.Sprintf("A rate limit policy's Key must be %s, %s or %s (was %s)", IPRateLimitKey, UserRateLimitKey, HeaderRateLimitKey, rp.Key))
+	}
+
+	return nil
+}
@@ -16,6 +16,10 @@ type HttpResponseWriter struct {
 
 	// How many bytes have been sent to the response so far (excluding headers).
 	BytesServed int
+
+	// How many bytes have been written to the client so far (excluding headers). Differs from BytesServed if the
+	// response is being transformed by a wrapper (see Wrap), for example when the response is compressed.
+	BytesSent int
 }
 
 // Header calls through to http.ResponseWriter.Header()
@@ -45,10 +49,32 @@ func (w *HttpResponseWriter) WriteHeader(i int) {
 	w.DataSent = true
 }
 
+// Wrap replaces the http.ResponseWriter that this HttpResponseWriter writes to with the result of passing the current
+// http.ResponseWriter to the supplied function. Allows an HttpFilter to transform a response (e.g. compressing it) while
+// the status and bytes served are still tracked (BytesServed counts bytes before they are passed to the wrapper and
+// BytesSent counts the bytes the wrapper writes to the client).
+func (w *HttpResponseWriter) Wrap(wrapper func(http.ResponseWriter) http.ResponseWriter) {
+	w.rw = wrapper(w.rw)
+}
+
 // Create a new WsHTTPResponseWriter wrapping the supplied http.ResponseWriter
 func NewHttpResponseWriter(rw http.ResponseWriter) *HttpResponseWriter {
 	w := new(HttpResponseWriter)
-	w.rw = rw
+	w.rw = &countingWriter{ResponseWriter: rw, count: &w.BytesSent}
 
 	return w
 }
+
+// Counts the bytes written to the original http.ResponseWriter.
+type countingWriter struct {
+	http.ResponseWriter
+	count *int
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+
+	n, err := cw.ResponseWriter.Write(b)
+	*cw.count += n
+
+	return n, err
+}
@@ -0,0 +1,274 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpendpoint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/*
+Path templates are a simpler and faster alternative to regular expressions for describing the paths an endpoint supports.
+A template is a sequence of /-separated segments, each of which is either literal text or a named parameter in braces:
+
+	/artist/{id:int}/album/{title}
+
+A parameter can be restricted to a type of value by adding a colon and one of the types below after its name. A parameter
+without a type matches any non-empty segment.
+
+	int     An optionally signed whole number (e.g. 12 or -3)
+	float   An optionally signed decimal number (e.g. 1.5)
+	uuid    A UUID (e.g. 3f2504e0-4f89-11d3-9a0c-0305e82c3301)
+	alpha   Letters only
+	alnum   Letters and numbers only
+	string  Any non-empty segment (the default)
+	path    One or more segments (including the / between them) - only allowed as the last segment
+
+When more than one template matches a path, the template with the most specific segment (reading from left to right) is
+chosen: literal text is more specific than int, uuid, float and alpha parameters, which are more specific than alnum,
+which is more specific than string, which is more specific than path. A trailing / in a request path is ignored.
+*/
+const (
+	IntParam    = "int"
+	FloatParam  = "float"
+	UuidParam   = "uuid"
+	AlphaParam  = "alpha"
+	AlnumParam  = "alnum"
+	StringParam = "string"
+	PathParam   = "path"
+)
+
+type paramType struct {
+	// Lower values are more specific. Types with the same rank never match the same segment.
+	rank    int
+	pattern string
+	regex   *regexp.Regexp
+}
+
+var paramTypes = map[string]*paramType{
+	IntParam:    {rank: 1, pattern: `[-+]?\d+`},
+	UuidParam:   {rank: 1, pattern: `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`},
+	FloatParam:  {rank: 2, pattern: `[-+]?(\d+\.\d*|\.\d+)`},
+	AlphaParam:  {rank: 2, pattern: `[a-zA-Z]+`},
+	AlnumParam:  {rank: 3, pattern: `[a-zA-Z0-9]+`},
+	StringParam: {rank: 4, pattern: `[^/]+`},
+	PathParam:   {rank: 5, pattern: `.+`},
+}
+
+func init() {
+	for _, pt := range paramTypes {
+		pt.regex = regexp.MustCompile("^(?:" + pt.pattern + ")$")
+	}
+}
+
+// A TemplateSegment is one /-separated part of a PathTemplate.
+type TemplateSegment struct {
+	// The text the segment must match (empty if the segment is a parameter).
+	Literal string
+
+	// The name of the parameter (empty if the segment is literal text).
+	Param string
+
+	// The type of the parameter (e.g. IntParam).
+	Type string
+}
+
+// IsParam returns true if this segment is a parameter rather than literal text.
+func (ts *TemplateSegment) IsParam() bool {
+	return ts.Param != ""
+}
+
+// Rank returns the specificity of the segment. Segments with lower ranks are preferred when more than one template matches
+// a path. Literal segments have rank 0.
+func (ts *TemplateSegment) Rank() int {
+
+	if !ts.IsParam() {
+		return 0
+	}
+
+	return paramTypes[ts.Type].rank
+}
+
+// Matches returns true if the supplied (non-empty) part of a path matches this segment.
+func (ts *TemplateSegment) Matches(s string) bool {
+
+	if !ts.IsParam() {
+		return s == ts.Literal
+	}
+
+	return paramTypes[ts.Type].regex.MatchString(s)
+}
+
+// Key returns a string that is the same for any two segments that match exactly the same values (e.g. two int parameters
+// with different names).
+func (ts *TemplateSegment) Key() string {
+
+	if !ts.IsParam() {
+		return ts.Literal
+	}
+
+	return "{:" + ts.Type + "}"
+}
+
+// A PathTemplate is a parsed path template (see the constants above).
+type PathTemplate struct {
+	// The template as it was originally written.
+	Template string
+
+	// The segments of the template (empty for the template /).
+	Segments []*TemplateSegment
+}
+
+// ParsePathTemplate parses a path template, returning an error if the template is not valid.
+func ParsePathTemplate(template string) (*PathTemplate, error) {
+
+	if !strings.HasPrefix(template, "/") {
+		return nil, errors.New(fmt.Sprintf("Path template %s must start with /", template))
+	}
+
+	pt := new(PathTemplate)
+	pt.Template = template
+	pt.Segments = make([]*TemplateSegment, 0)
+
+	trimmed := strings.Trim(template, "/")
+
+	if trimmed == "" {
+		return pt, nil
+	}
+
+	names := make(map[string]bool)
+	parts := strings.Split(trimmed, "/")
+
+	for i, part := range parts {
+
+		ts, err := parseTemplateSegment(part)
+
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Invalid path template %s: %s", template, err.Error()))
+		}
+
+		if ts.Type == PathParam && i != len(parts)-1 {
+			return nil, errors.New(fmt.Sprintf("Invalid path template %s: a %s parameter must be the last segment", template, PathParam))
+		}
+
+		if ts.IsParam() {
+			if names[ts.Param] {
+				return nil, errors.New(fmt.Sprintf("Invalid path template %s: parameter %s is used more than once", template, ts.Param))
+			}
+
+			names[ts.Param] = true
+		}
+
+		pt.Segments = append(pt.Segments, ts)
+	}
+
+	return pt, nil
+}
+
+func parseTemplateSegment(part string) (*TemplateSegment, error) {
+
+	if part == "" {
+		return nil, errors.New("empty segment")
+	}
+
+	ts := new(TemplateSegment)
+
+	if !strings.HasPrefix(part, "{") {
+
+		if strings.ContainsAny(part, "{}") {
+			return nil, errors.New("parameters must be a complete segment (" + part + ")")
+		}
+
+		ts.Literal = part
+		return ts, nil
+	}
+
+	if !strings.HasSuffix(part, "}") {
+		return nil, errors.New("parameters must be a complete segment (" + part + ")")
+	}
+
+	nameAndType := strings.SplitN(part[1:len(part)-1], ":", 2)
+
+	ts.Param = nameAndType[0]
+	ts.Type = StringParam
+
+	if len(nameAndType) == 2 {
+		ts.Type = nameAndType[1]
+	}
+
+	if !validParamName.MatchString(ts.Param) {
+		return nil, errors.New("invalid parameter name '" + ts.Param + "' (names must start with a letter and contain only letters, numbers and _)")
+	}
+
+	if paramTypes[ts.Type] == nil {
+		return nil, errors.New(fmt.Sprintf("unknown parameter type %s (supported types are %s, %s, %s, %s, %s, %s and %s)",
+			ts.Type, IntParam, FloatParam, UuidParam, AlphaParam, AlnumParam, StringParam, PathParam))
+	}
+
+	return ts, nil
+}
+
+var validParamName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+// Params returns the names of the template's parameters in the order they appear.
+func (pt *PathTemplate) Params() []string {
+
+	p := make([]string, 0)
+
+	for _, s := range pt.Segments {
+		if s.IsParam() {
+			p = append(p, s.Param)
+		}
+	}
+
+	return p
+}
+
+// RegexPattern returns a regular expression that matches the same paths as the template, with a named group (in the
+// same order as Params) for each parameter.
+func (pt *PathTemplate) RegexPattern() string {
+
+	var b bytes.Buffer
+
+	b.WriteString("^")
+
+	for _, s := range pt.Segments {
+
+		b.WriteString("/")
+
+		if s.IsParam() {
+			b.WriteString(fmt.Sprintf("(?P<%s>%s)", s.Param, paramTypes[s.Type].pattern))
+		} else {
+			b.WriteString(regexp.QuoteMeta(s.Literal))
+		}
+	}
+
+	b.WriteString("/?$")
+
+	return b.String()
+}
+
+// SplitPath converts the path of a request into the segments that will be compared to a template's segments. Leading
+// and trailing / characters are ignored.
+func SplitPath(path string) []string {
+
+	trimmed := strings.Trim(path, "/")
+
+	if trimmed == "" {
+		return []string{}
+	}
+
+	return strings.Split(trimmed, "/")
+}
+
+// RouteTemplateProvider is implemented by an HttpEndpointProvider that describes the paths it supports with a path
+// template rather than (only) a regular expression. If RouteTemplate returns a non-empty string, HTTP servers will use
+// the template instead of RegexPattern to match requests to the endpoint.
+type RouteTemplateProvider interface {
+	// RouteTemplate returns a path template such as /artist/{id:int}
+	RouteTemplate() string
+}
@@ -0,0 +1,109 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpendpoint
+
+import (
+	"github.com/graniticio/granitic/test"
+	"regexp"
+	"testing"
+)
+
+func TestParsePathTemplate(t *testing.T) {
+
+	pt, err := ParsePathTemplate("/artist/{id:int}/album/{title}/")
+	test.ExpectNil(t, err)
+
+	test.ExpectInt(t, len(pt.Segments), 4)
+	test.ExpectString(t, pt.Segments[0].Literal, "artist")
+	test.ExpectString(t, pt.Segments[1].Param, "id")
+	test.ExpectString(t, pt.Segments[1].Type, IntParam)
+	test.ExpectString(t, pt.Segments[3].Type, StringParam)
+
+	p := pt.Params()
+	test.ExpectInt(t, len(p), 2)
+	test.ExpectString(t, p[0], "id")
+	test.ExpectString(t, p[1], "title")
+
+	pt, err = ParsePathTemplate("/")
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(pt.Segments), 0)
+
+	invalid := []string{
+		"artist",
+		"/artist//album",
+		"/artist/{id:number}",
+		"/artist/{1d}",
+		"/artist/id{id}",
+		"/artist/{id}/{id}",
+		"/files/{p:path}/meta",
+	}
+
+	for _, i := range invalid {
+		_, err = ParsePathTemplate(i)
+		test.ExpectNotNil(t, err)
+	}
+}
+
+func TestSegmentMatching(t *testing.T) {
+
+	matches := map[string][]string{
+		IntParam:    {"1", "-20", "+3"},
+		FloatParam:  {"1.5", "-0.25", ".5", "2."},
+		UuidParam:   {"3f2504e0-4f89-11d3-9a0c-0305e82c3301"},
+		AlphaParam:  {"abc", "ABC"},
+		AlnumParam:  {"a1", "1"},
+		StringParam: {"a b", "a-1.x"},
+	}
+
+	misses := map[string][]string{
+		IntParam:   {"1.5", "a", "1a"},
+		FloatParam: {"1", "a.b"},
+		UuidParam:  {"3f2504e0", "3f2504e0-4f89-11d3-9a0c-0305e82c330x"},
+		AlphaParam: {"a1"},
+		AlnumParam: {"a-1"},
+	}
+
+	for pType, values := range matches {
+		s := &TemplateSegment{Param: "p", Type: pType}
+
+		for _, v := range values {
+			test.ExpectBool(t, s.Matches(v), true)
+		}
+	}
+
+	for pType, values := range misses {
+		s := &TemplateSegment{Param: "p", Type: pType}
+
+		for _, v := range values {
+			test.ExpectBool(t, s.Matches(v), false)
+		}
+	}
+
+	a := &TemplateSegment{Param: "a", Type: IntParam}
+	b := &TemplateSegment{Param: "b", Type: IntParam}
+
+	test.ExpectString(t, a.Key(), b.Key())
+	test.ExpectBool(t, a.Rank() < (&TemplateSegment{Param: "c", Type: StringParam}).Rank(), true)
+	test.ExpectInt(t, (&TemplateSegment{Literal: "x"}).Rank(), 0)
+}
+
+func TestTemplateRegexPattern(t *testing.T) {
+
+	pt, err := ParsePathTemplate("/artist/{id:int}/files/{rest:path}")
+	test.ExpectNil(t, err)
+
+	re := regexp.MustCompile(pt.RegexPattern())
+
+	m := re.FindStringSubmatch("/artist/12/files/a/b.txt/")
+	test.ExpectInt(t, len(m), 3)
+	test.ExpectString(t, m[1], "12")
+	test.ExpectString(t, m[2], "a/b.txt/")
+	test.ExpectString(t, re.SubexpNames()[1], "id")
+
+	test.ExpectBool(t, re.MatchString("/artist/x/files/a"), false)
+	test.ExpectBool(t, re.MatchString("/artist/1/files"), false)
+
+	pt, _ = ParsePathTemplate("/a.b")
+	test.ExpectBool(t, regexp.MustCompile(pt.RegexPattern()).MatchString("/aXb"), false)
+}
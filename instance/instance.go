@@ -66,6 +66,17 @@ type System struct {
 
 	//How many times a stoppable component can declare it is not ready to stop before a warning message is logged
 	StopTriesBeforeWarn int
+
+	//If configuration can be reloaded while the application is running (via the reload runtime control command or a SIGHUP
+	//signal). If true, the merged configuration is never flushed.
+	AllowConfigReload bool
+
+	//The names of the profiles that are active for this instance. Profiles are used to decide which conditional components are created.
+	Profiles []string
+
+	//If the merged configuration should be checked against Granitic's built-in configuration schemas and any schemas
+	//supplied by the application when the application starts.
+	ValidateConfig bool
 }
 
 // The name of the component in the IoC container holding an instance Id.
@@ -87,6 +87,23 @@
 	only have those elements defined once. This is especially useful for web service handlers. See
 	http://granitic.io/1.0/ref/components#templates for more details.
 
+	Factories
+
+	Types that cannot be configured by setting exported fields (for example *sql.DB or clients from third-party libraries) can
+	be built by a factory - either a component implementing ioc.Factory or a function matching ioc.FactoryFunc:
+
+		{
+		  "components": {
+		    "inventoryDB": {
+		  	  "factory": "ref:dbFactory",
+		  	  "DataSource": "conf:Inventory.DataSource"
+		    }
+		  }
+		}
+
+	The references, configuration promises and values in the definition of a factory-built component are passed to the factory
+	as inputs rather than being injected into fields. See the GoDoc for ioc.Factory for more details.
+
 	Binding
 
 	Unlike JVM/CLR languages, Go has no runtime 'instance-for-type-name' mechanism for creating instances of a struct. As
@@ -127,6 +144,60 @@
 			Stop        Components implementing ioc.Stoppable are allowed to stop gracefully before the application exits.
 
 
+	Scopes
+
+	By default, each component is a singleton - one instance is shared by every component that depends on it. A component
+	definition can declare a different scope:
+
+		{
+		  "components": {
+		    "unitOfWork": {
+		  	  "type": "inventory.UnitOfWork",
+		  	  "scope": "request",
+		  	  "Client": "ref:inventoryDB"
+		    }
+		  }
+		}
+
+	Prototype scoped components are re-created each time they are injected into another component. Request scoped
+	components are created once per web service request and can only be injected into other request scoped components
+	or obtained via ioc.RequestScopedComponent. See the GoDoc for ioc.SingletonScope for more details.
+
+	Conditional components
+
+	A component definition can include conditions that must be met for the component to be added to the container, and can
+	register the component under a different name. This allows different implementations of the same role to be chosen
+	according to configuration or the profiles that are active (see the -p command line argument in the granitic package):
+
+		{
+		  "components": {
+		    "mailSender": {
+		  	  "type": "mail.SMTPSender"
+		    },
+		    "loggingMailSender": {
+		  	  "type": "mail.LoggingSender",
+		  	  "if": ["profile:dev", "!conf:Mail.ForceSMTP"],
+		  	  "as": "mailSender"
+		    },
+		    "auditor": {
+		  	  "type": "audit.Auditor",
+		  	  "if": "conf:Audit.Enabled"
+		    }
+		  }
+		}
+
+	A component whose conditions are met replaces a component with the same name that has no conditions. It is an error for
+	more than one component with the same name to have its conditions met. Excluded components are logged when the container
+	is populated. See the GoDoc for ioc.ConfCondition for the supported conditions.
+
+	Start and stop order
+
+	The container builds a graph of the dependencies between components (from ref: fields, factories and factory inputs).
+	Components are started after the components they depend on and stopped before them. If components that need to be
+	started or stopped depend on each other (directly or via other components), the container cannot determine a safe order
+	and startup will fail with a report of the circular dependencies found. Dependencies injected by decorators are not
+	considered.
+
 	Decorators
 
 	Decorators are special components implementing ioc.ComponentDecorator. Their main purpose is to inject dynamically
@@ -184,6 +255,9 @@ type ProtoComponents struct {
 
 	//A Base64 encoded version of the JSON files found in resource/facility-confg
 	FrameworkConfig *string
+
+	//A Base64 encoded version of the JSON schemas found in resource/facility-schema
+	FrameworkSchema *string
 }
 
 // Clear removes the reference to the ProtoComponent objects held in this object, encouraging garbage collection.
@@ -215,6 +289,26 @@ func CreateProtoComponent(componentInstance interface{}, componentName string) *
 
 }
 
+// CreateFactoryProtoComponent creates a new ProtoComponent whose instance will be built by the supplied function during the
+// Populate phase of container startup.
+func CreateFactoryProtoComponent(f FactoryFunc, componentName string) *ProtoComponent {
+
+	proto := CreateProtoComponent(nil, componentName)
+	proto.FactoryFunc = f
+
+	return proto
+}
+
+// CreateFactoryRefProtoComponent creates a new ProtoComponent whose instance will be built by the component (implementing ioc.Factory)
+// with the supplied name during the Populate phase of container startup.
+func CreateFactoryRefProtoComponent(factoryName string, componentName string) *ProtoComponent {
+
+	proto := CreateProtoComponent(nil, componentName)
+	proto.FactoryName = factoryName
+
+	return proto
+}
+
 // A ProtoComponent is a partially configured component that will be hosted in the Granitic IoC container once
 // it is fully configured. Typically ProtoComponents are created using the grnc-bind tool.
 type ProtoComponent struct {
@@ -226,6 +320,42 @@ type ProtoComponent struct {
 
 	// A map of fields on the component instance and the config-path that will contain the configuration that shoud be inject into the field.
 	ConfigPromises map[string]string
+
+	// The name of a component implementing Factory that will build this component's instance.
+	FactoryName string
+
+	// A function that will build this component's instance.
+	FactoryFunc FactoryFunc
+
+	// Literal values that will be passed to this component's factory.
+	FactoryValues map[string]interface{}
+
+	// The scope of the component (SingletonScope, PrototypeScope or RequestScope). Empty means SingletonScope.
+	Scope string
+
+	// Conditions that must all be met for the component to be added to the container. See ConfCondition.
+	Conditions []string
+}
+
+// BuiltByFactory returns true if the instance of this component will be created by a Factory or FactoryFunc. For these
+// components, Dependencies and ConfigPromises are passed to the factory as inputs rather than being injected into fields.
+func (pc *ProtoComponent) BuiltByFactory() bool {
+	return pc.FactoryName != "" || pc.FactoryFunc != nil
+}
+
+// AddCondition adds a condition that must be met for this component to be added to the container. See ConfCondition.
+func (pc *ProtoComponent) AddCondition(condition string) {
+	pc.Conditions = append(pc.Conditions, condition)
+}
+
+// AddFactoryValue supplies a literal value that will be passed to this component's factory as the named input.
+func (pc *ProtoComponent) AddFactoryValue(inputName string, value interface{}) {
+
+	if pc.FactoryValues == nil {
+		pc.FactoryValues = make(map[string]interface{})
+	}
+
+	pc.FactoryValues[inputName] = value
 }
 
 // AddDependency requests that the container injects another component into the specified field during the configure phase of
@@ -269,6 +399,28 @@ type ByName struct{ Components }
 
 func (s ByName) Less(i, j int) bool { return s.Components[i].Name < s.Components[j].Name }
 
+// Sorts components so that components appear after the components they depend on. Components with no known position
+// appear last, sorted by name.
+type byStartOrder struct {
+	Components
+	rank map[string]int
+}
+
+func (s byStartOrder) Less(i, j int) bool {
+	ri, iFound := s.rank[s.Components[i].Name]
+	rj, jFound := s.rank[s.Components[j].Name]
+
+	if iFound && jFound {
+		return ri < rj
+	}
+
+	if iFound != jFound {
+		return iFound
+	}
+
+	return s.Components[i].Name < s.Components[j].Name
+}
+
 // Implemented by components where the component's instance needs to be aware of its own component name.
 type ComponentNamer interface {
 	// ComponentName returns the name of the component
@@ -0,0 +1,181 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+Conditions control whether or not a component is added to the container. A condition is a string in one of the forms:
+
+	conf:Path.To.Bool   Met if the configuration value at the path is the JSON bool true (a missing path is not met).
+
+	profile:name        Met if the named profile is active (see instance.System.Profiles).
+
+Either form can be prefixed with ! to negate it (e.g. !profile:production). A component with more than one condition is
+only added to the container if all of its conditions are met.
+
+Several components can be registered with the same name as long as no more than one of them has its conditions met. A
+component whose conditions are met replaces a component with the same name that has no conditions, which allows the
+unconditional component to act as a default.
+*/
+const (
+	ConfCondition    = "conf:"
+	ProfileCondition = "profile:"
+	NegateCondition  = "!"
+)
+
+// Exclusion records a component that was not added to the container because of its conditions.
+type Exclusion struct {
+	// The name of the excluded component.
+	Name string
+
+	// Why the component was excluded.
+	Reason string
+}
+
+// Exclusions returns the components that were not added to the container because their conditions were not met or because
+// they were replaced by another component with the same name.
+func (cc *ComponentContainer) Exclusions() []*Exclusion {
+	return cc.exclusions
+}
+
+// Decides whether the supplied proto-component should be registered, given its conditions and any component already
+// registered with the same name.
+func (cc *ComponentContainer) admit(proto *ProtoComponent) bool {
+
+	name := proto.Component.Name
+
+	met, reason, err := cc.conditionsMet(proto.Conditions)
+
+	if err != nil {
+		m := fmt.Sprintf("Unable to evaluate the conditions of component %s: %s", name, err.Error())
+		cc.conditionErrors = append(cc.conditionErrors, m)
+		return false
+	}
+
+	if !met {
+		cc.exclude(name, reason)
+		return false
+	}
+
+	existing := cc.protoComponents[name]
+
+	if existing == nil {
+		return true
+	}
+
+	conditional := len(proto.Conditions) > 0
+	existingConditional := len(existing.Conditions) > 0
+
+	switch {
+	case conditional && existingConditional:
+		m := fmt.Sprintf("More than one component named %s has its conditions met (%s and %s)", name,
+			strings.Join(existing.Conditions, ", "), strings.Join(proto.Conditions, ", "))
+		cc.conditionErrors = append(cc.conditionErrors, m)
+		return false
+
+	case conditional:
+		cc.exclude(name, "replaced by a component with conditions "+strings.Join(proto.Conditions, ", "))
+
+	case existingConditional:
+		cc.exclude(name, "replaced by a component with conditions "+strings.Join(existing.Conditions, ", "))
+		return false
+	}
+
+	return true
+}
+
+func (cc *ComponentContainer) exclude(name, reason string) {
+	cc.FrameworkLogger.LogDebugf("Excluding component %s: %s", name, reason)
+
+	cc.exclusions = append(cc.exclusions, &Exclusion{Name: name, Reason: reason})
+}
+
+// Returns false and a description of the first condition that is not met, or true if all conditions are met.
+func (cc *ComponentContainer) conditionsMet(conditions []string) (bool, string, error) {
+
+	for _, c := range conditions {
+
+		met, err := cc.conditionMet(c)
+
+		if err != nil {
+			return false, "", err
+		}
+
+		if !met {
+			return false, "condition " + c + " not met", nil
+		}
+	}
+
+	return true, "", nil
+}
+
+func (cc *ComponentContainer) conditionMet(condition string) (bool, error) {
+
+	c := strings.TrimSpace(condition)
+
+	negate := strings.HasPrefix(c, NegateCondition)
+
+	if negate {
+		c = strings.TrimSpace(strings.TrimPrefix(c, NegateCondition))
+	}
+
+	var met bool
+
+	switch {
+	case strings.HasPrefix(c, ConfCondition):
+		path := strings.TrimPrefix(c, ConfCondition)
+
+		if !cc.configAccessor.PathExists(path) {
+			break
+		}
+
+		b, err := cc.configAccessor.BoolVal(path)
+
+		if err != nil {
+			return false, err
+		}
+
+		met = b
+
+	case strings.HasPrefix(c, ProfileCondition):
+		met = cc.profileActive(strings.TrimPrefix(c, ProfileCondition))
+
+	default:
+		m := fmt.Sprintf("Unsupported condition '%s' (conditions must start with %s or %s)", condition, ConfCondition, ProfileCondition)
+		return false, errors.New(m)
+	}
+
+	return met != negate, nil
+}
+
+func (cc *ComponentContainer) profileActive(profile string) bool {
+
+	if cc.system == nil {
+		return false
+	}
+
+	return contains(cc.system.Profiles, profile)
+}
+
+// Returns an error summarising any problems encountered while evaluating conditions.
+func (cc *ComponentContainer) conditionError() error {
+
+	if len(cc.conditionErrors) == 0 {
+		return nil
+	}
+
+	return errors.New(strings.Join(cc.conditionErrors, "\n"))
+}
+
+func (cc *ComponentContainer) reportExclusions() {
+
+	for _, e := range cc.exclusions {
+		cc.FrameworkLogger.LogInfof("Component %s excluded (%s)", e.Name, e.Reason)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ioc
+
+import (
+	"github.com/graniticio/granitic/test"
+	"testing"
+)
+
+type sender struct {
+	kind string
+}
+
+type mailer struct {
+	Sender *sender
+}
+
+func TestConditionalComponentReplacesDefault(t *testing.T) {
+
+	conf := map[string]interface{}{"Mail": map[string]interface{}{"ForceSMTP": false}}
+
+	cc := newTestContainer(conf)
+	cc.system.Profiles = []string{"dev"}
+
+	stub := CreateProtoComponent(&sender{kind: "logging"}, "sender")
+	stub.AddCondition("profile:dev")
+	stub.AddCondition("!conf:Mail.ForceSMTP")
+	cc.AddProto(stub)
+
+	cc.AddProto(CreateProtoComponent(&sender{kind: "smtp"}, "sender"))
+
+	m := CreateProtoComponent(new(mailer), "mailer")
+	m.AddDependency("Sender", "sender")
+	cc.AddProto(m)
+
+	test.ExpectNil(t, cc.Populate())
+
+	s := cc.ComponentByName("mailer").Instance.(*mailer).Sender
+	test.ExpectString(t, s.kind, "logging")
+
+	test.ExpectInt(t, len(cc.Exclusions()), 1)
+	test.ExpectString(t, cc.Exclusions()[0].Name, "sender")
+}
+
+func TestConditionsNotMet(t *testing.T) {
+
+	conf := map[string]interface{}{"Audit": map[string]interface{}{"Enabled": false}}
+
+	cc := newTestContainer(conf)
+
+	cc.AddProto(CreateProtoComponent(&sender{kind: "smtp"}, "sender"))
+
+	stub := CreateProtoComponent(&sender{kind: "logging"}, "sender")
+	stub.AddCondition("profile:dev")
+	cc.AddProto(stub)
+
+	audit := CreateProtoComponent(new(mailer), "auditor")
+	audit.AddCondition("conf:Audit.Enabled")
+	cc.AddProto(audit)
+
+	missing := CreateProtoComponent(new(mailer), "reporter")
+	missing.AddCondition("conf:Reporting.Enabled")
+	cc.AddProto(missing)
+
+	test.ExpectNil(t, cc.Populate())
+
+	test.ExpectString(t, cc.ComponentByName("sender").Instance.(*sender).kind, "smtp")
+	test.ExpectBool(t, cc.ComponentByName("auditor") == nil, true)
+	test.ExpectBool(t, cc.ComponentByName("reporter") == nil, true)
+	test.ExpectInt(t, len(cc.Exclusions()), 3)
+}
+
+func TestConditionErrors(t *testing.T) {
+
+	conf := map[string]interface{}{"Audit": map[string]interface{}{"Enabled": "yes"}}
+
+	cc := newTestContainer(conf)
+	cc.system.Profiles = []string{"dev", "test"}
+
+	a := CreateProtoComponent(new(sender), "sender")
+	a.AddCondition("profile:dev")
+	cc.AddProto(a)
+
+	b := CreateProtoComponent(new(sender), "sender")
+	b.AddCondition("profile:test")
+	cc.AddProto(b)
+
+	test.ExpectNotNil(t, cc.Populate())
+
+	cc = newTestContainer(conf)
+
+	c := CreateProtoComponent(new(sender), "sender")
+	c.AddCondition("conf:Audit.Enabled")
+	cc.AddProto(c)
+
+	test.ExpectNotNil(t, cc.Populate())
+
+	cc = newTestContainer(conf)
+
+	d := CreateProtoComponent(new(sender), "sender")
+	d.AddCondition("env:HOME")
+	cc.AddProto(d)
+
+	test.ExpectNotNil(t, cc.Populate())
+}
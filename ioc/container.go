@@ -12,6 +12,7 @@ import (
 	"github.com/graniticio/granitic/reflecttools"
 	"os"
 	"sort"
+	"strings"
 )
 
 const containerDecoratorComponentName = instance.FrameworkPrefix + "ContainerDecorator"
@@ -59,6 +60,21 @@ type ComponentContainer struct {
 	modifiers          map[string]map[string]string
 	Lifecycle          *LifecycleManager
 	system             *instance.System
+	startRank          map[string]int
+	scopes             map[string]string
+	scopedDependencies map[string]map[string]string
+	pendingPrototypes  []*pendingInjection
+	exclusions         []*Exclusion
+	conditionErrors    []string
+	configPromises     map[string]map[string]string
+}
+
+// Records that a new instance of a prototype component needs to be injected into a field on a singleton component once
+// all components have been decorated.
+type pendingInjection struct {
+	target    *Component
+	fieldName string
+	depName   string
 }
 
 // ProtoComponentsByType returns any ProtoComponents whose Component.Instance field matches the against the supplied TypeMatcher function.
@@ -163,12 +179,15 @@ func (cc *ComponentContainer) Modifiers(comp string) map[string]string {
 	return cc.modifiers[comp]
 }
 
-// AddProto registers an instantiated but un-configured proto-component.
+// AddProto registers an instantiated but un-configured proto-component. Proto-components whose conditions are not met
+// are not registered (see ConfCondition).
 func (cc *ComponentContainer) AddProto(proto *ProtoComponent) {
 
 	cc.FrameworkLogger.LogTracef("Adding proto %s", proto.Component.Name)
 
-	cc.protoComponents[proto.Component.Name] = proto
+	if cc.admit(proto) {
+		cc.protoComponents[proto.Component.Name] = proto
+	}
 }
 
 // WrapAndAddProto registers an instance and name as an un-configured proto-component.
@@ -201,10 +220,24 @@ func (cc *ComponentContainer) Populate() error {
 
 	decorators[containerDecoratorComponentName] = containerDecorator
 
+	if err := cc.conditionError(); err != nil {
+		return err
+	}
+
+	cc.reportExclusions()
+
 	cc.allComponents = make(map[string]*Component)
 
+	if err := cc.recordScopes(); err != nil {
+		return err
+	}
+
 	for _, protoComponent := range cc.protoComponents {
 
+		if protoComponent.BuiltByFactory() {
+			continue
+		}
+
 		component := protoComponent.Component
 
 		if !reflecttools.IsPointerToStruct(component.Instance) {
@@ -216,7 +249,13 @@ func (cc *ComponentContainer) Populate() error {
 		cc.captureDecorator(component, decorators)
 	}
 
-	err := cc.resolveDependenciesAndConfig()
+	configured := make(map[string]bool)
+
+	err := cc.buildFactoryComponents(decorators, configured)
+
+	if err == nil {
+		err = cc.resolveDependenciesAndConfig(decorators, configured)
+	}
 
 	if err != nil {
 		cc.FrameworkLogger.LogFatalf(err.Error())
@@ -224,52 +263,326 @@ func (cc *ComponentContainer) Populate() error {
 		os.Exit(-1)
 	}
 
+	if err := cc.determineStartOrder(); err != nil {
+		return err
+	}
+
 	cc.runDecorators(decorators)
 
+	if err := cc.injectPrototypes(); err != nil {
+		return err
+	}
+
+	cc.recordConfigPromises()
+
 	cc.protoComponents = nil
 
 	return nil
 }
 
-func (cc *ComponentContainer) resolveDependenciesAndConfig() error {
+// Records the scope of each component that is not a singleton and checks that the scope is valid.
+func (cc *ComponentContainer) recordScopes() error {
 
-	fl := cc.FrameworkLogger
+	cc.scopes = make(map[string]string)
+	cc.scopedDependencies = make(map[string]map[string]string)
+
+	for name, proto := range cc.protoComponents {
+
+		s := proto.Scope
+
+		if !validScope(s) {
+			m := fmt.Sprintf("Component %s has an unsupported scope '%s' (valid scopes are %s, %s and %s)", name, s, SingletonScope, PrototypeScope, RequestScope)
+			return errors.New(m)
+		}
+
+		if s == "" || s == SingletonScope {
+			continue
+		}
+
+		if proto.BuiltByFactory() {
+			m := fmt.Sprintf("Component %s is built by a factory so must be a %s (scope is %s)", name, SingletonScope, s)
+			return errors.New(m)
+		}
+
+		cc.scopes[name] = s
+	}
+
+	return nil
+}
+
+// Injects new instances of prototype components into the singleton components that depend on them.
+func (cc *ComponentContainer) injectPrototypes() error {
+
+	for _, p := range cc.pendingPrototypes {
+
+		instance, err := cc.NewInstance(p.depName)
+
+		if err != nil {
+			return err
+		}
+
+		if err := reflecttools.SetPtrToStruct(p.target.Instance, p.fieldName, instance); err != nil {
+			m := fmt.Sprintf("Problem injecting dependency '%s' into %s.%s: %s", p.depName, p.target.Name, p.fieldName, err.Error())
+			return errors.New(m)
+		}
+	}
+
+	cc.pendingPrototypes = nil
+
+	return nil
+}
+
+// Creates the instances of any components that are built by a Factory or FactoryFunc. Factory-built components that
+// are inputs to other factories are built first.
+func (cc *ComponentContainer) buildFactoryComponents(decorators map[string]ComponentDecorator, configured map[string]bool) error {
+
+	for _, proto := range cc.protoComponents {
+
+		if !proto.BuiltByFactory() {
+			continue
+		}
+
+		if err := cc.buildFromFactory(proto, decorators, configured, []string{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cc *ComponentContainer) buildFromFactory(proto *ProtoComponent, decorators map[string]ComponentDecorator, configured map[string]bool, chain []string) error {
+
+	name := proto.Component.Name
+
+	if cc.allComponents[name] != nil {
+		return nil
+	}
+
+	if contains(chain, name) {
+		m := fmt.Sprintf("Components built by factories have a circular dependency: %s", strings.Join(append(chain, name), " -> "))
+		return errors.New(m)
+	}
+
+	chain = append(chain, name)
+
+	inputs := new(FactoryInputs)
+	inputs.Components = make(map[string]interface{})
+	inputs.Values = make(map[string]interface{})
+
+	for inputName, depName := range cc.mergeDependencies(name, proto.Dependencies) {
+
+		required, err := cc.requireComponent(depName, decorators, configured, chain)
+
+		if err != nil {
+			return err
+		}
+
+		if required == nil {
+			m := fmt.Sprintf("No component named %s available (required as input %s to the factory for %s)", depName, inputName, name)
+			return errors.New(m)
+		}
+
+		if ds := cc.Scope(depName); ds != SingletonScope {
+			m := fmt.Sprintf("Component %s is %s scoped and cannot be used as input %s to the factory for %s", depName, ds, inputName, name)
+			return errors.New(m)
+		}
+
+		if err := cc.configureBeforeUse(depName, decorators, configured, chain); err != nil {
+			return err
+		}
+
+		inputs.Components[inputName] = required.Instance
+	}
+
+	for inputName, configPath := range proto.ConfigPromises {
+
+		v := cc.configAccessor.Value(configPath)
+
+		if v == nil {
+			m := fmt.Sprintf("No value found at %s (required as input %s to the factory for %s)", configPath, inputName, name)
+			return errors.New(m)
+		}
+
+		inputs.Values[inputName] = v
+	}
+
+	for inputName, v := range proto.FactoryValues {
+		inputs.Values[inputName] = v
+	}
+
+	var instance interface{}
+	var err error
+
+	if proto.FactoryFunc != nil {
+		cc.FrameworkLogger.LogTracef("Building %s with a factory function", name)
+
+		instance, err = proto.FactoryFunc(inputs)
+
+	} else {
+		cc.FrameworkLogger.LogTracef("Building %s with factory component %s", name, proto.FactoryName)
+
+		if instance, err = cc.buildWithFactoryComponent(proto, inputs, decorators, configured, chain); err != nil {
+			return err
+		}
+	}
+
+	if err != nil {
+		m := fmt.Sprintf("Problem building %s with a factory: %s", name, err.Error())
+		return errors.New(m)
+	}
+
+	if !reflecttools.IsPointerToStruct(instance) {
+		m := fmt.Sprintf("The factory for component %s did not return a pointer to a struct.", name)
+		return errors.New(m)
+	}
+
+	proto.Component.Instance = instance
+
+	cc.addComponent(proto.Component)
+	cc.captureDecorator(proto.Component, decorators)
+
+	return nil
+}
+
+func (cc *ComponentContainer) buildWithFactoryComponent(proto *ProtoComponent, inputs *FactoryInputs, decorators map[string]ComponentDecorator, configured map[string]bool, chain []string) (interface{}, error) {
+
+	name := proto.Component.Name
+	fn := proto.FactoryName
+
+	fc, err := cc.requireComponent(fn, decorators, configured, chain)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if fc == nil {
+		m := fmt.Sprintf("No component named %s available (required as the factory for %s)", fn, name)
+		return nil, errors.New(m)
+	}
+
+	f, found := fc.Instance.(Factory)
+
+	if !found {
+		m := fmt.Sprintf("Component %s does not implement ioc.Factory (required as the factory for %s)", fn, name)
+		return nil, errors.New(m)
+	}
+
+	if err := cc.configureBeforeUse(fn, decorators, configured, chain); err != nil {
+		return nil, err
+	}
+
+	instance, err := f.Build(name, inputs)
+
+	if err != nil {
+		m := fmt.Sprintf("Problem building %s with factory %s: %s", name, fn, err.Error())
+		return nil, errors.New(m)
+	}
+
+	return instance, nil
+}
+
+// Injects the dependencies and configuration of the named component (unless it was built by a factory) so that it can
+// be used by a factory.
+func (cc *ComponentContainer) configureBeforeUse(name string, decorators map[string]ComponentDecorator, configured map[string]bool, chain []string) error {
+
+	if p := cc.protoComponents[name]; p != nil && !p.BuiltByFactory() {
+		return cc.configure(p, decorators, configured, chain)
+	}
+
+	return nil
+}
+
+// Finds the named component, building it first if it is built by a factory. Returns nil if no such component has been registered.
+func (cc *ComponentContainer) requireComponent(name string, decorators map[string]ComponentDecorator, configured map[string]bool, chain []string) (*Component, error) {
+
+	if c := cc.allComponents[name]; c != nil {
+		return c, nil
+	}
+
+	if p := cc.protoComponents[name]; p != nil && p.BuiltByFactory() {
+
+		if err := cc.buildFromFactory(p, decorators, configured, chain); err != nil {
+			return nil, err
+		}
+
+		return cc.allComponents[name], nil
+	}
+
+	return nil, nil
+}
+
+func (cc *ComponentContainer) resolveDependenciesAndConfig(decorators map[string]ComponentDecorator, configured map[string]bool) error {
 
 	for _, targetProto := range cc.protoComponents {
 
-		compName := targetProto.Component.Name
-		deps := cc.mergeDependencies(compName, targetProto.Dependencies)
+		if targetProto.BuiltByFactory() {
+			continue
+		}
 
-		for fieldName, depName := range deps {
+		if err := cc.configure(targetProto, decorators, configured, []string{}); err != nil {
+			return err
+		}
 
-			fl.LogTracef("%s needs %s", compName, depName)
+	}
 
-			requiredComponent := cc.allComponents[depName]
+	return nil
+}
 
-			if requiredComponent == nil {
-				message := fmt.Sprintf("No component named %s available (required by %s.%s)", depName, compName, fieldName)
-				return errors.New(message)
-			}
+// Injects dependencies and configuration into the fields of the supplied component.
+func (cc *ComponentContainer) configure(targetProto *ProtoComponent, decorators map[string]ComponentDecorator, configured map[string]bool, chain []string) error {
 
-			targetInstance := targetProto.Component.Instance
-			requiredInstance := requiredComponent.Instance
+	fl := cc.FrameworkLogger
+	compName := targetProto.Component.Name
 
-			err := reflecttools.SetPtrToStruct(targetInstance, fieldName, requiredInstance)
+	if configured[compName] {
+		return nil
+	}
+
+	configured[compName] = true
+
+	deps := cc.mergeDependencies(compName, targetProto.Dependencies)
+
+	for fieldName, depName := range deps {
 
-			if err != nil {
-				m := fmt.Sprintf("Problem injecting dependency '%s' into %s.%s: %s", depName, compName, fieldName, err.Error())
-				return errors.New(m)
-			}
+		fl.LogTracef("%s needs %s", compName, depName)
 
+		requiredComponent, err := cc.requireComponent(depName, decorators, configured, chain)
+
+		if err != nil {
+			return err
 		}
 
-		for fieldName, configPath := range targetProto.ConfigPromises {
-			fl.LogTracef("%s needs %s", targetProto.Component.Name, fieldName, configPath)
+		if requiredComponent == nil {
+			message := fmt.Sprintf("No component named %s available (required by %s.%s)", depName, compName, fieldName)
+			return errors.New(message)
+		}
 
-			if err := cc.configAccessor.SetField(fieldName, configPath, targetProto.Component.Instance); err != nil {
-				return err
-			}
+		if err := cc.checkScopes(compName, fieldName, depName); err != nil {
+			return err
+		}
 
+		if cc.Scope(depName) != SingletonScope {
+			cc.deferScopedDependency(targetProto.Component, fieldName, depName)
+			continue
+		}
+
+		targetInstance := targetProto.Component.Instance
+		requiredInstance := requiredComponent.Instance
+
+		err = reflecttools.SetPtrToStruct(targetInstance, fieldName, requiredInstance)
+
+		if err != nil {
+			m := fmt.Sprintf("Problem injecting dependency '%s' into %s.%s: %s", depName, compName, fieldName, err.Error())
+			return errors.New(m)
+		}
+
+	}
+
+	for fieldName, configPath := range targetProto.ConfigPromises {
+		fl.LogTracef("%s.%s needs %s", compName, fieldName, configPath)
+
+		if err := cc.configAccessor.SetField(fieldName, configPath, targetProto.Component.Instance); err != nil {
+			return err
 		}
 
 	}
@@ -277,6 +590,106 @@ func (cc *ComponentContainer) resolveDependenciesAndConfig() error {
 	return nil
 }
 
+// New instances of prototype and request scoped components can't be injected until all components have been decorated.
+func (cc *ComponentContainer) deferScopedDependency(target *Component, fieldName, depName string) {
+
+	name := target.Name
+
+	if cc.Scope(name) == SingletonScope {
+
+		p := new(pendingInjection)
+		p.target = target
+		p.fieldName = fieldName
+		p.depName = depName
+
+		cc.pendingPrototypes = append(cc.pendingPrototypes, p)
+
+		return
+	}
+
+	sd := cc.scopedDependencies[name]
+
+	if sd == nil {
+		sd = make(map[string]string)
+		cc.scopedDependencies[name] = sd
+	}
+
+	sd[fieldName] = depName
+}
+
+// Builds a graph of the dependencies between components and uses it to sort the components that support lifecycle
+// events so that components are started after the components they depend on.
+func (cc *ComponentContainer) determineStartOrder() error {
+
+	dg := newDependencyGraph()
+
+	for name, proto := range cc.protoComponents {
+
+		deps := cc.mergeDependencies(name, proto.Dependencies)
+
+		dg.add(name)
+
+		for _, d := range deps {
+			dg.add(name, d)
+		}
+
+		if proto.FactoryName != "" {
+			dg.add(name, proto.FactoryName)
+		}
+	}
+
+	needsOrder := func(name string) bool {
+		c := cc.allComponents[name]
+
+		if c == nil || cc.Scope(name) != SingletonScope {
+			return false
+		}
+
+		switch c.Instance.(type) {
+		case Startable, Stoppable, Accessible:
+			return true
+		}
+
+		return false
+	}
+
+	if cycles := dg.unorderableCycles(needsOrder); len(cycles) > 0 {
+		return errors.New(cycleReport(cycles))
+	}
+
+	cc.startRank = dg.startOrder()
+
+	for _, ls := range []LifecycleSupport{CanStart, CanBeAccessed, CanStop, CanSuspend, CanBlockStart} {
+		sort.Sort(byStartOrder{cc.byLifecycleSupport[ls], cc.startRank})
+	}
+
+	return nil
+}
+
+// InStartOrder returns a copy of the supplied components, sorted so that each component appears after the components
+// it depends on.
+func (cc *ComponentContainer) InStartOrder(comps []*Component) []*Component {
+
+	sorted := make([]*Component, len(comps))
+	copy(sorted, comps)
+
+	sort.Sort(byStartOrder{sorted, cc.startRank})
+
+	return sorted
+}
+
+// InStopOrder returns a copy of the supplied components, sorted so that each component appears before the components
+// it depends on.
+func (cc *ComponentContainer) InStopOrder(comps []*Component) []*Component {
+
+	sorted := make([]*Component, len(comps))
+	copy(sorted, comps)
+
+	sort.Sort(sort.Reverse(byStartOrder{sorted, cc.startRank}))
+
+	return sorted
+}
+
 // Combines dependencies attached to the proto components with any available framework modifiers
 func (cc *ComponentContainer) mergeDependencies(comp string, cd map[string]string) map[string]string {
 
@@ -354,6 +767,11 @@ func (cc *ComponentContainer) addComponent(component *Component) {
 		n.SetComponentName(component.Name)
 	}
 
+	if s := cc.Scope(component.Name); s != SingletonScope {
+		l.LogTracef("%s is %s scoped and will not receive lifecycle events", component.Name, s)
+		return
+	}
+
 	if _, startable := component.Instance.(Startable); startable {
 		l.LogTracef("%s is Startable", component.Name)
 		cc.addBySupport(component, CanStart)
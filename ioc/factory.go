@@ -0,0 +1,159 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ioc
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+Factory is implemented by components that are able to build the instance of another component. Factories allow types
+that have no exported fields (or that must be created with a constructor function, like *sql.DB) to be hosted in the
+container.
+
+A component is marked as being built by a Factory in its component definition file:
+
+	{
+	  "components": {
+	    "dbFactory": {
+	      "type": "db.ConnectionFactory"
+	    },
+	    "inventoryDB": {
+	      "factory": "ref:dbFactory",
+	      "Driver": "mysql",
+	      "DataSource": "conf:Inventory.DataSource",
+	      "Logger": "ref:auditLogger"
+	    }
+	  }
+	}
+
+The Factory will be invoked during the Populate phase of container startup, after its own dependencies and configuration
+have been injected. Any references, configuration promises and literal values in the component definition are passed to
+the Factory as FactoryInputs rather than being injected into fields.
+*/
+type Factory interface {
+	// Build creates the instance of the named component using the supplied inputs. The instance must be a pointer to a struct.
+	Build(componentName string, inputs *FactoryInputs) (interface{}, error)
+}
+
+/*
+FactoryFunc is a function that is able to build the instance of a component. Functions are referenced in component
+definition files by their package qualified name:
+
+	{
+	  "components": {
+	    "inventoryDB": {
+	      "factory": "db.OpenConnection",
+	      "DataSource": "conf:Inventory.DataSource"
+	    }
+	  }
+	}
+*/
+type FactoryFunc func(inputs *FactoryInputs) (interface{}, error)
+
+// FactoryInputs contains the components and values that were requested as ingredients for a component built by a Factory
+// or FactoryFunc.
+type FactoryInputs struct {
+	// Components requested with ref: in the component definition, keyed by input name.
+	Components map[string]interface{}
+
+	// Literal values and resolved configuration promises (conf: in the component definition), keyed by input name.
+	Values map[string]interface{}
+}
+
+// Component returns the component instance that was supplied as the named input, or nil if no such input exists.
+func (fi *FactoryInputs) Component(name string) interface{} {
+	return fi.Components[name]
+}
+
+// Value returns the literal or configuration value that was supplied as the named input, or nil if no such input exists.
+func (fi *FactoryInputs) Value(name string) interface{} {
+	return fi.Values[name]
+}
+
+// StringVal returns the named input as a string. Returns an error if the input is missing or is not a string.
+func (fi *FactoryInputs) StringVal(name string) (string, error) {
+
+	v := fi.Values[name]
+
+	if v == nil {
+		return "", missingInput(name)
+	}
+
+	if s, found := v.(string); found {
+		return s, nil
+	}
+
+	return "", wrongInputType(name, v, "string")
+}
+
+// IntVal returns the named input as an int. Configuration values (which are float64 after JSON parsing) are truncated.
+// Returns an error if the input is missing or is not a number.
+func (fi *FactoryInputs) IntVal(name string) (int, error) {
+
+	v := fi.Values[name]
+
+	if v == nil {
+		return 0, missingInput(name)
+	}
+
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case float64:
+		return int(n), nil
+	}
+
+	return 0, wrongInputType(name, v, "int")
+}
+
+// Float64Val returns the named input as a float64. Returns an error if the input is missing or is not a number.
+func (fi *FactoryInputs) Float64Val(name string) (float64, error) {
+
+	v := fi.Values[name]
+
+	if v == nil {
+		return 0, missingInput(name)
+	}
+
+	switch n := v.(type) {
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	}
+
+	return 0, wrongInputType(name, v, "float64")
+}
+
+// BoolVal returns the named input as a bool. Returns an error if the input is missing or is not a bool.
+func (fi *FactoryInputs) BoolVal(name string) (bool, error) {
+
+	v := fi.Values[name]
+
+	if v == nil {
+		return false, missingInput(name)
+	}
+
+	if b, found := v.(bool); found {
+		return b, nil
+	}
+
+	return false, wrongInputType(name, v, "bool")
+}
+
+func missingInput(name string) error {
+	m := fmt.Sprintf("No factory input named %s", name)
+	return errors.New(m)
+}
+
+func wrongInputType(name string, v interface{}, t string) error {
+	m := fmt.Sprintf("Factory input %s is %v and cannot be converted to a %s", name, v, t)
+	return errors.New(m)
+}
@@ -0,0 +1,167 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ioc
+
+import (
+	"errors"
+	"github.com/graniticio/granitic/config"
+	"github.com/graniticio/granitic/instance"
+	"github.com/graniticio/granitic/logging"
+	"github.com/graniticio/granitic/test"
+	"testing"
+)
+
+type connection struct {
+	dataSource string
+	maxConns   int
+	owner      *owner
+}
+
+type owner struct {
+	Name string
+}
+
+type connectionFactory struct {
+	Prefix string
+}
+
+func (cf *connectionFactory) Build(name string, inputs *FactoryInputs) (interface{}, error) {
+
+	ds, err := inputs.StringVal("DataSource")
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := new(connection)
+	c.dataSource = cf.Prefix + ds
+
+	return c, nil
+}
+
+type connectionUser struct {
+	Conn *connection
+}
+
+func newTestContainer(conf map[string]interface{}) *ComponentContainer {
+
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, map[string]interface{}{}, []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter())
+
+	ca := new(config.ConfigAccessor)
+	ca.JsonData = conf
+	ca.FrameworkLogger = new(logging.ConsoleErrorLogger)
+
+	return NewComponentContainer(lm, ca, new(instance.System))
+}
+
+func TestFactoryFuncWithInputs(t *testing.T) {
+
+	conf := map[string]interface{}{"Db": map[string]interface{}{"Source": "localhost"}}
+
+	cc := newTestContainer(conf)
+
+	cc.WrapAndAddProto("owner", &owner{Name: "inventory"})
+
+	f := func(inputs *FactoryInputs) (interface{}, error) {
+		c := new(connection)
+		c.dataSource, _ = inputs.StringVal("DataSource")
+		c.maxConns, _ = inputs.IntVal("MaxConns")
+		c.owner = inputs.Component("Owner").(*owner)
+
+		return c, nil
+	}
+
+	p := CreateFactoryProtoComponent(f, "conn")
+	p.AddConfigPromise("DataSource", "Db.Source")
+	p.AddFactoryValue("MaxConns", 10)
+	p.AddDependency("Owner", "owner")
+	cc.AddProto(p)
+
+	u := CreateProtoComponent(new(connectionUser), "user")
+	u.AddDependency("Conn", "conn")
+	cc.AddProto(u)
+
+	err := cc.Populate()
+	test.ExpectNil(t, err)
+
+	c := cc.ComponentByName("conn").Instance.(*connection)
+
+	test.ExpectString(t, c.dataSource, "localhost")
+	test.ExpectInt(t, c.maxConns, 10)
+	test.ExpectString(t, c.owner.Name, "inventory")
+
+	cu := cc.ComponentByName("user").Instance.(*connectionUser)
+	test.ExpectBool(t, cu.Conn == c, true)
+}
+
+func TestFactoryComponentConfiguredBeforeBuild(t *testing.T) {
+
+	conf := map[string]interface{}{"Db": map[string]interface{}{"Source": "localhost", "Prefix": "tcp://"}}
+
+	cc := newTestContainer(conf)
+
+	fp := CreateProtoComponent(new(connectionFactory), "factory")
+	fp.AddConfigPromise("Prefix", "Db.Prefix")
+	cc.AddProto(fp)
+
+	p := CreateFactoryRefProtoComponent("factory", "conn")
+	p.AddConfigPromise("DataSource", "Db.Source")
+	cc.AddProto(p)
+
+	err := cc.Populate()
+	test.ExpectNil(t, err)
+
+	c := cc.ComponentByName("conn").Instance.(*connection)
+	test.ExpectString(t, c.dataSource, "tcp://localhost")
+}
+
+func TestFactoryInputConfiguredBeforeBuild(t *testing.T) {
+
+	conf := map[string]interface{}{"Db": map[string]interface{}{"Source": "localhost", "Owner": "inventory"}}
+
+	cc := newTestContainer(conf)
+
+	o := CreateProtoComponent(new(owner), "owner")
+	o.AddConfigPromise("Name", "Db.Owner")
+	cc.AddProto(o)
+
+	var ownerAtBuild string
+
+	f := func(inputs *FactoryInputs) (interface{}, error) {
+		ownerAtBuild = inputs.Component("Owner").(*owner).Name
+
+		return new(connection), nil
+	}
+
+	p := CreateFactoryProtoComponent(f, "conn")
+	p.AddDependency("Owner", "owner")
+	cc.AddProto(p)
+
+	err := cc.Populate()
+	test.ExpectNil(t, err)
+
+	test.ExpectString(t, ownerAtBuild, "inventory")
+}
+
+func TestFactoryCycleDetected(t *testing.T) {
+
+	cc := newTestContainer(map[string]interface{}{})
+
+	f := func(inputs *FactoryInputs) (interface{}, error) {
+		return nil, errors.New("should not be called")
+	}
+
+	a := CreateFactoryProtoComponent(f, "a")
+	a.AddDependency("B", "b")
+	cc.AddProto(a)
+
+	b := CreateFactoryProtoComponent(f, "b")
+	b.AddDependency("A", "a")
+	cc.AddProto(b)
+
+	cc.allComponents = make(map[string]*Component)
+
+	err := cc.buildFactoryComponents(map[string]ComponentDecorator{}, map[string]bool{})
+	test.ExpectNotNil(t, err)
+}
@@ -0,0 +1,264 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ioc
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+A dependencyGraph records, for each component in the container, the names of the other components it depends on (components
+injected into its fields with ref: or used as its factory or factory inputs). Dependencies injected by decorators are not
+recorded.
+
+The graph is used to determine the order in which components are started (dependencies first) and stopped (dependents first).
+*/
+type dependencyGraph struct {
+	dependsOn map[string][]string
+}
+
+func newDependencyGraph() *dependencyGraph {
+	dg := new(dependencyGraph)
+	dg.dependsOn = make(map[string][]string)
+
+	return dg
+}
+
+// add records that the named component depends on the other named components.
+func (dg *dependencyGraph) add(name string, dependsOn ...string) {
+
+	existing := dg.dependsOn[name]
+
+	for _, d := range dependsOn {
+
+		if !contains(existing, d) {
+			existing = append(existing, d)
+		}
+	}
+
+	sort.Strings(existing)
+
+	dg.dependsOn[name] = existing
+}
+
+// names returns the names of all components in the graph, sorted lexicographically.
+func (dg *dependencyGraph) names() []string {
+
+	seen := make(map[string]bool)
+	n := make([]string, 0)
+
+	for c, deps := range dg.dependsOn {
+
+		for _, d := range append([]string{c}, deps...) {
+			if !seen[d] {
+				seen[d] = true
+				n = append(n, d)
+			}
+		}
+	}
+
+	sort.Strings(n)
+
+	return n
+}
+
+// startOrder returns a map of component name to that component's position in an order where each component appears after
+// all of the components it depends on. Where cycles exist, the ordering of components within the cycle is determined by
+// component name.
+func (dg *dependencyGraph) startOrder() map[string]int {
+
+	visited := make(map[string]bool)
+	rank := make(map[string]int)
+
+	var visit func(n string)
+
+	visit = func(n string) {
+
+		if visited[n] {
+			return
+		}
+
+		visited[n] = true
+
+		for _, d := range dg.dependsOn[n] {
+			visit(d)
+		}
+
+		rank[n] = len(rank)
+	}
+
+	for _, n := range dg.names() {
+		visit(n)
+	}
+
+	return rank
+}
+
+// unorderableCycles finds groups of components that depend on each other (directly or indirectly) where two or more
+// members of the group need to be ordered (as determined by the supplied function). Each cycle is returned as a path of
+// component names starting and ending with the same name.
+func (dg *dependencyGraph) unorderableCycles(needsOrder func(name string) bool) [][]string {
+
+	cycles := make([][]string, 0)
+
+	for _, scc := range dg.stronglyConnected() {
+
+		ordered := make([]string, 0)
+
+		for _, n := range scc {
+			if needsOrder(n) {
+				ordered = append(ordered, n)
+			}
+		}
+
+		if len(ordered) < 2 {
+			continue
+		}
+
+		a, b := ordered[0], ordered[1]
+
+		there := dg.pathWithin(scc, a, b)
+		back := dg.pathWithin(scc, b, a)
+
+		cycles = append(cycles, append(there, back[1:]...))
+	}
+
+	return cycles
+}
+
+// stronglyConnected uses Tarjan's algorithm to find groups of components that mutually depend on each other. Groups with
+// only one member are only included if that component depends on itself.
+func (dg *dependencyGraph) stronglyConnected() [][]string {
+
+	index := 0
+	indices := make(map[string]int)
+	lowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	stack := make([]string, 0)
+	result := make([][]string, 0)
+
+	var connect func(n string)
+
+	connect = func(n string) {
+		indices[n] = index
+		lowLink[n] = index
+		index++
+
+		stack = append(stack, n)
+		onStack[n] = true
+
+		for _, d := range dg.dependsOn[n] {
+
+			if _, seen := indices[d]; !seen {
+				connect(d)
+				lowLink[n] = intMin(lowLink[n], lowLink[d])
+			} else if onStack[d] {
+				lowLink[n] = intMin(lowLink[n], indices[d])
+			}
+		}
+
+		if lowLink[n] != indices[n] {
+			return
+		}
+
+		scc := make([]string, 0)
+
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+
+			scc = append(scc, last)
+
+			if last == n {
+				break
+			}
+		}
+
+		if len(scc) > 1 || contains(dg.dependsOn[n], n) {
+			sort.Strings(scc)
+			result = append(result, scc)
+		}
+	}
+
+	for _, n := range dg.names() {
+		if _, seen := indices[n]; !seen {
+			connect(n)
+		}
+	}
+
+	return result
+}
+
+// pathWithin finds the shortest path of dependencies from one component to another, only passing through the supplied components.
+func (dg *dependencyGraph) pathWithin(within []string, from, to string) []string {
+
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+
+	for len(queue) > 0 {
+
+		n := queue[0]
+		queue = queue[1:]
+
+		for _, d := range dg.dependsOn[n] {
+
+			if !contains(within, d) {
+				continue
+			}
+
+			if d == to {
+				path := []string{to}
+
+				for c := n; c != ""; c = previous[c] {
+					path = append([]string{c}, path...)
+				}
+
+				return path
+			}
+
+			if _, seen := previous[d]; !seen {
+				previous[d] = n
+				queue = append(queue, d)
+			}
+		}
+	}
+
+	return []string{from, to}
+}
+
+// cycleReport builds a human-readable description of the supplied dependency cycles.
+func cycleReport(cycles [][]string) string {
+
+	var b bytes.Buffer
+
+	b.WriteString("Unable to determine the order in which to start and stop components because of circular dependencies:")
+
+	for _, c := range cycles {
+		b.WriteString(fmt.Sprintf("\n  %s", strings.Join(c, " -> ")))
+	}
+
+	return b.String()
+}
+
+func contains(a []string, s string) bool {
+	for _, e := range a {
+		if e == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func intMin(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
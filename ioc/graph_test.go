@@ -0,0 +1,102 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ioc
+
+import (
+	"github.com/graniticio/granitic/test"
+	"strings"
+	"testing"
+)
+
+type startRecorder struct {
+	Other *startRecorder
+	Plain *plainComponent
+	name  string
+	log   *[]string
+}
+
+func (sr *startRecorder) StartComponent() error {
+	*sr.log = append(*sr.log, sr.name)
+	return nil
+}
+
+type plainComponent struct {
+	Next *startRecorder
+}
+
+func TestStartOrderFollowsDependencies(t *testing.T) {
+
+	cc := newTestContainer(map[string]interface{}{})
+
+	started := make([]string, 0)
+
+	a := &startRecorder{name: "a", log: &started}
+	b := &startRecorder{name: "b", log: &started}
+	c := &startRecorder{name: "c", log: &started}
+
+	pa := CreateProtoComponent(a, "a")
+	pa.AddDependency("Other", "b")
+	cc.AddProto(pa)
+
+	pb := CreateProtoComponent(b, "b")
+	pb.AddDependency("Plain", "p")
+	cc.AddProto(pb)
+
+	pp := CreateProtoComponent(new(plainComponent), "p")
+	pp.AddDependency("Next", "c")
+	cc.AddProto(pp)
+
+	cc.AddProto(CreateProtoComponent(c, "c"))
+
+	test.ExpectNil(t, cc.Populate())
+	test.ExpectNil(t, cc.Lifecycle.StartAll())
+
+	test.ExpectString(t, strings.Join(started, ","), "c,b,a")
+
+	stopOrder := cc.InStopOrder(cc.ByLifecycleSupport(CanStart))
+
+	test.ExpectString(t, stopOrder[0].Name, "a")
+	test.ExpectString(t, stopOrder[2].Name, "c")
+}
+
+func TestCycleBetweenStartableComponents(t *testing.T) {
+
+	cc := newTestContainer(map[string]interface{}{})
+
+	started := make([]string, 0)
+
+	pa := CreateProtoComponent(&startRecorder{name: "a", log: &started}, "a")
+	pa.AddDependency("Plain", "p")
+	cc.AddProto(pa)
+
+	pp := CreateProtoComponent(new(plainComponent), "p")
+	pp.AddDependency("Next", "b")
+	cc.AddProto(pp)
+
+	pb := CreateProtoComponent(&startRecorder{name: "b", log: &started}, "b")
+	pb.AddDependency("Other", "a")
+	cc.AddProto(pb)
+
+	err := cc.Populate()
+
+	test.ExpectNotNil(t, err)
+	test.ExpectBool(t, strings.Contains(err.Error(), "a -> p -> b -> a"), true)
+}
+
+func TestCycleWithSingleStartableAllowed(t *testing.T) {
+
+	cc := newTestContainer(map[string]interface{}{})
+
+	started := make([]string, 0)
+
+	pa := CreateProtoComponent(&startRecorder{name: "a", log: &started}, "a")
+	pa.AddDependency("Plain", "p")
+	cc.AddProto(pa)
+
+	pp := CreateProtoComponent(new(plainComponent), "p")
+	pp.AddDependency("Next", "a")
+	cc.AddProto(pp)
+
+	test.ExpectNil(t, cc.Populate())
+}
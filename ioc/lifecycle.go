@@ -103,7 +103,8 @@ type LifecycleManager struct {
 	system          *instance.System
 }
 
-// StartAll finds all Startable and Accessible components runs the Start/Block/Accessible cycle.
+// StartAll finds all Startable and Accessible components runs the Start/Block/Accessible cycle. Components are started
+// (and made accessible) after any components they depend on.
 func (lm *LifecycleManager) StartAll() error {
 
 	defer func() {
@@ -121,7 +122,8 @@ func (lm *LifecycleManager) StartAll() error {
 
 /*
 	Start starts the supplied components, waits for any access-blocking components to be ready, then makes all
-	components accessible. See GoDoc for Startable, AccessibilityBlocker and Accessible for more details.
+	components accessible. Components are started after any components they depend on. See GoDoc for Startable,
+	AccessibilityBlocker and Accessible for more details.
 */
 func (lm *LifecycleManager) Start(startable []*Component) error {
 
@@ -141,6 +143,9 @@ func (lm *LifecycleManager) Start(startable []*Component) error {
 
 func (lm *LifecycleManager) start(start []*Component, access []*Component) error {
 
+	start = lm.container.InStartOrder(start)
+	access = lm.container.InStartOrder(access)
+
 	for _, component := range start {
 
 		startable := component.Instance.(Startable)
@@ -201,7 +206,7 @@ func (lm *LifecycleManager) waitForBlockers(retestInterval time.Duration, maxTri
 
 }
 
-// StopAll finds all components implementing Stoppable and passes them to Stop
+// StopAll finds all components implementing Stoppable and passes them to StopComponents
 func (lm *LifecycleManager) StopAll() error {
 
 	return lm.StopComponents(lm.container.byLifecycleSupport[CanStop])
@@ -241,10 +246,13 @@ func (lm *LifecycleManager) ResumeComponents(comps []*Component) error {
  calling ReadyToStop on each component. If one or more components are not ready, they are given x chances to become
  ready with y milliseconds between each check. See http://granitic.io/1.0/ref/system-settings
 
- If all components are ready, or if x has been exceeded, Stop is called on all components.
+ If all components are ready, or if x has been exceeded, Stop is called on all components. Components are stopped before
+ any components they depend on.
 */
 func (lm *LifecycleManager) StopComponents(comps []*Component) error {
 
+	comps = lm.container.InStopOrder(comps)
+
 	for _, s := range comps {
 
 		s.Instance.(Stoppable).PrepareToStop()
@@ -0,0 +1,81 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/config"
+	"sort"
+	"strings"
+)
+
+// Retains the configuration promised to singleton components that implement config.ConfigChangeListener so they can be
+// notified if that configuration changes after it has been reloaded.
+func (cc *ComponentContainer) recordConfigPromises() {
+
+	cc.configPromises = make(map[string]map[string]string)
+
+	for name, proto := range cc.protoComponents {
+
+		if len(proto.ConfigPromises) == 0 || cc.Scope(name) != SingletonScope {
+			continue
+		}
+
+		if _, found := proto.Component.Instance.(config.ConfigChangeListener); found {
+			cc.configPromises[name] = proto.ConfigPromises
+		}
+	}
+}
+
+// NotifyConfigChanged calls ConfigChanged on each component implementing config.ConfigChangeListener that has had
+// configuration promised to one of its fields from one of the supplied paths. Components are notified in the order in
+// which they were started. All affected components are notified even if some return errors - any errors are combined
+// and returned.
+func (cc *ComponentContainer) NotifyConfigChanged(changedPaths []string) error {
+
+	affected := make([]*Component, 0)
+	changes := make(map[string][]*config.PromiseChange)
+
+	for name, promises := range cc.configPromises {
+
+		for fieldName, path := range promises {
+
+			if !config.PathAffected(path, changedPaths) {
+				continue
+			}
+
+			pc := new(config.PromiseChange)
+			pc.Field = fieldName
+			pc.Path = path
+
+			changes[name] = append(changes[name], pc)
+		}
+
+		if changes[name] != nil {
+			sort.Slice(changes[name], func(i, j int) bool { return changes[name][i].Field < changes[name][j].Field })
+			affected = append(affected, cc.allComponents[name])
+		}
+	}
+
+	problems := make([]string, 0)
+
+	for _, c := range cc.InStartOrder(affected) {
+
+		cc.FrameworkLogger.LogDebugf("Notifying %s of configuration changes", c.Name)
+
+		l := c.Instance.(config.ConfigChangeListener)
+
+		if err := l.ConfigChanged(changes[c.Name], cc.configAccessor); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", c.Name, err.Error()))
+		}
+	}
+
+	if len(problems) > 0 {
+		m := fmt.Sprintf("Problem applying changed configuration to components:\n%s", strings.Join(problems, "\n"))
+		return errors.New(m)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ioc
+
+import (
+	"github.com/graniticio/granitic/config"
+	"github.com/graniticio/granitic/test"
+	"testing"
+)
+
+type rateLimiter struct {
+	Limit   int
+	Burst   int
+	changes []*config.PromiseChange
+}
+
+func (rl *rateLimiter) ConfigChanged(changes []*config.PromiseChange, ca *config.ConfigAccessor) error {
+	rl.changes = changes
+
+	for _, c := range changes {
+		ca.SetField(c.Field, c.Path, rl)
+	}
+
+	return nil
+}
+
+func TestListenersNotifiedOfPromisedChanges(t *testing.T) {
+
+	conf := map[string]interface{}{"Limits": map[string]interface{}{"Rate": 10.0, "Burst": 5.0}}
+
+	cc := newTestContainer(conf)
+
+	rl := new(rateLimiter)
+
+	p := CreateProtoComponent(rl, "limiter")
+	p.AddConfigPromise("Limit", "Limits.Rate")
+	p.AddConfigPromise("Burst", "Limits.Burst")
+	cc.AddProto(p)
+
+	test.ExpectNil(t, cc.Populate())
+	test.ExpectInt(t, rl.Limit, 10)
+
+	cc.configAccessor.JsonData = map[string]interface{}{"Limits": map[string]interface{}{"Rate": 20.0, "Burst": 5.0}}
+
+	test.ExpectNil(t, cc.NotifyConfigChanged([]string{"Other"}))
+	test.ExpectBool(t, rl.changes == nil, true)
+
+	test.ExpectNil(t, cc.NotifyConfigChanged([]string{"Limits.Rate"}))
+
+	test.ExpectInt(t, len(rl.changes), 1)
+	test.ExpectString(t, rl.changes[0].Field, "Limit")
+	test.ExpectInt(t, rl.Limit, 20)
+}
@@ -0,0 +1,218 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ioc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/reflecttools"
+	"reflect"
+	"sync"
+)
+
+/*
+The scope of a component determines how many instances of that component exist.
+
+	singleton   One instance is shared by every component that depends on it (the default).
+
+	prototype   A new instance is created each time the component is injected into another component or requested with
+	            ComponentContainer.NewInstance.
+
+	request     A new instance is created once per web service request. See RequestComponents.
+
+The instance created from a component definition file is used as a template for prototype and request scoped components.
+New instances are shallow copies of the template after dependencies, configuration and decoration have been applied, so
+maps, slices and pointers are shared between instances. Prototype and request scoped components do not take part in
+lifecycle events (start, stop etc.) and cannot be built by factories.
+*/
+const (
+	SingletonScope = "singleton"
+	PrototypeScope = "prototype"
+	RequestScope   = "request"
+)
+
+// Implemented by request scoped components that need to release resources or complete work when the request that
+// they were created for has been processed.
+type RequestScopeEnder interface {
+	// EndRequestScope is called after the request has been processed and the response written.
+	EndRequestScope(ctx context.Context)
+}
+
+// Implemented by components that are able to create a set of request scoped components for a new request.
+type RequestScopeSource interface {
+	// NewRequestComponents creates an empty set of request scoped components.
+	NewRequestComponents() *RequestComponents
+}
+
+// RequestComponents holds the instances of request scoped components that have been created for a single request.
+// Instances are created the first time they are needed.
+type RequestComponents struct {
+	container *ComponentContainer
+	instances map[string]interface{}
+	created   []string
+	mutex     sync.Mutex
+}
+
+// Component returns the instance of the named component for this request, creating it if required. Singleton and
+// prototype scoped components can also be obtained with this method.
+func (rc *RequestComponents) Component(name string) (interface{}, error) {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	return rc.container.instantiate(name, rc, []string{})
+}
+
+// EndScope calls EndRequestScope on any instances created for this request that implement RequestScopeEnder, in the
+// reverse order to which they were created.
+func (rc *RequestComponents) EndScope(ctx context.Context) {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	for i := len(rc.created) - 1; i >= 0; i-- {
+
+		if e, found := rc.instances[rc.created[i]].(RequestScopeEnder); found {
+			e.EndRequestScope(ctx)
+		}
+	}
+}
+
+type requestComponentsKey struct{}
+
+// WithRequestComponents returns a copy of the supplied context that carries the supplied RequestComponents.
+func WithRequestComponents(ctx context.Context, rc *RequestComponents) context.Context {
+	return context.WithValue(ctx, requestComponentsKey{}, rc)
+}
+
+// RequestComponentsFromContext returns the RequestComponents carried by the supplied context or nil if there are none.
+func RequestComponentsFromContext(ctx context.Context) *RequestComponents {
+
+	rc, _ := ctx.Value(requestComponentsKey{}).(*RequestComponents)
+
+	return rc
+}
+
+// RequestScopedComponent returns the instance of the named component for the request associated with the supplied context.
+// An error is returned if the context does not carry any RequestComponents or if the component does not exist.
+func RequestScopedComponent(ctx context.Context, name string) (interface{}, error) {
+
+	rc := RequestComponentsFromContext(ctx)
+
+	if rc == nil {
+		m := fmt.Sprintf("Unable to find component %s: no request scoped components are associated with this context", name)
+		return nil, errors.New(m)
+	}
+
+	return rc.Component(name)
+}
+
+// NewRequestComponents creates an empty set of request scoped components. See RequestScopeSource
+func (cc *ComponentContainer) NewRequestComponents() *RequestComponents {
+	rc := new(RequestComponents)
+	rc.container = cc
+	rc.instances = make(map[string]interface{})
+
+	return rc
+}
+
+// NewInstance returns a new instance of a prototype scoped component. If the named component is a singleton, the shared
+// instance is returned. Request scoped components must be obtained via RequestComponents.
+func (cc *ComponentContainer) NewInstance(name string) (interface{}, error) {
+	return cc.instantiate(name, nil, []string{})
+}
+
+// Scope returns the scope (SingletonScope, PrototypeScope or RequestScope) of the named component.
+func (cc *ComponentContainer) Scope(name string) string {
+
+	if s := cc.scopes[name]; s != "" {
+		return s
+	}
+
+	return SingletonScope
+}
+
+func (cc *ComponentContainer) instantiate(name string, rc *RequestComponents, chain []string) (interface{}, error) {
+
+	c := cc.allComponents[name]
+
+	if c == nil {
+		m := fmt.Sprintf("No component named %s available", name)
+		return nil, errors.New(m)
+	}
+
+	scope := cc.Scope(name)
+
+	switch scope {
+	case SingletonScope:
+		return c.Instance, nil
+
+	case RequestScope:
+		if rc == nil {
+			m := fmt.Sprintf("Component %s is request scoped and can only be obtained while processing a request", name)
+			return nil, errors.New(m)
+		}
+
+		if i := rc.instances[name]; i != nil {
+			return i, nil
+		}
+
+	case PrototypeScope:
+		if contains(chain, name) {
+			m := fmt.Sprintf("Prototype components have a circular dependency: %v", append(chain, name))
+			return nil, errors.New(m)
+		}
+	}
+
+	chain = append(chain, name)
+
+	instance := shallowCopy(c.Instance)
+
+	if scope == RequestScope {
+		rc.instances[name] = instance
+		rc.created = append(rc.created, name)
+	}
+
+	for fieldName, depName := range cc.scopedDependencies[name] {
+
+		dep, err := cc.instantiate(depName, rc, chain)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := reflecttools.SetPtrToStruct(instance, fieldName, dep); err != nil {
+			m := fmt.Sprintf("Problem injecting dependency '%s' into %s.%s: %s", depName, name, fieldName, err.Error())
+			return nil, errors.New(m)
+		}
+	}
+
+	return instance, nil
+}
+
+// Checks whether a dependency between two components is allowed given their scopes.
+func (cc *ComponentContainer) checkScopes(compName, fieldName, depName string) error {
+
+	cs := cc.Scope(compName)
+	ds := cc.Scope(depName)
+
+	if ds == RequestScope && cs != RequestScope {
+		m := fmt.Sprintf("Component %s is request scoped and cannot be injected into %s.%s (which is %s scoped)", depName, compName, fieldName, cs)
+		return errors.New(m)
+	}
+
+	return nil
+}
+
+func validScope(s string) bool {
+	return s == "" || s == SingletonScope || s == PrototypeScope || s == RequestScope
+}
+
+func shallowCopy(template interface{}) interface{} {
+	v := reflect.ValueOf(template).Elem()
+
+	c := reflect.New(v.Type())
+	c.Elem().Set(v)
+
+	return c.Interface()
+}
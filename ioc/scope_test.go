@@ -0,0 +1,125 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ioc
+
+import (
+	"context"
+	"github.com/graniticio/granitic/test"
+	"strings"
+	"testing"
+)
+
+type unitOfWork struct {
+	Owner  *owner
+	Helper *helper
+	ended  bool
+}
+
+func (u *unitOfWork) EndRequestScope(ctx context.Context) {
+	u.ended = true
+}
+
+type helper struct {
+	Work *unitOfWork
+}
+
+type helperUser struct {
+	Helper *helper
+}
+
+func TestPrototypeInjectedAsNewInstances(t *testing.T) {
+
+	cc := newTestContainer(map[string]interface{}{})
+
+	hp := CreateProtoComponent(new(helper), "helper")
+	hp.Scope = PrototypeScope
+	cc.AddProto(hp)
+
+	a := CreateProtoComponent(new(helperUser), "a")
+	a.AddDependency("Helper", "helper")
+	cc.AddProto(a)
+
+	b := CreateProtoComponent(new(helperUser), "b")
+	b.AddDependency("Helper", "helper")
+	cc.AddProto(b)
+
+	test.ExpectNil(t, cc.Populate())
+
+	ah := cc.ComponentByName("a").Instance.(*helperUser).Helper
+	bh := cc.ComponentByName("b").Instance.(*helperUser).Helper
+
+	test.ExpectNotNil(t, ah)
+	test.ExpectBool(t, ah == bh, false)
+
+	n, err := cc.NewInstance("helper")
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, n.(*helper) == ah, false)
+}
+
+func TestRequestScopedInstancesSharedWithinRequest(t *testing.T) {
+
+	cc := newTestContainer(map[string]interface{}{})
+
+	cc.WrapAndAddProto("owner", &owner{Name: "inventory"})
+
+	up := CreateProtoComponent(new(unitOfWork), "uow")
+	up.Scope = RequestScope
+	up.AddDependency("Owner", "owner")
+	up.AddDependency("Helper", "helper")
+	cc.AddProto(up)
+
+	hp := CreateProtoComponent(new(helper), "helper")
+	hp.Scope = RequestScope
+	hp.AddDependency("Work", "uow")
+	cc.AddProto(hp)
+
+	test.ExpectNil(t, cc.Populate())
+
+	_, err := cc.NewInstance("uow")
+	test.ExpectNotNil(t, err)
+
+	rc := cc.NewRequestComponents()
+	ctx := WithRequestComponents(context.Background(), rc)
+
+	i, err := RequestScopedComponent(ctx, "uow")
+	test.ExpectNil(t, err)
+
+	u := i.(*unitOfWork)
+	test.ExpectString(t, u.Owner.Name, "inventory")
+	test.ExpectBool(t, u.Helper.Work == u, true)
+
+	again, _ := RequestScopedComponent(ctx, "uow")
+	test.ExpectBool(t, again.(*unitOfWork) == u, true)
+
+	other, _ := cc.NewRequestComponents().Component("uow")
+	test.ExpectBool(t, other.(*unitOfWork) == u, false)
+
+	rc.EndScope(ctx)
+	test.ExpectBool(t, u.ended, true)
+}
+
+func TestSingletonCannotDependOnRequestScoped(t *testing.T) {
+
+	cc := newTestContainer(map[string]interface{}{})
+
+	hp := CreateProtoComponent(new(helper), "helper")
+	hp.Scope = RequestScope
+	cc.AddProto(hp)
+
+	a := CreateProtoComponent(new(helperUser), "a")
+	a.AddDependency("Helper", "helper")
+	cc.AddProto(a)
+
+	cc.allComponents = make(map[string]*Component)
+	test.ExpectNil(t, cc.recordScopes())
+
+	for _, p := range cc.protoComponents {
+		cc.addComponent(p.Component)
+	}
+
+	err := cc.resolveDependenciesAndConfig(map[string]ComponentDecorator{}, map[string]bool{})
+
+	test.ExpectNotNil(t, err)
+	test.ExpectBool(t, strings.Contains(err.Error(), "request scoped"), true)
+}
@@ -0,0 +1,43 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"mime/multipart"
+)
+
+// A file uploaded as part of a multipart/form-data request body. Fields of type *FilePart or []*FilePart on a
+// WsRequest.RequestBody are populated with the files uploaded under a form field with the same name as the field (see
+// form.FormUnmarshaller).
+//
+// The contents of a file are only available while the request is being processed.
+type FilePart struct {
+	// The name of the file as supplied by the caller.
+	FileName string
+
+	// The media type of the file as declared by the caller.
+	ContentType string
+
+	// The size of the file in bytes.
+	Size int64
+
+	header *multipart.FileHeader
+}
+
+// NewFilePart creates a FilePart for a file parsed from a multipart request body.
+func NewFilePart(fh *multipart.FileHeader) *FilePart {
+
+	fp := new(FilePart)
+	fp.FileName = fh.Filename
+	fp.ContentType = fh.Header.Get(ContentTypeHeader)
+	fp.Size = fh.Size
+	fp.header = fh
+
+	return fp
+}
+
+// Open returns a reader of the file's contents. The caller is responsible for closing the file.
+func (fp *FilePart) Open() (multipart.File, error) {
+	return fp.header.Open()
+}
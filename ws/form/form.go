@@ -0,0 +1,241 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+	Package form defines a WsUnmarshaller able to bind the fields of HTML-style forms (application/x-www-form-urlencoded
+	and multipart/form-data request bodies) onto the request body of a web service handler.
+
+	Binding
+
+	Each form field is bound onto the field of the handler's UnmarshallTarget with exactly the same name using the same
+	type conversion rules (including support for the nilable types in the types package) as query parameter binding (see
+	ws.ParamBinder). Bound fields are recorded with ws.WsRequest.RecordFieldAsBound. Values that cannot be converted are
+	recorded as framework errors and result in a 400 response unless the handler defers framework errors.
+
+	Files
+
+	Files uploaded in a multipart/form-data body are bound onto fields of type *ws.FilePart (or []*ws.FilePart if more than
+	one file may be uploaded under the same name) with the same name as the file's form field. Files larger than
+	MaxFileBytes are recorded as framework errors. The limit is applied while the request body is read, so no more than
+	MaxFileBytes of a file is held in memory or written to temporary storage.
+
+	Using forms
+
+	If the JsonWs or XmlWs facility is enabled with its BindForms setting set to true, form bodies are unmarshalled
+	automatically alongside JSON or XML bodies and the facility's FormUnmarshaller is configured with the
+	FormUnmarshaller block in configuration (see the facility/ws package documentation). A handler that needs different limits can declare its own FormUnmarshaller component and reference it
+	(note that the handler will then only accept form bodies):
+
+		"uploadUnmarshaller": {
+		  "type": "form.FormUnmarshaller",
+		  "MaxFileBytes": 1048576
+		},
+
+		"uploadHandler": {
+		  "type": "handler.WsHandler",
+		  "HttpMethod": "POST",
+		  "Logic": "ref:uploadLogic",
+		  "PathPattern": "^/upload$",
+		  "Unmarshaller": "ref:uploadUnmarshaller"
+		}
+
+	The FormUnmarshaller's ParamBinder is injected automatically by the JsonWs and XmlWs facilities.
+*/
+package form
+
+import (
+	"context"
+	"github.com/graniticio/granitic/logging"
+	"github.com/graniticio/granitic/ws"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+)
+
+const (
+	// The media type of URL encoded form bodies.
+	URLEncodedMediaType = "application/x-www-form-urlencoded"
+
+	// The media type of multipart form bodies.
+	MultipartMediaType = "multipart/form-data"
+
+	// The number of bytes of a multipart form held in memory (the remainder is stored in temporary files) if MaxMemoryBytes is not set.
+	DefaultMaxMemoryBytes = 32 << 20
+)
+
+var filePartType = reflect.TypeOf(new(ws.FilePart))
+
+// A WsUnmarshaller that binds the fields (and files) of application/x-www-form-urlencoded and multipart/form-data request
+// bodies onto a WsRequest.RequestBody.
+type FormUnmarshaller struct {
+	// Injected by Granitic
+	FrameworkLogger logging.Logger
+
+	// Used to convert form values and bind them onto the request body. Injected automatically by the JsonWs and XmlWs facilities.
+	ParamBinder *ws.ParamBinder
+
+	// The number of bytes of a multipart form held in memory. The remainder is stored in temporary files which are removed
+	// when the request is complete. Defaults to DefaultMaxMemoryBytes.
+	MaxMemoryBytes int64
+
+	// The maximum size of an individual uploaded file. The remainder of a larger file is discarded as it is read. Zero
+	// means no limit other than that of the request body (see HttpServer.MaxRequestBodyBytes and WsHandler.MaxBodyBytes)
+	MaxFileBytes int64
+}
+
+// Unmarshall parses the form in the request body and binds its fields and files onto wsReq.RequestBody.
+func (fu *FormUnmarshaller) Unmarshall(ctx context.Context, req *http.Request, wsReq *ws.WsRequest) error {
+
+	mt, _, _ := mime.ParseMediaType(req.Header.Get(ws.ContentTypeHeader))
+
+	if mt == MultipartMediaType {
+		return fu.unmarshallMultipart(req, wsReq)
+	}
+
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	fu.ParamBinder.BindFormParameters(wsReq, ws.NewWsParamsForQuery(req.PostForm))
+
+	return nil
+}
+
+func (fu *FormUnmarshaller) unmarshallMultipart(req *http.Request, wsReq *ws.WsRequest) error {
+
+	mm := fu.MaxMemoryBytes
+
+	if mm <= 0 {
+		mm = DefaultMaxMemoryBytes
+	}
+
+	mf, err := fu.readMultipart(req, mm)
+
+	if err != nil {
+		return err
+	}
+
+	// The server removes any temporary files created for the form when the request is complete
+	req.MultipartForm = mf
+
+	fu.ParamBinder.BindFormParameters(wsReq, ws.NewWsParamsForQuery(mf.Value))
+
+	for field, headers := range mf.File {
+		fu.bindFiles(wsReq, field, headers)
+	}
+
+	return nil
+}
+
+// Parses a multipart form, truncating files larger than MaxFileBytes to MaxFileBytes+1 bytes as the body is read (so
+// that they are still detected as too large by bindFiles without being stored in full).
+func (fu *FormUnmarshaller) readMultipart(req *http.Request, maxMemory int64) (*multipart.Form, error) {
+
+	mr, err := req.MultipartReader()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if fu.MaxFileBytes <= 0 {
+		return mr.ReadForm(maxMemory)
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	boundary := mw.Boundary()
+
+	go func() {
+		pw.CloseWithError(copyLimitedParts(mr, mw, fu.MaxFileBytes))
+	}()
+
+	mf, err := multipart.NewReader(pr, boundary).ReadForm(maxMemory)
+
+	// Stops the copy if the form could not be read
+	pr.Close()
+
+	return mf, err
+}
+
+// Copies each part read by mr to mw, copying no more than limit+1 bytes of each file.
+func copyLimitedParts(mr *multipart.Reader, mw *multipart.Writer, limit int64) error {
+
+	for {
+
+		p, err := mr.NextPart()
+
+		if err == io.EOF {
+			return mw.Close()
+		} else if err != nil {
+			return err
+		}
+
+		w, err := mw.CreatePart(p.Header)
+
+		if err != nil {
+			return err
+		}
+
+		var r io.Reader = p
+
+		if p.FileName() != "" {
+			// The unread remainder of the part is discarded by the next call to NextPart
+			r = io.LimitReader(p, limit+1)
+		}
+
+		if _, err := io.Copy(w, r); err != nil {
+			return err
+		}
+	}
+}
+
+// Binds uploaded files onto a field of type *ws.FilePart or []*ws.FilePart with the same name as the form field.
+func (fu *FormUnmarshaller) bindFiles(wsReq *ws.WsRequest, field string, headers []*multipart.FileHeader) {
+
+	t := reflect.ValueOf(wsReq.RequestBody).Elem()
+	f := t.FieldByName(field)
+
+	if !f.IsValid() || !f.CanSet() {
+		fu.FrameworkLogger.LogTracef("No field %s to bind uploaded files into", field)
+		return
+	}
+
+	single := f.Type() == filePartType
+	multiple := f.Kind() == reflect.Slice && f.Type().Elem() == filePartType
+
+	if !single && !multiple {
+		fu.FrameworkLogger.LogTracef("Field %s is not of type *ws.FilePart or []*ws.FilePart", field)
+		return
+	}
+
+	fe := fu.ParamBinder.FrameworkErrors
+
+	if single && len(headers) > 1 {
+		m, c := fe.MessageCode(ws.FormTargetNotArray, field)
+		wsReq.AddFrameworkError(ws.NewFormBindFrameworkError(m, c, field, field))
+		return
+	}
+
+	parts := reflect.MakeSlice(reflect.SliceOf(filePartType), 0, len(headers))
+
+	for _, h := range headers {
+
+		if fu.MaxFileBytes > 0 && h.Size > fu.MaxFileBytes {
+			m, c := fe.MessageCode(ws.FileTooLarge, field, fu.MaxFileBytes)
+			wsReq.AddFrameworkError(ws.NewFormBindFrameworkError(m, c, field, field))
+			return
+		}
+
+		parts = reflect.Append(parts, reflect.ValueOf(ws.NewFilePart(h)))
+	}
+
+	if single {
+		f.Set(parts.Index(0))
+	} else {
+		f.Set(parts)
+	}
+
+	wsReq.RecordFieldAsBound(field)
+}
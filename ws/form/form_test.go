@@ -0,0 +1,201 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package form
+
+import (
+	"bytes"
+	"context"
+	"github.com/graniticio/granitic/logging"
+	"github.com/graniticio/granitic/test"
+	"github.com/graniticio/granitic/types"
+	"github.com/graniticio/granitic/ws"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type formTarget struct {
+	Name     string
+	Age      int
+	Nick     *types.NilableString
+	Score    *types.NilableFloat64
+	Avatar   *ws.FilePart
+	Attached []*ws.FilePart
+}
+
+func newFormUnmarshaller() *FormUnmarshaller {
+
+	fl := new(logging.ConsoleErrorLogger)
+
+	feg := new(ws.FrameworkErrorGenerator)
+	feg.FrameworkLogger = fl
+	feg.Messages = map[ws.FrameworkErrorEvent][]string{
+		ws.FormWrongType:      {"FORMBIND", "Field %s is not a %s (%s)"},
+		ws.FormTargetNotArray: {"FORMBIND", "Field %s has multiple values"},
+		ws.FileTooLarge:       {"FORMBIND", "File %s is larger than %d bytes"},
+	}
+
+	pb := new(ws.ParamBinder)
+	pb.FrameworkLogger = fl
+	pb.FrameworkErrors = feg
+
+	fu := new(FormUnmarshaller)
+	fu.FrameworkLogger = fl
+	fu.ParamBinder = pb
+
+	return fu
+}
+
+func TestURLEncodedBinding(t *testing.T) {
+
+	fu := newFormUnmarshaller()
+
+	req := httptest.NewRequest("POST", "/?Age=99", strings.NewReader("Name=n&Age=42&Nick=&Unknown=u"))
+	req.Header.Set(ws.ContentTypeHeader, URLEncodedMediaType)
+
+	ft := new(formTarget)
+	wsReq := &ws.WsRequest{RequestBody: ft}
+
+	test.ExpectNil(t, fu.Unmarshall(context.Background(), req, wsReq))
+	test.ExpectBool(t, wsReq.HasFrameworkErrors(), false)
+
+	test.ExpectString(t, ft.Name, "n")
+	test.ExpectInt(t, ft.Age, 42)
+	test.ExpectBool(t, ft.Nick.IsSet(), true)
+	test.ExpectBool(t, ft.Score.IsSet(), false)
+
+	test.ExpectBool(t, wsReq.WasFieldBound("Name"), true)
+	test.ExpectBool(t, wsReq.WasFieldBound("Score"), false)
+}
+
+func TestURLEncodedBindingErrors(t *testing.T) {
+
+	fu := newFormUnmarshaller()
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("Name=a&Name=b&Age=old"))
+	req.Header.Set(ws.ContentTypeHeader, URLEncodedMediaType)
+
+	wsReq := &ws.WsRequest{RequestBody: new(formTarget)}
+
+	test.ExpectNil(t, fu.Unmarshall(context.Background(), req, wsReq))
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 2)
+
+	for _, fe := range wsReq.FrameworkErrors {
+		test.ExpectInt(t, int(fe.Phase), ws.FormBind)
+		test.ExpectString(t, fe.Code, "FORMBIND")
+	}
+}
+
+func TestMultipartBinding(t *testing.T) {
+
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+
+	mw.WriteField("Name", "n")
+	mw.WriteField("Score", "1.5")
+
+	fw, _ := mw.CreateFormFile("Avatar", "me.png")
+	fw.Write([]byte("png"))
+
+	for _, n := range []string{"a.txt", "b.txt"} {
+		fw, _ = mw.CreateFormFile("Attached", n)
+		fw.Write([]byte("contents of " + n))
+	}
+
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/", bytes.NewReader(b.Bytes()))
+	req.Header.Set(ws.ContentTypeHeader, mw.FormDataContentType())
+
+	fu := newFormUnmarshaller()
+	ft := new(formTarget)
+	wsReq := &ws.WsRequest{RequestBody: ft}
+
+	test.ExpectNil(t, fu.Unmarshall(context.Background(), req, wsReq))
+	test.ExpectBool(t, wsReq.HasFrameworkErrors(), false)
+
+	test.ExpectString(t, ft.Name, "n")
+	test.ExpectFloat(t, ft.Score.Float64(), 1.5)
+
+	test.ExpectNotNil(t, ft.Avatar)
+	test.ExpectString(t, ft.Avatar.FileName, "me.png")
+	test.ExpectInt(t, int(ft.Avatar.Size), 3)
+	test.ExpectBool(t, wsReq.WasFieldBound("Avatar"), true)
+
+	test.ExpectInt(t, len(ft.Attached), 2)
+
+	f, err := ft.Attached[1].Open()
+	test.ExpectNil(t, err)
+
+	contents, _ := ioutil.ReadAll(f)
+	f.Close()
+
+	test.ExpectString(t, string(contents), "contents of b.txt")
+
+	req = httptest.NewRequest("POST", "/", bytes.NewReader(b.Bytes()))
+	req.Header.Set(ws.ContentTypeHeader, mw.FormDataContentType())
+
+	fu.MaxFileBytes = 10
+	ft = new(formTarget)
+	wsReq = &ws.WsRequest{RequestBody: ft}
+
+	test.ExpectNil(t, fu.Unmarshall(context.Background(), req, wsReq))
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 1)
+	test.ExpectString(t, wsReq.FrameworkErrors[0].ClientField, "Attached")
+	test.ExpectString(t, wsReq.FrameworkErrors[0].Message, "File Attached is larger than 10 bytes")
+	test.ExpectNotNil(t, ft.Avatar)
+
+	// Only one byte more than the limit of an oversized file is read into the form
+	test.ExpectInt(t, int(req.MultipartForm.File["Attached"][0].Size), 11)
+}
+
+func TestOversizedFileNotStored(t *testing.T) {
+
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+
+	fw, _ := mw.CreateFormFile("Avatar", "large.png")
+	fw.Write(bytes.Repeat([]byte("x"), 1<<20))
+
+	mw.WriteField("Name", "n")
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/", bytes.NewReader(b.Bytes()))
+	req.Header.Set(ws.ContentTypeHeader, mw.FormDataContentType())
+
+	fu := newFormUnmarshaller()
+	fu.MaxFileBytes = 1024
+	fu.MaxMemoryBytes = 4096
+
+	ft := new(formTarget)
+	wsReq := &ws.WsRequest{RequestBody: ft}
+
+	test.ExpectNil(t, fu.Unmarshall(context.Background(), req, wsReq))
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 1)
+	test.ExpectString(t, ft.Name, "n")
+	test.ExpectBool(t, ft.Avatar == nil, true)
+
+	fh := req.MultipartForm.File["Avatar"][0]
+	test.ExpectInt(t, int(fh.Size), 1025)
+
+	f, err := fh.Open()
+	test.ExpectNil(t, err)
+
+	contents, _ := ioutil.ReadAll(f)
+	f.Close()
+
+	test.ExpectInt(t, len(contents), 1025)
+	test.ExpectNil(t, req.MultipartForm.RemoveAll())
+
+	// Limits on the size of the request body still apply
+	req = httptest.NewRequest("POST", "/", bytes.NewReader(b.Bytes()))
+	req.Header.Set(ws.ContentTypeHeader, mw.FormDataContentType())
+	req.Body = http.MaxBytesReader(nil, req.Body, 2048)
+
+	err = fu.Unmarshall(context.Background(), req, &ws.WsRequest{RequestBody: new(formTarget)})
+	test.ExpectBool(t, ws.IsBodyTooLarge(err), true)
+}
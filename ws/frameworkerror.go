@@ -21,6 +21,15 @@ const (
 
 	// Error encountered while mapping elements of an HTTP request's path to fields on a struct
 	PathBind
+
+	// Error encountered while mapping the fields of a form (or the files of a multipart form) to fields on a struct
+	FormBind
+
+	// Error encountered while mapping HTTP request headers to fields on a struct
+	HeaderBind
+
+	// Error encountered while mapping HTTP cookies to fields on a struct
+	CookieBind
 )
 
 // An error encountered in early phases of request processing, before application code is invoked.
@@ -83,6 +92,45 @@ func NewPathBindFrameworkError(message, code, target string) *WsFrameworkError {
 	return f
 }
 
+// NewFormBindFrameworkError creates a WsFrameworkError with fields set appropriate for an error
+// encountered during mapping of the fields in a form request body to fields on a WsRequest's Body.
+func NewFormBindFrameworkError(message, code, field, target string) *WsFrameworkError {
+	f := new(WsFrameworkError)
+	f.Phase = FormBind
+	f.Message = message
+	f.ClientField = field
+	f.TargetField = target
+	f.Code = code
+
+	return f
+}
+
+// NewHeaderBindFrameworkError creates a WsFrameworkError with fields set appropriate for an error
+// encountered during mapping of HTTP request headers to fields on a WsRequest's Body.
+func NewHeaderBindFrameworkError(message, code, header, target string) *WsFrameworkError {
+	f := new(WsFrameworkError)
+	f.Phase = HeaderBind
+	f.Message = message
+	f.ClientField = header
+	f.TargetField = target
+	f.Code = code
+
+	return f
+}
+
+// NewCookieBindFrameworkError creates a WsFrameworkError with fields set appropriate for an error
+// encountered during mapping of HTTP cookies to fields on a WsRequest's Body.
+func NewCookieBindFrameworkError(message, code, cookie, target string) *WsFrameworkError {
+	f := new(WsFrameworkError)
+	f.Phase = CookieBind
+	f.Message = message
+	f.ClientField = cookie
+	f.TargetField = target
+	f.Code = code
+
+	return f
+}
+
 // Uniquely identifies a 'handled' failure during the parsing and binding phases
 type FrameworkErrorEvent string
 
@@ -92,6 +140,11 @@ const (
 	QueryWrongType       = "QueryWrongType"
 	PathWrongType        = "PathWrongType"
 	QueryNoTargetField   = "QueryNoTargetField"
+	FormTargetNotArray   = "FormTargetNotArray"
+	FormWrongType        = "FormWrongType"
+	FileTooLarge         = "FileTooLarge"
+	HeaderWrongType      = "HeaderWrongType"
+	CookieWrongType      = "CookieWrongType"
 )
 
 // A FrameworkErrorGenerator can create error messages for errors that occur outside of application code and messages
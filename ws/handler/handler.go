@@ -27,7 +27,8 @@
 
 	Each handler must have the following before it is considered a valid web service endpoint.
 
-	1. A regular expression that will be matched against the path component of incoming HTTP requests.
+	1. A regular expression (PathPattern) or path template (PathTemplate) that will be matched against the path component
+	of incoming HTTP requests.
 
 	2. A single HTTP method that it will be responsible for handling. This is generally GET, POST, PUT or DELETE but any
 	standard or custom HTTP method can be used.
@@ -35,12 +36,150 @@
 	3. A 'logic' component that implements at least WsRequestProcessor (additional WsXXX interfaces can be implemented
 	to support advanced behaviour).
 
+	Path templates
+
+	Instead of a regular expression, a handler can declare a simpler path template:
+
+		{
+		  "artistHandler": {
+			"type": "handler.WsHandler",
+			"HttpMethod": "GET",
+			"Logic": "ref:artistLogic",
+			"PathTemplate": "/artist/{id:int}"
+		  }
+		}
+
+	Templates are matched more quickly than regular expressions and, when more than one handler could handle a request,
+	the handler with the most specific template is chosen. See httpendpoint.PathTemplate for the supported syntax. Each
+	parameter in a template is treated as a named group in the equivalent regular expression, so path parameters work in
+	the same way for both.
+
+	Path parameters
+
+	Values captured by groups in a PathPattern (or parameters in a PathTemplate) are available to Logic components in
+	order via ws.WsRequest.PathParams and, if the group is named (e.g. (?P<id>\d+)), by name via
+	ws.WsRequest.NamedPathParams. Values can be bound onto fields of the request body by position (BindPathParams), by
+	name (FieldPathParam, a map of field names to parameter names) or onto fields with the same name as the parameter
+	(AutoBindPath):
+
+		{
+		  "artistHandler": {
+			"type": "handler.WsHandler",
+			"HttpMethod": "GET",
+			"Logic": "ref:artistLogic",
+			"PathTemplate": "/artist/{id:int}",
+			"FieldPathParam": {"ArtistID": "id"}
+		  }
+		}
+
+	Values that cannot be converted to the type of their target field are recorded as PathWrongType framework errors,
+	which are given the name of the parameter (rather than the position of its group).
+
+	Headers and cookies
+
+	HTTP request headers and cookies can be bound onto fields of the request body with FieldHeader and FieldCookie (maps
+	of field names to header or cookie names) or with binding tags (see below). Values that cannot be converted to the type
+	of their target field are recorded as HeaderWrongType or CookieWrongType framework errors.
+
+	Binding tags
+
+	As an alternative to FieldQueryParam, FieldPathParam, FieldHeader and FieldCookie, the fields of the Logic's
+	UnmarshallTarget type can declare where their values are bound from with a ws tag:
+
+		type ArtistSearchRequest struct {
+			ArtistID int                  `ws:"path=id"`
+			PageSize *types.NilableInt64  `ws:"query=page_size"`
+			Tenant   string               `ws:"header=X-Tenant-Id"`
+			Session  *types.NilableString `ws:"cookie=session"`
+		}
+
+	Tags on the fields of nested structs (or pointers to structs) are also honoured and bind into the nested field, allocating
+	nil struct pointers as needed. Tags are checked when the handler starts. A handler will not start if a tag is malformed, is on a field of a type that
+	cannot be bound, names a path parameter that does not exist or binds a field that is also bound by one of the maps above.
+
+	Parameter types
+
+	Query and path parameters, headers and cookies can be bound onto fields of Go's int, uint, float, bool and string types,
+	the nilable types in the types package, time.Time and time.Duration. Fields that are slices of any of these types
+	(other than the nilable types) are populated with every value of a repeated parameter (?tag=a&tag=b) and with each part
+	of a comma separated value (?tag=a,b). Times are parsed using the layouts in the ParamBinder.TimeLayouts
+	configuration setting and durations with time.ParseDuration.
+
+	The field names used in FieldQueryParam and FieldPathParam (and the names of auto-bound query parameters) may be
+	dot-delimited paths to fields on nested structs, e.g.
+
+		"FieldQueryParam": {"Filter.Status": "filter.status"}
+
+	Request scoped components
+
+	Components declared with "scope": "request" (see the ioc package documentation) can be created once per request by
+	listing their names in the handler's RequestScoped field:
+
+		{
+		  "artistHandler": {
+			"type": "handler.WsHandler",
+			"HttpMethod": "POST",
+			"Logic": "ref:artistLogic",
+			"PathPattern": "^/artist$",
+			"RequestScoped": ["unitOfWork"]
+		  }
+		}
+
+	The instances are available to the handler's Logic (and anything it passes the context to) for the lifetime of a single
+	call to ServeHttp via ioc.RequestScopedComponent(ctx, "unitOfWork"). Request scoped components implementing
+	ioc.RequestScopeEnder are notified once the response has been written.
+
+	CORS
+
+	Cross-origin requests are controlled by the HttpServer facility's CORS policy (HttpServer.CORS in configuration). A
+	handler can use a different policy by referencing a component of type httpendpoint.CORSPolicy:
+
+		{
+		  "publicCors": {
+			"type": "httpendpoint.CORSPolicy",
+			"Enabled": true,
+			"AllowedOrigins": ["https://*.example.com"],
+			"MaxAgeSeconds": 600
+		  },
+
+		  "artistHandler": {
+			"type": "handler.WsHandler",
+			"HttpMethod": "GET",
+			"Logic": "ref:artistLogic",
+			"PathTemplate": "/artist/{id:int}",
+			"CORS": "ref:publicCors"
+		  }
+		}
+
+	Request bodies
+
+	The size of request bodies is limited by the HttpServer facility's MaxRequestBodyBytes setting, which a handler can
+	override with MaxBodyBytes. Requests with larger bodies receive a 413 response. Handlers that accept uploads or other
+	large bodies can set StreamBody to true, in which case the body is not unmarshalled but is available to the handler's
+	Logic as ws.WsRequest.BodyReader (reading past the limit returns an error for which ws.IsBodyTooLarge returns true).
+
+	Content negotiation
+
+	If both the JsonWs and XmlWs facilities are enabled (or either facility's BindForms setting is true), handlers are
+	given a ws.NegotiatingUnmarshaller, so the format of a request body (including HTML forms) is chosen by its
+	Content-Type header. If both facilities are enabled, handlers are also given a ws.NegotiatingResponseWriter, so the
+	format of the response is chosen by the request's Accept header. Requests with a body in an unsupported format receive
+	a 415 response and requests that accept none of the supported formats receive a 406 response (before the handler's
+	Logic is invoked).
+
+	Rate limits
+
+	Handlers are subject to the HttpServer facility's rate limit policy (HttpServer.RateLimit in configuration). A handler
+	can use a different policy (or, with a policy that is not enabled, no limit at all) by referencing a component of type
+	httpendpoint.RateLimitPolicy in its RateLimit field.
+
 */
 package handler
 
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/graniticio/granitic/httpendpoint"
 	"github.com/graniticio/granitic/iam"
 	"github.com/graniticio/granitic/ioc"
@@ -122,6 +261,9 @@ type WsHandler struct {
 	// Whether or not the underlying HTTP request and response writer should be made available to request Logic.
 	AllowDirectHTTPAccess bool
 
+	// Whether or not named path parameters should be automatically injected into request body fields with the same name.
+	AutoBindPath bool
+
 	// Whether or not query parameters should be automatically injected into the request body.
 	AutoBindQuery bool
 
@@ -131,6 +273,9 @@ type WsHandler struct {
 	// A list of field names on the target object into which path parameters (groups in the request regex) should be bound to.
 	BindPathParams []string
 
+	// A CORS policy to use for this handler instead of the server's policy. See httpendpoint.CORSPolicy
+	CORS *httpendpoint.CORSPolicy
+
 	// Check caller's permissions after request has been parsed (true) or before parsing (false).
 	CheckAccessAfterParse bool
 
@@ -149,6 +294,16 @@ type WsHandler struct {
 	// An object that provides access to application defined error messages for use during validation.
 	ErrorFinder ws.ServiceErrorFinder
 
+	// A map of fields on the request body object and the names of cookies that should be used to populate them
+	FieldCookie map[string]string
+
+	// A map of fields on the request body object and the names of HTTP request headers that should be used to populate them
+	FieldHeader map[string]string
+
+	// A map of fields on the request body object and the names of path parameters (named regex groups or template
+	// parameters) that should be used to populate them
+	FieldPathParam map[string]string
+
 	// A map of fields on the request body object and the names of query parameters that should be used to populate them
 	FieldQueryParam map[string]string
 
@@ -158,6 +313,10 @@ type WsHandler struct {
 	// The HTTP method (GET, POST etc) that this handler supports.
 	HttpMethod string
 
+	// The names of the HTTP servers this handler should be registered with (see httpserver.HttpServerFacilityBuilder).
+	// If not set, the handler is only registered with the default server.
+	HttpServers []string
+
 	// A logger injected by the Granitic framework. Note this will be an application logger rather than a framework logger
 	// as instances of WsHandler are considered application components.
 	Log logging.Logger
@@ -165,6 +324,10 @@ type WsHandler struct {
 	// The object representing the 'logic' behind this handler.
 	Logic WsRequestProcessor
 
+	// The largest request body (in bytes) this handler accepts. Zero means the HTTP server's limit is used and a negative
+	// value means there is no limit. See httpserver.HttpServer.MaxRequestBodyBytes
+	MaxBodyBytes int
+
 	// A component injected by the Granitic framework that can map text representations of query and path parameters to Go
 	// and Granitic types.
 	ParamBinder *ws.ParamBinder
@@ -172,6 +335,9 @@ type WsHandler struct {
 	// A regex that will be matched against inbound request paths to check if this handler should be used to service the request.
 	PathPattern string
 
+	// A path template (e.g. /artist/{id:int}) that can be set instead of PathPattern.
+	PathTemplate string
+
 	// A component that might want to modify a response after it has been processed by the supplied Logic component.
 	PostProcessor WsPostProcessor
 
@@ -181,12 +347,21 @@ type WsHandler struct {
 	// Stop the framework automatically adding this handler to an HTTP server.
 	PreventAutoWiring bool
 
+	// A rate limit policy to use for this handler instead of the server's policy. See httpendpoint.RateLimitPolicy
+	RateLimit *httpendpoint.RateLimitPolicy
+
 	// A component injected by the Granitic framework that writes the response from this handler to an HTTP response.
 	ResponseWriter ws.WsResponseWriter
 
 	// Whether on not the caller needs to be authenticated (using a ws.WsIdentifier) in order to access the logic behind this handler.
 	RequireAuthentication bool
 
+	// The names of request scoped components that should be created at the start of each request and made available via the request's context.
+	RequestScoped []string
+
+	// If true, the request body is not unmarshalled but made available to Logic as ws.WsRequest.BodyReader.
+	StreamBody bool
+
 	// A component injected by the Granitic framework that can extract the body of the incoming HTTP request into a Go struct.
 	Unmarshaller ws.WsUnmarshaller
 
@@ -196,9 +371,12 @@ type WsHandler struct {
 	// A component that can check if this handler supports the version of functionality required by the caller.
 	VersionAssessor   WsVersionAssessor
 	bindPathParams    bool
+	bindNamedParams   bool
 	bindQuery         bool
+	bindTagged        bool
 	httpMethods       []string
 	componentName     string
+	container         *ioc.ComponentContainer
 	pathRegex         *regexp.Regexp
 	state             ioc.ComponentState
 	validationEnabled bool
@@ -224,6 +402,20 @@ func (wh *WsHandler) ServeHttp(ctx context.Context, w *httpendpoint.HttpResponse
 		}
 	}()
 
+	if len(wh.RequestScoped) > 0 {
+
+		rc := wh.container.NewRequestComponents()
+		ctx = ioc.WithRequestComponents(ctx, rc)
+
+		defer rc.EndScope(ctx)
+
+		if !wh.createRequestScoped(ctx, rc, w) {
+			return ctx
+		}
+	}
+
+	ctx = ws.WithAccept(ctx, req.Header.Get(ws.AcceptHeader))
+
 	wsReq := new(ws.WsRequest)
 	wsReq.HttpMethod = req.Method
 	wsReq.ServingHandler = wh.ComponentName()
@@ -249,10 +441,21 @@ func (wh *WsHandler) ServeHttp(ctx context.Context, w *httpendpoint.HttpResponse
 		return ctx
 	}
 
+	//Check a response can be written in a format the caller accepts
+	if ac, found := wh.ResponseWriter.(ws.AcceptChecker); found && !ac.Acceptable(ctx) {
+		wh.ResponseWriter.Write(ctx, ws.NewAbnormalState(http.StatusNotAcceptable, w), ws.Abnormal)
+		return ctx
+	}
+
 	//Unmarshall body, query parameters and path parameters
-	wh.unmarshall(ctx, req, wsReq)
+	if status := wh.unmarshall(ctx, req, wsReq); status != 0 {
+		wh.writeHttpError(ctx, w, wsReq, status)
+		return ctx
+	}
+
 	wh.processQueryParams(ctx, req, wsReq)
-	wh.processPathParams(req, wsReq)
+	wh.processPathParams(ctx, req, wsReq)
+	wh.processTagsHeadersAndCookies(req, wsReq)
 
 	if wsReq.HasFrameworkErrors() && !wh.DeferFrameworkErrors {
 		wh.handleFrameworkErrors(ctx, w, wsReq)
@@ -282,6 +485,24 @@ func (wh *WsHandler) ServeHttp(ctx context.Context, w *httpendpoint.HttpResponse
 	return ctx
 }
 
+func (wh *WsHandler) createRequestScoped(ctx context.Context, rc *ioc.RequestComponents, w *httpendpoint.HttpResponseWriter) bool {
+
+	for _, name := range wh.RequestScoped {
+
+		if _, err := rc.Component(name); err != nil {
+
+			wh.Log.LogErrorfCtx(ctx, "Unable to create request scoped component: %s", err.Error())
+
+			state := ws.NewAbnormalState(http.StatusInternalServerError, w)
+			wh.ResponseWriter.Write(ctx, state, ws.Abnormal)
+
+			return false
+		}
+	}
+
+	return true
+}
+
 func (wh *WsHandler) validateRequest(ctx context.Context, wsReq *ws.WsRequest, errors *ws.ServiceErrors) {
 	if wh.validationEnabled {
 		proceed := true
@@ -344,7 +565,14 @@ func (wh *WsHandler) validateRequest(ctx context.Context, wsReq *ws.WsRequest, e
 
 }
 
-func (wh *WsHandler) unmarshall(ctx context.Context, req *http.Request, wsReq *ws.WsRequest) {
+// Unmarshalls the request body (if the handler's Logic provides a target), or makes the body available as a reader if
+// the handler streams request bodies. Returns a non-zero HTTP status if the body is larger than the limit set by the
+// server or handler (413) or is in a format the handler's Unmarshaller does not support (415).
+func (wh *WsHandler) unmarshall(ctx context.Context, req *http.Request, wsReq *ws.WsRequest) int {
+
+	if wh.StreamBody {
+		wsReq.BodyReader = req.Body
+	}
 
 	targetSource, found := wh.Logic.(WsUnmarshallTarget)
 
@@ -352,14 +580,22 @@ func (wh *WsHandler) unmarshall(ctx context.Context, req *http.Request, wsReq *w
 		target := targetSource.UnmarshallTarget()
 		wsReq.RequestBody = target
 
-		if req.ContentLength == 0 {
-			return
+		if req.ContentLength == 0 || wh.StreamBody {
+			return 0
 		}
 
 		err := wh.Unmarshaller.Unmarshall(ctx, req, wsReq)
 
 		if err != nil {
 
+			if ws.IsBodyTooLarge(err) {
+				return http.StatusRequestEntityTooLarge
+			}
+
+			if ws.IsUnsupportedMediaType(err) {
+				return http.StatusUnsupportedMediaType
+			}
+
 			wh.Log.LogDebugfCtx(ctx, "Error unmarshalling request body for %s %s %s", req.URL.Path, req.Method, err)
 
 			m, c := wh.FrameworkErrors.MessageCode(ws.UnableToParseRequest)
@@ -369,23 +605,66 @@ func (wh *WsHandler) unmarshall(ctx context.Context, req *http.Request, wsReq *w
 		}
 
 	}
+
+	return 0
 }
 
-func (wh *WsHandler) processPathParams(req *http.Request, wsReq *ws.WsRequest) {
+func (wh *WsHandler) writeHttpError(ctx context.Context, w *httpendpoint.HttpResponseWriter, wsReq *ws.WsRequest, status int) {
+
+	var se ws.ServiceErrors
+	se.HttpStatus = status
+	se.AddError(wh.FrameworkErrors.HttpError(status))
+
+	wh.writeErrorResponse(ctx, &se, w, wsReq)
+}
+
+func (wh *WsHandler) processPathParams(ctx context.Context, req *http.Request, wsReq *ws.WsRequest) {
 
 	if wh.DisablePathParsing {
 		return
 	}
 
 	re := wh.pathRegex
-	params := re.FindStringSubmatch(req.URL.Path)
-	wsReq.PathParams = params[1:]
+	path := req.URL.Path
+	loc := re.FindStringSubmatchIndex(path)
+	groups := re.NumSubexp()
+
+	params := make([]string, groups)
+	names := make([]string, groups)
+
+	for i, name := range re.SubexpNames()[1:] {
+
+		start, end := loc[2*i+2], loc[2*i+3]
+
+		// Optional groups that were not matched are not recorded as named parameters
+		if start >= 0 {
+			params[i] = path[start:end]
+			names[i] = name
+		}
+	}
+
+	wsReq.PathParams = params
+	wsReq.NamedPathParams = ws.NewWsParamsForNamedPath(names, params)
 
 	if wh.bindPathParams && len(wsReq.PathParams) > 0 {
 		pp := ws.NewWsParamsForPath(wh.BindPathParams, wsReq.PathParams)
 		wh.ParamBinder.BindPathParameters(wsReq, pp)
 	}
 
+	if wh.bindNamedParams {
+
+		if wsReq.RequestBody == nil {
+			wh.Log.LogErrorfCtx(ctx, "Path parameter binding is enabled, but no target available to bind into. Does your Logic component implement the WsUnmarshallTarget interface?")
+			return
+		}
+
+		if wh.AutoBindPath {
+			wh.ParamBinder.AutoBindNamedPathParameters(wsReq)
+		} else {
+			wh.ParamBinder.BindNamedPathParameters(wsReq, wh.FieldPathParam)
+		}
+	}
+
 }
 
 func (wh *WsHandler) processQueryParams(ctx context.Context, req *http.Request, wsReq *ws.WsRequest) {
@@ -413,6 +692,25 @@ func (wh *WsHandler) processQueryParams(ctx context.Context, req *http.Request,
 
 }
 
+func (wh *WsHandler) processTagsHeadersAndCookies(req *http.Request, wsReq *ws.WsRequest) {
+
+	if wsReq.RequestBody == nil {
+		return
+	}
+
+	if wh.bindTagged {
+		wh.ParamBinder.BindTaggedParameters(wsReq, req)
+	}
+
+	if len(wh.FieldHeader) > 0 {
+		wh.ParamBinder.BindHeaders(wsReq, ws.NewWsParamsForHeaders(req.Header), wh.FieldHeader)
+	}
+
+	if len(wh.FieldCookie) > 0 {
+		wh.ParamBinder.BindCookies(wsReq, ws.NewWsParamsForCookies(req.Cookies()), wh.FieldCookie)
+	}
+}
+
 func (wh *WsHandler) checkAccess(ctx context.Context, w *httpendpoint.HttpResponseWriter, wsReq *ws.WsRequest) bool {
 
 	ac := wh.AccessChecker
@@ -476,11 +774,44 @@ func (wh *WsHandler) SupportedHttpMethods() []string {
 }
 
 // RegexPattern returns the unparsed regex pattern that should be applicaed to the path of incoming requests to
-// see if this handler should handle the request.
+// see if this handler should handle the request. If PathTemplate is set, the equivalent regex is returned.
 func (wh *WsHandler) RegexPattern() string {
+
+	if wh.PathTemplate != "" {
+
+		if pt, err := httpendpoint.ParsePathTemplate(wh.PathTemplate); err == nil {
+			return pt.RegexPattern()
+		}
+	}
+
 	return wh.PathPattern
 }
 
+// ServerNames returns the value of HttpServers. See httpendpoint.ServerNamesProvider
+func (wh *WsHandler) ServerNames() []string {
+	return wh.HttpServers
+}
+
+// CORSPolicy returns the value of CORS. See httpendpoint.CORSPolicyProvider
+func (wh *WsHandler) CORSPolicy() *httpendpoint.CORSPolicy {
+	return wh.CORS
+}
+
+// BodyLimit returns the value of MaxBodyBytes. See httpendpoint.BodyLimitProvider
+func (wh *WsHandler) BodyLimit() int {
+	return wh.MaxBodyBytes
+}
+
+// RateLimitPolicy returns the value of RateLimit. See httpendpoint.RateLimitPolicyProvider
+func (wh *WsHandler) RateLimitPolicy() *httpendpoint.RateLimitPolicy {
+	return wh.RateLimit
+}
+
+// RouteTemplate returns the value of PathTemplate. See httpendpoint.RouteTemplateProvider
+func (wh *WsHandler) RouteTemplate() string {
+	return wh.PathTemplate
+}
+
 // VersionAware returns true if this handler can be considered when a user requests a specific version of functionality.
 func (wh *WsHandler) VersionAware() bool {
 	return wh.VersionAssessor != nil
@@ -612,8 +943,30 @@ func (wh *WsHandler) StartComponent() error {
 
 	wh.state = ioc.StartingState
 
-	if wh.PathPattern == "" || wh.HttpMethod == "" || wh.Logic == nil {
-		return errors.New("Handlers must have at least a PathPattern string, HttpMethod string and Logic component set.")
+	if (wh.PathPattern == "" && wh.PathTemplate == "") || wh.HttpMethod == "" || wh.Logic == nil {
+		return errors.New("Handlers must have at least a PathPattern or PathTemplate string, HttpMethod string and Logic component set.")
+	}
+
+	if wh.PathPattern != "" && wh.PathTemplate != "" {
+		return errors.New("Handlers cannot have both a PathPattern and a PathTemplate.")
+	}
+
+	if wh.PathTemplate != "" {
+		if _, err := httpendpoint.ParsePathTemplate(wh.PathTemplate); err != nil {
+			return err
+		}
+	}
+
+	if wh.CORS != nil {
+		if err := wh.CORS.Compile(); err != nil {
+			return err
+		}
+	}
+
+	if wh.RateLimit != nil {
+		if err := wh.RateLimit.Validate(); err != nil {
+			return err
+		}
 	}
 
 	if wh.AutoValidator != nil && wh.ErrorFinder == nil {
@@ -634,7 +987,7 @@ func (wh *WsHandler) StartComponent() error {
 
 		wh.bindPathParams = len(wh.BindPathParams) > 0
 
-		r, err := regexp.Compile(wh.PathPattern)
+		r, err := regexp.Compile(wh.RegexPattern())
 
 		if err != nil {
 			return err
@@ -642,18 +995,129 @@ func (wh *WsHandler) StartComponent() error {
 			wh.pathRegex = r
 		}
 
+		wh.bindNamedParams = wh.AutoBindPath || len(wh.FieldPathParam) > 0
+
+		if err := wh.checkNamedPathParams(); err != nil {
+			return err
+		}
+
+	}
+
+	if err := wh.checkBindings(); err != nil {
+		return err
 	}
 
 	if wh.DeferAutoErrors && wh.validator == nil {
 		return errors.New("If you want to defer errors generated during auto validation, your logic component must implement WsRequestValidator.")
 	}
 
+	for _, name := range wh.RequestScoped {
+
+		if wh.container == nil || wh.container.ComponentByName(name) == nil {
+			m := fmt.Sprintf("No component named %s is available to be request scoped", name)
+			return errors.New(m)
+		}
+
+		if s := wh.container.Scope(name); s != ioc.RequestScope {
+			m := fmt.Sprintf("Component %s is listed in RequestScoped but is %s scoped", name, s)
+			return errors.New(m)
+		}
+	}
+
 	wh.state = ioc.RunningState
 
 	return nil
 
 }
 
+// Makes sure every path parameter named in FieldPathParam is a named group in the path regex.
+func (wh *WsHandler) checkNamedPathParams() error {
+
+	names := make(map[string]bool)
+
+	for _, n := range wh.pathRegex.SubexpNames() {
+		names[n] = true
+	}
+
+	for field, param := range wh.FieldPathParam {
+
+		if param == "" || !names[param] {
+			m := fmt.Sprintf("Field %s is mapped to path parameter %s in FieldPathParam, but there is no group or template parameter with that name", field, param)
+			return errors.New(m)
+		}
+	}
+
+	return nil
+}
+
+// Checks that the fields named in FieldHeader and FieldCookie exist and that any ws.BindingTag tags on the Logic's
+// UnmarshallTarget are valid and do not bind fields that are also bound by FieldQueryParam, FieldPathParam, FieldHeader or FieldCookie.
+func (wh *WsHandler) checkBindings() error {
+
+	targetSource, found := wh.Logic.(WsUnmarshallTarget)
+
+	if !found {
+
+		if len(wh.FieldHeader) > 0 || len(wh.FieldCookie) > 0 {
+			return errors.New("FieldHeader or FieldCookie is set, but Logic does not implement WsUnmarshallTarget")
+		}
+
+		return nil
+	}
+
+	target := targetSource.UnmarshallTarget()
+
+	if target == nil {
+		return nil
+	}
+
+	var pathParams []string
+
+	if wh.pathRegex != nil {
+		pathParams = wh.pathRegex.SubexpNames()
+	}
+
+	if err := ws.CheckTagBindings(target, pathParams); err != nil {
+		return err
+	}
+
+	for _, b := range []struct {
+		source     string
+		setting    string
+		configured map[string]string
+	}{
+		{ws.QueryBinding, "FieldQueryParam", wh.FieldQueryParam},
+		{ws.PathBinding, "FieldPathParam", wh.FieldPathParam},
+		{ws.HeaderBinding, "FieldHeader", wh.FieldHeader},
+		{ws.CookieBinding, "FieldCookie", wh.FieldCookie},
+	} {
+
+		tagged, _ := ws.TagBindings(target, b.source)
+
+		wh.bindTagged = wh.bindTagged || len(tagged) > 0
+
+		for field, name := range b.configured {
+
+			if !ws.HasFieldPath(target, field) && b.source != ws.QueryBinding {
+				m := fmt.Sprintf("Field %s is mapped to %s %s in %s, but the UnmarshallTarget has no field with that name", field, b.source, name, b.setting)
+				return errors.New(m)
+			}
+
+			if tagged[field] != "" {
+				m := fmt.Sprintf("Field %s is bound by both %s and a %s tag", field, b.setting, ws.BindingTag)
+				return errors.New(m)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Container accepts a reference to the IoC container so that request scoped components can be created. See ioc.ContainerAccessor
+func (wh *WsHandler) Container(container *ioc.ComponentContainer) {
+	wh.container = container
+}
+
 // See ComponentNamer.ComponentName
 func (wh *WsHandler) ComponentName() string {
 	return wh.componentName
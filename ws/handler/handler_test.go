@@ -8,11 +8,17 @@ import (
 	"bytes"
 	"context"
 	"github.com/graniticio/granitic/httpendpoint"
+	"github.com/graniticio/granitic/logging"
 	"github.com/graniticio/granitic/test"
 	"github.com/graniticio/granitic/ws"
+	"github.com/graniticio/granitic/ws/json"
+	"github.com/graniticio/granitic/ws/xml"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -153,3 +159,370 @@ func (l *AllPhasesLogic) PreValidate(ctx context.Context, request *ws.WsRequest,
 }
 
 type Body struct{}
+
+func TestPathTemplate(t *testing.T) {
+
+	h, req := GetHandler(t)
+
+	h.Logic = new(ProcessOnlyLogic)
+	h.PathTemplate = "/test"
+
+	test.ExpectNotNil(t, h.StartComponent())
+
+	h, req = GetHandler(t)
+
+	h.Logic = new(ProcessOnlyLogic)
+	h.PathPattern = ""
+	h.PathTemplate = "/{name:alpha}"
+
+	test.ExpectString(t, h.RouteTemplate(), "/{name:alpha}")
+	test.ExpectString(t, h.RegexPattern(), "^/(?P<name>[a-zA-Z]+)/?$")
+	test.ExpectNil(t, h.StartComponent())
+
+	test.ExpectBool(t, h.pathRegex.MatchString(req.URL.Path), true)
+}
+
+func TestFieldPathParamMustBeNamed(t *testing.T) {
+
+	h, _ := GetHandler(t)
+
+	h.Logic = new(ProcessOnlyLogic)
+	h.PathPattern = "^/(?P<name>[a-z]+)$"
+	h.FieldPathParam = map[string]string{"Name": "nme"}
+
+	test.ExpectNotNil(t, h.StartComponent())
+
+	h, req := GetHandler(t)
+
+	h.Logic = new(ProcessOnlyLogic)
+	h.PathPattern = "^/(?P<name>[a-z]+)$"
+	h.FieldPathParam = map[string]string{"Name": "name"}
+	h.ParamBinder = &ws.ParamBinder{FrameworkLogger: new(logging.ConsoleErrorLogger)}
+
+	test.ExpectNil(t, h.StartComponent())
+
+	target := new(namedTarget)
+
+	wsReq := new(ws.WsRequest)
+	wsReq.RequestBody = target
+	h.processPathParams(context.Background(), req, wsReq)
+
+	n, _ := wsReq.NamedPathParams.StringValue("name")
+	test.ExpectString(t, n, "test")
+	test.ExpectString(t, target.Name, "test")
+}
+
+type optionalIDTarget struct {
+	ID   int
+	Name string
+}
+
+func TestUnmatchedOptionalPathParam(t *testing.T) {
+
+	h, _ := GetHandler(t)
+
+	h.Logic = new(ProcessOnlyLogic)
+	h.PathPattern = `^/a(?:/(?P<id>\d+))?(?:/(?P<name>[a-z]+))?$`
+	h.FieldPathParam = map[string]string{"ID": "id", "Name": "name"}
+	h.ParamBinder = &ws.ParamBinder{FrameworkLogger: new(logging.ConsoleErrorLogger), FrameworkErrors: new(ws.FrameworkErrorGenerator)}
+
+	test.ExpectNil(t, h.StartComponent())
+
+	target := new(optionalIDTarget)
+
+	wsReq := new(ws.WsRequest)
+	wsReq.RequestBody = target
+	h.processPathParams(context.Background(), httptest.NewRequest("GET", "/a/bob", nil), wsReq)
+
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 0)
+	test.ExpectBool(t, wsReq.NamedPathParams.Exists("id"), false)
+	test.ExpectBool(t, wsReq.NamedPathParams.Exists("name"), true)
+	test.ExpectString(t, target.Name, "bob")
+	test.ExpectInt(t, len(wsReq.PathParams), 2)
+	test.ExpectString(t, wsReq.PathParams[0], "")
+
+	target = new(optionalIDTarget)
+	wsReq = &ws.WsRequest{RequestBody: target}
+	h.processPathParams(context.Background(), httptest.NewRequest("GET", "/a/7", nil), wsReq)
+
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 0)
+	test.ExpectInt(t, target.ID, 7)
+}
+
+type namedTarget struct {
+	Name string
+}
+
+type uploadLogic struct {
+	read    int
+	readErr error
+	called  bool
+}
+
+func (l *uploadLogic) Process(ctx context.Context, request *ws.WsRequest, response *ws.WsResponse) {
+	l.called = true
+
+	if request.BodyReader != nil {
+		b, err := ioutil.ReadAll(request.BodyReader)
+		l.read, l.readErr = len(b), err
+	}
+}
+
+func (l *uploadLogic) UnmarshallTarget() interface{} {
+	return new(namedTarget)
+}
+
+type statusRecordingWriter struct {
+	status int
+}
+
+func (rw *statusRecordingWriter) Write(ctx context.Context, state *ws.WsProcessState, outcome ws.WsOutcome) error {
+
+	if state.ServiceErrors != nil {
+		rw.status = state.ServiceErrors.HttpStatus
+	} else if outcome == ws.Abnormal {
+		rw.status = state.Status
+	}
+
+	return nil
+}
+
+func limitedRequest(body string, limit int) *http.Request {
+
+	req := httptest.NewRequest("POST", "/test", strings.NewReader(body))
+	req.ContentLength = -1
+	req.Body = http.MaxBytesReader(nil, req.Body, int64(limit))
+
+	return req
+}
+
+func TestBodyTooLarge(t *testing.T) {
+
+	l := new(uploadLogic)
+	rw := new(statusRecordingWriter)
+
+	h, _ := GetHandler(t)
+	h.Logic = l
+	h.HttpMethod = "POST"
+	h.MaxBodyBytes = 5
+	h.ResponseWriter = rw
+	h.Unmarshaller = new(json.StandardJSONUnmarshaller)
+	h.FrameworkErrors = &ws.FrameworkErrorGenerator{HttpMessages: map[string]string{"413": "Too large"}}
+
+	test.ExpectNil(t, h.StartComponent())
+	test.ExpectInt(t, h.BodyLimit(), 5)
+
+	w := httpendpoint.NewHttpResponseWriter(NewStringBufferResponseWriter())
+	h.ServeHttp(context.Background(), w, limitedRequest(`{"Name": "long enough"}`, 5))
+
+	test.ExpectInt(t, rw.status, http.StatusRequestEntityTooLarge)
+	test.ExpectBool(t, l.called, false)
+
+	rw.status = 0
+	h.Unmarshaller = new(xml.StandardXmlUnmarshaller)
+
+	h.ServeHttp(context.Background(), w, limitedRequest(`<namedTarget><Name>long enough</Name></namedTarget>`, 5))
+
+	test.ExpectInt(t, rw.status, http.StatusRequestEntityTooLarge)
+	test.ExpectBool(t, l.called, false)
+}
+
+func TestStreamBody(t *testing.T) {
+
+	l := new(uploadLogic)
+
+	h, _ := GetHandler(t)
+	h.Logic = l
+	h.HttpMethod = "POST"
+	h.StreamBody = true
+
+	test.ExpectNil(t, h.StartComponent())
+
+	w := httpendpoint.NewHttpResponseWriter(NewStringBufferResponseWriter())
+	h.ServeHttp(context.Background(), w, limitedRequest("0123456789", 20))
+
+	test.ExpectBool(t, l.called, true)
+	test.ExpectInt(t, l.read, 10)
+	test.ExpectNil(t, l.readErr)
+
+	h.ServeHttp(context.Background(), w, limitedRequest("0123456789", 4))
+
+	test.ExpectBool(t, ws.IsBodyTooLarge(l.readErr), true)
+}
+
+func TestNegotiationFailures(t *testing.T) {
+
+	l := new(uploadLogic)
+	rw := new(statusRecordingWriter)
+
+	nrw := new(ws.NegotiatingResponseWriter)
+	nrw.DefaultContentType = "application/json"
+	nrw.Register("application/json", rw)
+
+	nu := new(ws.NegotiatingUnmarshaller)
+	nu.DefaultContentType = "application/json"
+	nu.Register("application/json", new(json.StandardJSONUnmarshaller))
+
+	h, _ := GetHandler(t)
+	h.Logic = l
+	h.HttpMethod = "POST"
+	h.ResponseWriter = nrw
+	h.Unmarshaller = nu
+	h.FrameworkErrors = new(ws.FrameworkErrorGenerator)
+
+	test.ExpectNil(t, h.StartComponent())
+
+	w := httpendpoint.NewHttpResponseWriter(NewStringBufferResponseWriter())
+
+	req := httptest.NewRequest("POST", "/test", strings.NewReader(`{"Name": "n"}`))
+	req.Header.Set("Accept", "text/csv")
+
+	h.ServeHttp(context.Background(), w, req)
+
+	test.ExpectInt(t, rw.status, http.StatusNotAcceptable)
+	test.ExpectBool(t, l.called, false)
+
+	req = httptest.NewRequest("POST", "/test", strings.NewReader("Name=n"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	h.ServeHttp(context.Background(), w, req)
+
+	test.ExpectInt(t, rw.status, http.StatusUnsupportedMediaType)
+	test.ExpectBool(t, l.called, false)
+
+	req = httptest.NewRequest("POST", "/test", strings.NewReader(`{"Name": "n"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Accept", "application/*")
+
+	h.ServeHttp(context.Background(), w, req)
+
+	test.ExpectBool(t, l.called, true)
+}
+
+type bodyRecordingLogic struct {
+	body *namedTarget
+}
+
+func (l *bodyRecordingLogic) Process(ctx context.Context, request *ws.WsRequest, response *ws.WsResponse) {
+	l.body = request.RequestBody.(*namedTarget)
+}
+
+func (l *bodyRecordingLogic) UnmarshallTarget() interface{} {
+	return new(namedTarget)
+}
+
+func TestJSONBodyWithFormContentType(t *testing.T) {
+
+	l := new(bodyRecordingLogic)
+
+	h, _ := GetHandler(t)
+	h.Logic = l
+	h.HttpMethod = "POST"
+	h.Unmarshaller = new(json.StandardJSONUnmarshaller)
+
+	test.ExpectNil(t, h.StartComponent())
+
+	req := httptest.NewRequest("POST", "/test", strings.NewReader(`{"Name": "n"}`))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httpendpoint.NewHttpResponseWriter(NewStringBufferResponseWriter())
+	h.ServeHttp(context.Background(), w, req)
+
+	test.ExpectNotNil(t, l.body)
+	test.ExpectString(t, l.body.Name, "n")
+}
+
+type headerBoundTarget struct {
+	Tenant  string `ws:"header=X-Tenant-Id"`
+	Session string
+}
+
+type headerBoundLogic struct {
+	target *headerBoundTarget
+}
+
+func (l *headerBoundLogic) Process(ctx context.Context, request *ws.WsRequest, response *ws.WsResponse) {
+	l.target = request.RequestBody.(*headerBoundTarget)
+}
+
+func (l *headerBoundLogic) UnmarshallTarget() interface{} {
+	return new(headerBoundTarget)
+}
+
+func TestHeaderAndCookieBinding(t *testing.T) {
+
+	l := new(headerBoundLogic)
+
+	h, _ := GetHandler(t)
+	h.Logic = l
+	h.ParamBinder = &ws.ParamBinder{FrameworkLogger: new(logging.ConsoleErrorLogger)}
+	h.FieldCookie = map[string]string{"Session": "sid"}
+
+	test.ExpectNil(t, h.StartComponent())
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Tenant-Id", "acme")
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "s1"})
+
+	w := httpendpoint.NewHttpResponseWriter(NewStringBufferResponseWriter())
+	h.ServeHttp(context.Background(), w, req)
+
+	test.ExpectNotNil(t, l.target)
+	test.ExpectString(t, l.target.Tenant, "acme")
+	test.ExpectString(t, l.target.Session, "s1")
+
+	h, _ = GetHandler(t)
+	h.Logic = l
+	h.FieldHeader = map[string]string{"Nope": "X-Nope"}
+
+	test.ExpectNotNil(t, h.StartComponent())
+}
+
+type taggedPathTarget struct {
+	ArtistID int `ws:"path=id"`
+	Tenant   string
+}
+
+type taggedPathLogic struct {
+	target *taggedPathTarget
+}
+
+func (l *taggedPathLogic) Process(ctx context.Context, request *ws.WsRequest, response *ws.WsResponse) {
+	l.target = request.RequestBody.(*taggedPathTarget)
+}
+
+func (l *taggedPathLogic) UnmarshallTarget() interface{} {
+	return new(taggedPathTarget)
+}
+
+func TestTaggedBindingVerification(t *testing.T) {
+
+	l := new(taggedPathLogic)
+
+	h, _ := GetHandler(t)
+	h.Logic = l
+	h.PathPattern = ""
+	h.PathTemplate = "/artist/{id:int}"
+	h.ParamBinder = &ws.ParamBinder{FrameworkLogger: new(logging.ConsoleErrorLogger)}
+
+	test.ExpectNil(t, h.StartComponent())
+
+	w := httpendpoint.NewHttpResponseWriter(NewStringBufferResponseWriter())
+	h.ServeHttp(context.Background(), w, httptest.NewRequest("GET", "/artist/12", nil))
+
+	test.ExpectNotNil(t, l.target)
+	test.ExpectInt(t, l.target.ArtistID, 12)
+
+	h, _ = GetHandler(t)
+	h.Logic = l
+	h.PathPattern = "^/artist/(?P<artist>\\d+)$"
+
+	test.ExpectNotNil(t, h.StartComponent())
+
+	h, _ = GetHandler(t)
+	h.Logic = l
+	h.PathPattern = "^/artist/(?P<id>\\d+)$"
+	h.FieldPathParam = map[string]string{"ArtistID": "id"}
+
+	test.ExpectNotNil(t, h.StartComponent())
+}
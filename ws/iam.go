@@ -5,6 +5,7 @@ package ws
 
 import (
 	"context"
+	"crypto/x509"
 	"github.com/graniticio/granitic/iam"
 	"net/http"
 )
@@ -21,3 +22,15 @@ type WsAccessChecker interface {
 	// Allowed returns true if the caller is allowed to have this request processed, false otherwise.
 	Allowed(ctx context.Context, r *WsRequest) bool
 }
+
+// VerifiedPeerCertificate returns the certificate presented by the caller if the request was made over a TLS connection
+// and the certificate was verified by the server (see httpserver.TLSSettings), or nil otherwise. Intended for use by
+// WsIdentifier implementations that identify callers by their client certificate.
+func VerifiedPeerCertificate(req *http.Request) *x509.Certificate {
+
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	return req.TLS.VerifiedChains[0][0]
+}
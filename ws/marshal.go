@@ -49,11 +49,7 @@ type MarshallingResponseWriter struct {
 // See WsResponseWriter.Write
 func (rw *MarshallingResponseWriter) Write(ctx context.Context, state *WsProcessState, outcome WsOutcome) error {
 
-	var ch map[string]string
-
-	if rw.HeaderBuilder != nil {
-		ch = rw.HeaderBuilder.BuildHeaders(ctx, state)
-	}
+	ch := CommonHeaders(ctx, state, rw.HeaderBuilder)
 
 	switch outcome {
 	case Normal:
@@ -0,0 +1,326 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/httpendpoint"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// The HTTP header listing the media types a caller is willing to accept in a response.
+	AcceptHeader = "Accept"
+
+	// The HTTP header declaring the media type of a request or response body.
+	ContentTypeHeader = "Content-Type"
+)
+
+type acceptKey struct{}
+
+// WithAccept returns a copy of the supplied context carrying the value of an HTTP request's Accept header, allowing
+// response writers to negotiate the format of a response (see NegotiatingResponseWriter).
+func WithAccept(ctx context.Context, accept string) context.Context {
+	return context.WithValue(ctx, acceptKey{}, accept)
+}
+
+// Accept returns the Accept header stored in the supplied context by WithAccept (or an empty string).
+func Accept(ctx context.Context) string {
+
+	a, _ := ctx.Value(acceptKey{}).(string)
+
+	return a
+}
+
+// A media range from an HTTP Accept header (e.g. text/*;q=0.5)
+type MediaRange struct {
+	// The media type or range, in lower case (e.g. application/json, text/* or */*)
+	Type string
+
+	// The relative preference of the caller for this range (between 0 and 1).
+	Quality float64
+}
+
+// Matches returns true if the supplied media type falls within this range.
+func (mr MediaRange) Matches(mediaType string) bool {
+
+	if mr.Type == "*/*" || mr.Type == mediaType {
+		return true
+	}
+
+	if strings.HasSuffix(mr.Type, "/*") {
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(mr.Type, "*"))
+	}
+
+	return false
+}
+
+// How closely a range matches a media type - exact matches are preferred over type/* which is preferred over */*
+func (mr MediaRange) specificity() int {
+
+	switch {
+	case mr.Type == "*/*":
+		return 0
+	case strings.HasSuffix(mr.Type, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// ParseAccept converts the value of an HTTP Accept header into the media ranges it lists. Ranges that cannot be parsed
+// are ignored.
+func ParseAccept(header string) []MediaRange {
+
+	ranges := make([]MediaRange, 0)
+
+	for _, part := range strings.Split(header, ",") {
+
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		t, params, err := mime.ParseMediaType(part)
+
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+
+		if qs, found := params["q"]; found {
+
+			if q, err = strconv.ParseFloat(qs, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+
+		ranges = append(ranges, MediaRange{Type: t, Quality: q})
+	}
+
+	return ranges
+}
+
+// NegotiateMediaType chooses the media type from those offered that is most acceptable to a caller with the supplied
+// Accept header. The quality of each offered type is that of the most specific range that matches it and ties are
+// resolved in favour of the type offered first. If the Accept header is empty, the first offered type is chosen. Returns
+// false if none of the offered types are acceptable.
+func NegotiateMediaType(accept string, offered []string) (string, bool) {
+
+	if len(offered) == 0 {
+		return "", false
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return offered[0], true
+	}
+
+	ranges := ParseAccept(accept)
+
+	best := ""
+	bestQuality := 0.0
+
+	for _, mt := range offered {
+
+		q := 0.0
+		specificity := -1
+
+		for _, r := range ranges {
+
+			if r.Matches(mt) && r.specificity() > specificity {
+				q = r.Quality
+				specificity = r.specificity()
+			}
+		}
+
+		if q > bestQuality {
+			best = mt
+			bestQuality = q
+		}
+	}
+
+	return best, best != ""
+}
+
+// Implemented by response writers that are only able to render some media types. Allows a handler to reject a request
+// with HTTP 406 before its logic is executed if none of the media types acceptable to the caller can be rendered.
+type AcceptChecker interface {
+	// Acceptable returns true if the writer can render a response in a media type acceptable to the caller (see WithAccept).
+	Acceptable(ctx context.Context) bool
+}
+
+// Returned by a NegotiatingUnmarshaller when no WsUnmarshaller is registered for the media type of a request's body.
+type UnsupportedMediaTypeError struct {
+	// The media type declared by the request's Content-Type header.
+	MediaType string
+}
+
+// Error implements error.Error
+func (e *UnsupportedMediaTypeError) Error() string {
+	return fmt.Sprintf("Unsupported media type %s", e.MediaType)
+}
+
+// IsUnsupportedMediaType returns true if the supplied error was caused by a request body in a media type that could not
+// be unmarshalled.
+func IsUnsupportedMediaType(err error) bool {
+
+	var ume *UnsupportedMediaTypeError
+
+	return errors.As(err, &ume)
+}
+
+// A WsUnmarshaller that delegates to another WsUnmarshaller based on the media type declared by an HTTP request's
+// Content-Type header. Created automatically by the JsonWs and XmlWs facilities.
+type NegotiatingUnmarshaller struct {
+	// WsUnmarshallers keyed by the (lower case) media type they are able to parse.
+	Unmarshallers map[string]WsUnmarshaller
+
+	// The media type assumed for requests without a Content-Type header.
+	DefaultContentType string
+
+	// If set, used for requests whose Content-Type has no registered WsUnmarshaller (instead of returning an
+	// UnsupportedMediaTypeError).
+	Fallback WsUnmarshaller
+}
+
+// Register associates a WsUnmarshaller with a media type (e.g. application/json)
+func (nu *NegotiatingUnmarshaller) Register(mediaType string, um WsUnmarshaller) {
+
+	if nu.Unmarshallers == nil {
+		nu.Unmarshallers = make(map[string]WsUnmarshaller)
+	}
+
+	nu.Unmarshallers[strings.ToLower(mediaType)] = um
+}
+
+// Unmarshall parses the request body with the WsUnmarshaller registered for the request's Content-Type. Returns an
+// UnsupportedMediaTypeError if no WsUnmarshaller is registered for that type and there is no Fallback.
+func (nu *NegotiatingUnmarshaller) Unmarshall(ctx context.Context, req *http.Request, wsReq *WsRequest) error {
+
+	mt := nu.DefaultContentType
+
+	if ct := req.Header.Get(ContentTypeHeader); ct != "" {
+
+		parsed, _, err := mime.ParseMediaType(ct)
+
+		if err != nil && nu.Fallback == nil {
+			return &UnsupportedMediaTypeError{MediaType: ct}
+		}
+
+		mt = parsed
+	}
+
+	um := nu.Unmarshallers[strings.ToLower(mt)]
+
+	if um == nil {
+		um = nu.Fallback
+	}
+
+	if um == nil {
+		return &UnsupportedMediaTypeError{MediaType: mt}
+	}
+
+	return um.Unmarshall(ctx, req, wsReq)
+}
+
+// A WsResponseWriter that delegates to another WsResponseWriter based on the media types a caller will accept (see
+// WithAccept). If none of the registered media types are acceptable, an HTTP 406 response is written in the default
+// media type instead. Created automatically if both the JsonWs and XmlWs facilities are enabled.
+//
+// The writer is also an httpendpoint.HttpFilter that records the Accept header of every request, so that responses
+// written by the HttpServer facility (not found, too busy etc) are negotiated too.
+type NegotiatingResponseWriter struct {
+	// WsResponseWriters keyed by the (lower case) media type they render.
+	Writers map[string]WsResponseWriter
+
+	// The media type used when the caller does not express a preference, or when none of the acceptable types can be rendered.
+	DefaultContentType string
+}
+
+// Register associates a WsResponseWriter with a media type (e.g. application/json)
+func (rw *NegotiatingResponseWriter) Register(mediaType string, w WsResponseWriter) {
+
+	if rw.Writers == nil {
+		rw.Writers = make(map[string]WsResponseWriter)
+	}
+
+	rw.Writers[strings.ToLower(mediaType)] = w
+}
+
+// See WsResponseWriter.Write
+func (rw *NegotiatingResponseWriter) Write(ctx context.Context, state *WsProcessState, outcome WsOutcome) error {
+
+	mt, found := NegotiateMediaType(Accept(ctx), rw.mediaTypes())
+
+	if !found {
+
+		if outcome != Abnormal {
+			state = NewAbnormalState(http.StatusNotAcceptable, state.HttpResponseWriter)
+			outcome = Abnormal
+		}
+
+		mt = rw.DefaultContentType
+	}
+
+	w := rw.Writers[strings.ToLower(mt)]
+
+	if w == nil {
+		return errors.New(fmt.Sprintf("No WsResponseWriter registered for media type %s", mt))
+	}
+
+	vary := AcceptHeader
+
+	if v := ContextHeaders(ctx)["Vary"]; v != "" {
+		vary = v + ", " + vary
+	}
+
+	ctx = AddContextHeaders(ctx, map[string]string{"Vary": vary})
+
+	return w.Write(ctx, state, outcome)
+}
+
+// See AbnormalStatusWriter.WriteAbnormalStatus
+func (rw *NegotiatingResponseWriter) WriteAbnormalStatus(ctx context.Context, state *WsProcessState) error {
+	return rw.Write(ctx, state, Abnormal)
+}
+
+// See AcceptChecker.Acceptable
+func (rw *NegotiatingResponseWriter) Acceptable(ctx context.Context) bool {
+
+	_, found := NegotiateMediaType(Accept(ctx), rw.mediaTypes())
+
+	return found
+}
+
+// Filter implements httpendpoint.HttpFilter, recording the request's Accept header in the context.
+func (rw *NegotiatingResponseWriter) Filter(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request, next httpendpoint.FilterChain) context.Context {
+	return next(WithAccept(ctx, req.Header.Get(AcceptHeader)), w, req)
+}
+
+// The registered media types, default type first then in alphabetical order.
+func (rw *NegotiatingResponseWriter) mediaTypes() []string {
+
+	dt := strings.ToLower(rw.DefaultContentType)
+	types := make([]string, 0, len(rw.Writers))
+
+	for mt := range rw.Writers {
+		if mt != dt {
+			types = append(types, mt)
+		}
+	}
+
+	sort.Strings(types)
+
+	if _, found := rw.Writers[dt]; found {
+		types = append([]string{dt}, types...)
+	}
+
+	return types
+}
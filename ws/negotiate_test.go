@@ -0,0 +1,131 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"context"
+	"github.com/graniticio/granitic/httpendpoint"
+	"github.com/graniticio/granitic/test"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseAccept(t *testing.T) {
+
+	r := ParseAccept("text/html, application/JSON;q=0.5, bad;;, */*;q=0.1,")
+
+	test.ExpectInt(t, len(r), 3)
+	test.ExpectString(t, r[1].Type, "application/json")
+	test.ExpectBool(t, r[1].Quality == 0.5, true)
+	test.ExpectBool(t, r[2].Matches("image/png"), true)
+
+	test.ExpectBool(t, MediaRange{Type: "text/*"}.Matches("text/xml"), true)
+	test.ExpectBool(t, MediaRange{Type: "text/*"}.Matches("application/xml"), false)
+}
+
+func TestNegotiateMediaType(t *testing.T) {
+
+	offered := []string{"application/json", "application/xml"}
+
+	check := func(accept string, expected string, found bool) {
+		mt, ok := NegotiateMediaType(accept, offered)
+		test.ExpectString(t, mt, expected)
+		test.ExpectBool(t, ok, found)
+	}
+
+	check("", "application/json", true)
+	check("*/*", "application/json", true)
+	check("application/xml", "application/xml", true)
+	check("application/json;q=0.5, application/xml", "application/xml", true)
+	check("application/*;q=0.2, application/json;q=0", "application/xml", true)
+	check("text/csv", "", false)
+	check("application/json;q=0", "", false)
+}
+
+type recordingWriter struct {
+	outcome WsOutcome
+	status  int
+	vary    string
+}
+
+func (rw *recordingWriter) Write(ctx context.Context, state *WsProcessState, outcome WsOutcome) error {
+	rw.outcome = outcome
+	rw.status = state.Status
+	rw.vary = ContextHeaders(ctx)["Vary"]
+
+	return nil
+}
+
+func TestNegotiatingResponseWriter(t *testing.T) {
+
+	jw := new(recordingWriter)
+	xw := new(recordingWriter)
+
+	rw := new(NegotiatingResponseWriter)
+	rw.DefaultContentType = "application/json"
+	rw.Register("application/json", jw)
+	rw.Register("application/xml", xw)
+	rw.Register("text/xml", xw)
+
+	test.ExpectString(t, strings.Join(rw.mediaTypes(), ","), "application/json,application/xml,text/xml")
+
+	w := httpendpoint.NewHttpResponseWriter(httptest.NewRecorder())
+	state := &WsProcessState{HttpResponseWriter: w}
+
+	ctx := WithAccept(context.Background(), "text/xml")
+	test.ExpectBool(t, rw.Acceptable(ctx), true)
+	test.ExpectNil(t, rw.Write(ctx, state, Normal))
+	test.ExpectInt(t, int(xw.outcome), Normal)
+	test.ExpectString(t, xw.vary, AcceptHeader)
+
+	ctx = WithAccept(AddContextHeaders(context.Background(), map[string]string{"Vary": "Origin"}), "image/png")
+	test.ExpectBool(t, rw.Acceptable(ctx), false)
+	test.ExpectNil(t, rw.Write(ctx, state, Normal))
+	test.ExpectInt(t, int(jw.outcome), Abnormal)
+	test.ExpectInt(t, jw.status, http.StatusNotAcceptable)
+	test.ExpectString(t, jw.vary, "Origin, Accept")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(AcceptHeader, "application/xml")
+
+	rw.Filter(context.Background(), w, req, func(ctx context.Context, w *httpendpoint.HttpResponseWriter, req *http.Request) context.Context {
+		test.ExpectString(t, Accept(ctx), "application/xml")
+		return ctx
+	})
+}
+
+type recordingUnmarshaller struct {
+	called bool
+}
+
+func (u *recordingUnmarshaller) Unmarshall(ctx context.Context, req *http.Request, wsReq *WsRequest) error {
+	u.called = true
+	return nil
+}
+
+func TestNegotiatingUnmarshaller(t *testing.T) {
+
+	ju := new(recordingUnmarshaller)
+
+	nu := new(NegotiatingUnmarshaller)
+	nu.DefaultContentType = "application/json"
+	nu.Register("Application/JSON", ju)
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("{}"))
+	test.ExpectNil(t, nu.Unmarshall(context.Background(), req, new(WsRequest)))
+	test.ExpectBool(t, ju.called, true)
+
+	req.Header.Set(ContentTypeHeader, "text/csv; charset=utf-8")
+	err := nu.Unmarshall(context.Background(), req, new(WsRequest))
+
+	test.ExpectBool(t, IsUnsupportedMediaType(err), true)
+	test.ExpectString(t, err.Error(), "Unsupported media type text/csv")
+	fu := new(recordingUnmarshaller)
+	nu.Fallback = fu
+
+	test.ExpectNil(t, nu.Unmarshall(context.Background(), req, new(WsRequest)))
+	test.ExpectBool(t, fu.called, true)
+}
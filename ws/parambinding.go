@@ -4,23 +4,55 @@
 package ws
 
 import (
+	"errors"
+	"fmt"
 	"github.com/graniticio/granitic/logging"
 	rt "github.com/graniticio/granitic/reflecttools"
 	"github.com/graniticio/granitic/types"
+	"net/http"
+	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The layouts (see time.Parse) tried when binding a parameter into a time.Time field if ParamBinder.TimeLayouts is not set.
+var DefaultTimeLayouts = []string{time.RFC3339, "2006-01-02"}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
 )
 
 type bindError func(string, string, string, *WsParams) *WsFrameworkError
 
 // Takes string parameters extracted from an HTTP request, converts them to Go native or Granitic nilable types and
 // injects them into the RequestBody on a WsRequest.
+//
+// Fields that are slices of any of the supported types are populated with every value of a repeated parameter
+// (e.g. ?tag=a&tag=b) and, if ValueSeparator is set, with each part of a delimited value (e.g. ?tag=a,b). Fields of
+// type time.Time are parsed using TimeLayouts and fields of type time.Duration using time.ParseDuration.
+//
+// Target field names may be dot-delimited paths (e.g. Filter.Status) to fields on nested structs or pointers to structs.
+// Nil pointers to structs on the path are allocated when a value is bound.
 type ParamBinder struct {
 	// Injected by Granitic
 	FrameworkLogger logging.Logger
 
 	// Source of service errors for errors encountered while binding.
 	FrameworkErrors *FrameworkErrorGenerator
+
+	// The layouts (see time.Parse) tried, in order, when binding a value into a time.Time field. Defaults to DefaultTimeLayouts.
+	TimeLayouts []string
+
+	// If set, each value bound into a slice field is split into multiple elements on this string. The JsonWs and XmlWs
+	// facilities set this from ParamBinder.ValueSeparator in configuration (a comma by default).
+	ValueSeparator string
+
+	// The BindingTags declared on each type of request body, keyed by reflect.Type
+	tagCache sync.Map
 }
 
 // BindPathParameters takes strings extracted from an HTTP's request path (using regular expression groups) and
@@ -32,8 +64,8 @@ func (pb *ParamBinder) BindPathParameters(wsReq *WsRequest, p *WsParams) {
 
 	for i, fieldName := range p.ParamNames() {
 
-		if rt.HasFieldOfName(t, fieldName) {
-			fErr := pb.bindValueToField(strconv.Itoa(i), fieldName, p, t, pb.pathParamError)
+		if HasFieldPath(t, fieldName) {
+			fErr := pb.bindValueToPath(strconv.Itoa(i), fieldName, p, t, pb.pathParamError)
 
 			if fErr != nil {
 				fErr.Position = i
@@ -50,7 +82,58 @@ func (pb *ParamBinder) BindPathParameters(wsReq *WsRequest, p *WsParams) {
 
 }
 
-// BindPathParameters takes the query parameters from an HTTP request and
+// BindNamedPathParameters takes the values of named regular expression groups (or path template parameters) stored in
+// WsRequest.NamedPathParams and injects them into fields on the WsRequest.RequestBody using the keys of the supplied map
+// as the names of the target fields and the values as the names of the parameters. Any errors encountered are recorded as
+// framework errors in the WsRequest.
+func (pb *ParamBinder) BindNamedPathParameters(wsReq *WsRequest, targets map[string]string) {
+
+	t := wsReq.RequestBody
+	p := wsReq.NamedPathParams
+
+	for field, param := range targets {
+
+		if !HasFieldPath(t, field) {
+			pb.FrameworkLogger.LogWarnf("No field %s exists on a target object to bind path parameter %s into.", field, param)
+			continue
+		}
+
+		if p.Exists(param) {
+			pb.bindNamedPathParameter(wsReq, param, field)
+		}
+	}
+}
+
+// AutoBindNamedPathParameters takes the values of named regular expression groups (or path template parameters) stored
+// in WsRequest.NamedPathParams and injects them into fields on the WsRequest.RequestBody with exactly the same names as
+// the parameters. Any errors encountered are recorded as framework errors in the WsRequest.
+func (pb *ParamBinder) AutoBindNamedPathParameters(wsReq *WsRequest) {
+
+	t := wsReq.RequestBody
+
+	for _, param := range wsReq.NamedPathParams.ParamNames() {
+
+		if HasFieldPath(t, param) {
+			pb.bindNamedPathParameter(wsReq, param, param)
+		}
+	}
+}
+
+func (pb *ParamBinder) bindNamedPathParameter(wsReq *WsRequest, param, field string) {
+
+	pb.FrameworkLogger.LogTracef("Binding path parameter %s to field %s", param, field)
+
+	fErr := pb.bindValueToPath(param, field, wsReq.NamedPathParams, wsReq.RequestBody, pb.pathParamError)
+
+	if fErr != nil {
+		fErr.ClientField = param
+		wsReq.AddFrameworkError(fErr)
+	} else {
+		wsReq.RecordFieldAsBound(field)
+	}
+}
+
+// BindQueryParameters takes the query parameters from an HTTP request and
 // injects them into fields on the WsRequest.RequestBody using the keys of the supplied map as the name of the target fields.
 // Any errors encountered are recorded as framework errors in the WsRequest.
 func (pb *ParamBinder) BindQueryParameters(wsReq *WsRequest, targets map[string]string) {
@@ -61,12 +144,12 @@ func (pb *ParamBinder) BindQueryParameters(wsReq *WsRequest, targets map[string]
 
 	for field, param := range targets {
 
-		if rt.HasFieldOfName(t, field) {
+		if HasFieldPath(t, field) {
 
 			if p.Exists(param) {
 				l.LogTracef("Binding parameter %s to field %s", param, field)
 
-				fErr := pb.bindValueToField(param, field, p, t, pb.queryParamError)
+				fErr := pb.bindValueToPath(param, field, p, t, pb.queryParamError)
 
 				if fErr != nil {
 					wsReq.AddFrameworkError(fErr)
@@ -85,7 +168,7 @@ func (pb *ParamBinder) BindQueryParameters(wsReq *WsRequest, targets map[string]
 	pb.initialiseUnsetNilables(t)
 }
 
-// BindPathParameters takes the query parameters from an HTTP request and
+// AutoBindQueryParameters takes the query parameters from an HTTP request and
 // injects them into fields on the WsRequest.RequestBody assuming the parameters have exactly the same name as the target
 // fields. Any errors encountered are recorded as framework errors in the WsRequest.
 func (pb *ParamBinder) AutoBindQueryParameters(wsReq *WsRequest) {
@@ -95,9 +178,9 @@ func (pb *ParamBinder) AutoBindQueryParameters(wsReq *WsRequest) {
 
 	for _, paramName := range p.ParamNames() {
 
-		if rt.HasFieldOfName(t, paramName) {
+		if HasFieldPath(t, paramName) {
 
-			fErr := pb.bindValueToField(paramName, paramName, p, t, pb.queryParamError)
+			fErr := pb.bindValueToPath(paramName, paramName, p, t, pb.queryParamError)
 
 			if fErr != nil {
 				wsReq.AddFrameworkError(fErr)
@@ -112,6 +195,247 @@ func (pb *ParamBinder) AutoBindQueryParameters(wsReq *WsRequest) {
 	pb.initialiseUnsetNilables(t)
 }
 
+// BindFormParameters takes the fields of a form submitted as an HTTP request body and injects them into fields on the
+// WsRequest.RequestBody with exactly the same names as the form fields. Any errors encountered are recorded as framework
+// errors in the WsRequest.
+func (pb *ParamBinder) BindFormParameters(wsReq *WsRequest, p *WsParams) {
+
+	t := wsReq.RequestBody
+
+	for _, field := range p.ParamNames() {
+
+		ft, found := fieldPathType(t, field)
+
+		if !found {
+			continue
+		}
+
+		pb.FrameworkLogger.LogTracef("Binding form field %s", field)
+
+		if ft.Kind() != reflect.Slice && ft.Kind() != reflect.Array && p.MultipleValues(field) {
+			m, c := pb.FrameworkErrors.MessageCode(FormTargetNotArray, field)
+			wsReq.AddFrameworkError(NewFormBindFrameworkError(m, c, field, field))
+			continue
+		}
+
+		fErr := pb.bindValueToPath(field, field, p, t, pb.formParamError)
+
+		if fErr != nil {
+			wsReq.AddFrameworkError(fErr)
+		} else {
+			wsReq.RecordFieldAsBound(field)
+		}
+	}
+
+	pb.initialiseUnsetNilables(t)
+}
+
+// BindHeaders takes the headers of an HTTP request (see NewWsParamsForHeaders) and injects them into fields on the
+// WsRequest.RequestBody using the keys of the supplied map as the names of the target fields and the values as the names of
+// the headers. Any errors encountered are recorded as framework errors in the WsRequest.
+func (pb *ParamBinder) BindHeaders(wsReq *WsRequest, p *WsParams, targets map[string]string) {
+
+	canonical := make(map[string]string, len(targets))
+
+	for field, header := range targets {
+		canonical[field] = http.CanonicalHeaderKey(header)
+	}
+
+	pb.bindNamed(wsReq, p, canonical, pb.headerError)
+}
+
+// BindCookies takes the cookies sent with an HTTP request (see NewWsParamsForCookies) and injects them into fields on the
+// WsRequest.RequestBody using the keys of the supplied map as the names of the target fields and the values as the names
+// of the cookies. Any errors encountered are recorded as framework errors in the WsRequest.
+func (pb *ParamBinder) BindCookies(wsReq *WsRequest, p *WsParams, targets map[string]string) {
+	pb.bindNamed(wsReq, p, targets, pb.cookieError)
+}
+
+// BindTaggedParameters binds query parameters, named path parameters, headers and cookies onto the fields of the
+// WsRequest.RequestBody that declare a source with a BindingTag (e.g. `ws:"query=page_size"`). Query and path parameters
+// are read from the WsRequest, so are not bound if query or path parsing is disabled. Any errors encountered are recorded
+// as framework errors in the WsRequest.
+func (pb *ParamBinder) BindTaggedParameters(wsReq *WsRequest, req *http.Request) {
+
+	t := wsReq.RequestBody
+
+	if t == nil {
+		return
+	}
+
+	tb, err := pb.tagBindings(reflect.TypeOf(t))
+
+	if err != nil {
+		pb.FrameworkLogger.LogErrorf("Unable to bind tagged fields: %s", err.Error())
+		return
+	}
+
+	if qb := tb[QueryBinding]; len(qb) > 0 && wsReq.QueryParams != nil {
+		pb.bindNamed(wsReq, wsReq.QueryParams, qb, pb.queryParamError)
+	}
+
+	if nb := tb[PathBinding]; len(nb) > 0 && wsReq.NamedPathParams != nil {
+		pb.BindNamedPathParameters(wsReq, nb)
+	}
+
+	if hb := tb[HeaderBinding]; len(hb) > 0 {
+		pb.BindHeaders(wsReq, NewWsParamsForHeaders(req.Header), hb)
+	}
+
+	if cb := tb[CookieBinding]; len(cb) > 0 {
+		pb.BindCookies(wsReq, NewWsParamsForCookies(req.Cookies()), cb)
+	}
+}
+
+// Returns the (cached) tag bindings for the supplied type.
+func (pb *ParamBinder) tagBindings(t reflect.Type) (tagBindings, error) {
+
+	if tb, found := pb.tagCache.Load(t); found {
+		return tb.(tagBindings), nil
+	}
+
+	tb, err := parseTagBindings(t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pb.tagCache.Store(t, tb)
+
+	return tb, nil
+}
+
+// IsBindableType returns true if a field of the supplied type can have string parameters (query and path parameters,
+// headers etc) bound into it.
+func IsBindableType(t reflect.Type) bool {
+
+	if isBindableValue(t) || (t.Kind() == reflect.Slice && isBindableValue(t.Elem())) {
+		return true
+	}
+
+	switch reflect.Zero(t).Interface().(type) {
+	case *types.NilableString, *types.NilableBool, *types.NilableInt64, *types.NilableFloat64:
+		return true
+	}
+
+	return false
+}
+
+// Returns true if a single string value can be converted to the supplied type (see convertValue)
+func isBindableValue(t reflect.Type) bool {
+
+	if t == timeType || t == durationType {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Bool, reflect.String:
+		return true
+	}
+
+	return false
+}
+
+// HasFieldPath returns true if the supplied pointer to a struct has an exported field at the supplied dot-delimited path
+// (e.g. Filter.Status) where each step of the path before the last is a struct or a pointer to a struct.
+func HasFieldPath(t interface{}, path string) bool {
+	_, found := fieldPathType(t, path)
+
+	return found
+}
+
+// Finds the type of the field at the end of a dot-delimited path on the supplied pointer to a struct.
+func fieldPathType(t interface{}, path string) (reflect.Type, bool) {
+	return typeAtPath(reflect.TypeOf(t).Elem(), path)
+}
+
+// Finds the type of the field at the end of a dot-delimited path on the supplied struct type.
+func typeAtPath(ct reflect.Type, path string) (reflect.Type, bool) {
+
+	steps := rt.ExtractDotPath(path)
+
+	for i, name := range steps {
+
+		if ct.Kind() == reflect.Ptr {
+			ct = ct.Elem()
+		}
+
+		if ct.Kind() != reflect.Struct {
+			return nil, false
+		}
+
+		f, found := ct.FieldByName(name)
+
+		if !found || f.PkgPath != "" {
+			return nil, false
+		}
+
+		if i == len(steps)-1 {
+			return f.Type, true
+		}
+
+		ct = f.Type
+	}
+
+	return nil, false
+}
+
+// Finds the struct containing the field at the end of a dot-delimited path on the supplied pointer to a struct, allocating
+// any nil pointers to structs along the path. Returns a pointer to that struct and the name of the field.
+func fieldPathParent(t interface{}, path string) (interface{}, string) {
+
+	steps := rt.ExtractDotPath(path)
+	last := len(steps) - 1
+	v := reflect.ValueOf(t)
+
+	for _, name := range steps[:last] {
+
+		f := v.Elem().FieldByName(name)
+
+		if f.Kind() == reflect.Struct {
+			v = f.Addr()
+			continue
+		}
+
+		if f.IsNil() {
+			f.Set(reflect.New(f.Type().Elem()))
+		}
+
+		v = f
+	}
+
+	return v.Interface(), steps[last]
+}
+
+func (pb *ParamBinder) bindNamed(wsReq *WsRequest, p *WsParams, targets map[string]string, errorFn bindError) {
+
+	t := wsReq.RequestBody
+
+	for field, param := range targets {
+
+		if !HasFieldPath(t, field) {
+			pb.FrameworkLogger.LogWarnf("No field %s exists on a target object to bind %s into.", field, param)
+			continue
+		}
+
+		if p.Exists(param) {
+			pb.FrameworkLogger.LogTracef("Binding %s to field %s", param, field)
+
+			fErr := pb.bindValueToPath(param, field, p, t, errorFn)
+
+			if fErr != nil {
+				wsReq.AddFrameworkError(fErr)
+			} else {
+				wsReq.RecordFieldAsBound(field)
+			}
+		}
+	}
+
+	pb.initialiseUnsetNilables(t)
+}
+
 func (pb *ParamBinder) initialiseUnsetNilables(t interface{}) {
 
 	vt := reflect.ValueOf(t).Elem()
@@ -176,14 +500,67 @@ func (pb *ParamBinder) pathParamError(paramName string, fieldName string, typeNa
 
 }
 
+func (pb *ParamBinder) formParamError(paramName string, fieldName string, typeName string, p *WsParams) *WsFrameworkError {
+
+	var v = ""
+
+	if p.Exists(paramName) {
+		v, _ = p.StringValue(paramName)
+	}
+
+	m, c := pb.FrameworkErrors.MessageCode(FormWrongType, paramName, typeName, v)
+	return NewFormBindFrameworkError(m, c, paramName, fieldName)
+
+}
+
+func (pb *ParamBinder) headerError(paramName string, fieldName string, typeName string, p *WsParams) *WsFrameworkError {
+
+	v, _ := p.StringValue(paramName)
+
+	m, c := pb.FrameworkErrors.MessageCode(HeaderWrongType, paramName, typeName, v)
+	return NewHeaderBindFrameworkError(m, c, paramName, fieldName)
+}
+
+func (pb *ParamBinder) cookieError(paramName string, fieldName string, typeName string, p *WsParams) *WsFrameworkError {
+
+	v, _ := p.StringValue(paramName)
+
+	m, c := pb.FrameworkErrors.MessageCode(CookieWrongType, paramName, typeName, v)
+	return NewCookieBindFrameworkError(m, c, paramName, fieldName)
+}
+
+// Binds a parameter into the field at the end of a dot-delimited path (see HasFieldPath)
+func (pb *ParamBinder) bindValueToPath(paramName string, fieldPath string, p *WsParams, t interface{}, errorFn bindError) *WsFrameworkError {
+
+	parent, fieldName := fieldPathParent(t, fieldPath)
+
+	fErr := pb.bindValueToField(paramName, fieldName, p, parent, errorFn)
+
+	if fErr != nil {
+		fErr.TargetField = fieldPath
+	}
+
+	return fErr
+}
+
 func (pb *ParamBinder) bindValueToField(paramName string, fieldName string, p *WsParams, t interface{}, errorFn bindError) *WsFrameworkError {
 
+	ft := rt.TypeOfField(t, fieldName)
+
+	if ft.Kind() == reflect.Slice {
+		return pb.setSliceField(paramName, fieldName, p, t, errorFn)
+	}
+
 	if !rt.TargetFieldIsArray(t, fieldName) && p.MultipleValues(paramName) {
 		m, c := pb.FrameworkErrors.MessageCode(QueryTargetNotArray, fieldName)
 		return NewQueryBindFrameworkError(m, c, paramName, fieldName)
 	}
 
-	switch rt.TypeOfField(t, fieldName).Kind() {
+	if ft == timeType || ft == durationType {
+		return pb.setConvertedField(paramName, fieldName, p, t, errorFn)
+	}
+
+	switch ft.Kind() {
 	case reflect.Int:
 		return pb.setIntNField(paramName, fieldName, p, t, 0, errorFn)
 	case reflect.Int8:
@@ -273,6 +650,158 @@ func (pb *ParamBinder) setNilableField(paramName string, fieldName string, p *Ws
 	return e
 }
 
+// Binds every value of a parameter (split on ValueSeparator if set) into a slice field. Slices of types that cannot be bound
+// (e.g. []*FilePart) are ignored.
+func (pb *ParamBinder) setSliceField(paramName string, fieldName string, p *WsParams, t interface{}, errorFn bindError) *WsFrameworkError {
+
+	ft := rt.TypeOfField(t, fieldName)
+
+	if !isBindableValue(ft.Elem()) {
+		return nil
+	}
+
+	values := pb.splitValues(p.values[paramName])
+	s := reflect.MakeSlice(ft, 0, len(values))
+
+	for _, v := range values {
+
+		ev, err := pb.convertValue(v, ft.Elem())
+
+		if err != nil {
+			return errorFn(paramName, fieldName, ft.String(), singleValueParams(paramName, v))
+		}
+
+		s = reflect.Append(s, ev)
+	}
+
+	rt.FieldValue(t, fieldName).Set(s)
+
+	return nil
+}
+
+// Binds a single value into a field of a type not handled by the WsParams conversion functions (time.Time or time.Duration).
+func (pb *ParamBinder) setConvertedField(paramName string, fieldName string, p *WsParams, t interface{}, errorFn bindError) *WsFrameworkError {
+
+	ft := rt.TypeOfField(t, fieldName)
+	s, _ := p.StringValue(paramName)
+
+	v, err := pb.convertValue(s, ft)
+
+	if err != nil {
+		return errorFn(paramName, fieldName, ft.String(), p)
+	}
+
+	rt.FieldValue(t, fieldName).Set(v)
+
+	return nil
+}
+
+// Splits each of the supplied values on ValueSeparator (if set), discarding empty elements.
+func (pb *ParamBinder) splitValues(values []string) []string {
+
+	split := make([]string, 0, len(values))
+
+	for _, v := range values {
+
+		parts := []string{v}
+
+		if pb.ValueSeparator != "" {
+			parts = strings.Split(v, pb.ValueSeparator)
+		}
+
+		for _, part := range parts {
+
+			if part = strings.TrimSpace(part); part != "" {
+				split = append(split, part)
+			}
+		}
+	}
+
+	return split
+}
+
+// Converts a single string value to the supplied type, which must be one of the types accepted by isBindableValue.
+func (pb *ParamBinder) convertValue(s string, t reflect.Type) (reflect.Value, error) {
+
+	v := reflect.New(t).Elem()
+
+	switch t {
+	case timeType:
+		tm, err := pb.parseTime(s)
+		v.Set(reflect.ValueOf(tm))
+
+		return v, err
+
+	case durationType:
+		d, err := time.ParseDuration(s)
+		v.SetInt(int64(d))
+
+		return v, err
+	}
+
+	var err error
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		i, err = strconv.ParseInt(s, 10, t.Bits())
+		v.SetInt(i)
+
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		u, err = strconv.ParseUint(s, 10, t.Bits())
+		v.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(s, t.Bits())
+		v.SetFloat(f)
+
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(s)
+		v.SetBool(b)
+
+	case reflect.String:
+		v.SetString(s)
+
+	default:
+		err = errors.New(fmt.Sprintf("Cannot bind a value into a %v", t))
+	}
+
+	return v, err
+}
+
+// Parses a time using each of TimeLayouts (or DefaultTimeLayouts) in turn, returning the error from the last layout
+// tried if none match.
+func (pb *ParamBinder) parseTime(s string) (time.Time, error) {
+
+	layouts := pb.TimeLayouts
+
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts
+	}
+
+	var err error
+
+	for _, layout := range layouts {
+
+		var tm time.Time
+
+		if tm, err = time.Parse(layout, s); err == nil {
+			return tm, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+// Creates a WsParams holding a single value so errors can report the element of a multi-valued parameter that could not
+// be converted.
+func singleValueParams(name string, value string) *WsParams {
+	return NewWsParamsForQuery(url.Values{name: {value}})
+}
+
 func (pb *ParamBinder) setStringField(paramName string, fieldName string, qp *WsParams, t interface{}, errorFn bindError) *WsFrameworkError {
 	s, err := qp.StringValue(paramName)
 
@@ -8,8 +8,12 @@ import (
 	"github.com/graniticio/granitic/logging"
 	"github.com/graniticio/granitic/test"
 	"github.com/graniticio/granitic/types"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestQueryAutoBinding(t *testing.T) {
@@ -336,6 +340,62 @@ func TestMorePathValuesThanTargets(t *testing.T) {
 
 }
 
+func TestPathBindingErrors(t *testing.T) {
+
+	pb := createParamBinder()
+	pb.FrameworkErrors.Messages[PathWrongType] = []string{"PATHBIND", "Unable to convert path parameter (group %s) to %s (%s)"}
+
+	req := new(WsRequest)
+	req.RequestBody = new(BindingTarget)
+
+	pb.BindPathParameters(req, NewWsParamsForPath([]string{"S", "I"}, []string{"s", "one"}))
+
+	fe := req.FrameworkErrors
+	test.ExpectInt(t, len(fe), 1)
+	test.ExpectInt(t, fe[0].Position, 1)
+	test.ExpectString(t, fe[0].TargetField, "I")
+	test.ExpectString(t, fe[0].Message, "Unable to convert path parameter (group 1) to int (one)")
+}
+
+func TestNamedPathBinding(t *testing.T) {
+
+	names := []string{"name", "", "id", "flag"}
+	values := []string{"s", "ignored", "12", "nope"}
+
+	bt := new(BindingTarget)
+
+	pb := createParamBinder()
+	pb.FrameworkErrors.Messages[PathWrongType] = []string{"PATHBIND", "Unable to convert path parameter %s to %s (%s)"}
+
+	req := new(WsRequest)
+	req.RequestBody = bt
+	req.NamedPathParams = NewWsParamsForNamedPath(names, values)
+
+	pb.BindNamedPathParameters(req, map[string]string{"S": "name", "I": "id", "B": "flag", "Missing": "id"})
+
+	test.ExpectString(t, bt.S, "s")
+	test.ExpectInt(t, bt.I, 12)
+
+	fe := req.FrameworkErrors
+	test.ExpectInt(t, len(fe), 1)
+	test.ExpectString(t, fe[0].Code, "PATHBIND")
+	test.ExpectString(t, fe[0].ClientField, "flag")
+	test.ExpectString(t, fe[0].TargetField, "B")
+	test.ExpectString(t, fe[0].Message, "Unable to convert path parameter flag to bool (nope)")
+
+	bt = new(BindingTarget)
+
+	req = new(WsRequest)
+	req.RequestBody = bt
+	req.NamedPathParams = NewWsParamsForNamedPath([]string{"S", "I64", "other"}, []string{"a", "64", "b"})
+
+	pb.AutoBindNamedPathParameters(req)
+
+	test.ExpectInt(t, len(req.FrameworkErrors), 0)
+	test.ExpectString(t, bt.S, "a")
+	test.ExpectInt(t, int(bt.I64), 64)
+}
+
 func printErrs(errs []*WsFrameworkError) {
 
 	for _, e := range errs {
@@ -368,3 +428,233 @@ type InvalidTargets struct {
 type InvalidTargetField struct{}
 
 type InvalidInterface interface{}
+
+type headerTarget struct {
+	Tenant  string
+	Version int
+	Session *types.NilableString
+	Missing *types.NilableInt64
+}
+
+func TestHeaderAndCookieBinding(t *testing.T) {
+
+	h := make(http.Header)
+	h.Add("x-tenant-id", "t1")
+	h.Add("X-Tenant-Id", "t2")
+	h.Add("X-Version", "v2")
+
+	c := []*http.Cookie{{Name: "session", Value: "abc"}, {Name: "session", Value: "def"}}
+
+	pb := createParamBinder()
+	ht := new(headerTarget)
+
+	req := new(WsRequest)
+	req.RequestBody = ht
+
+	pb.BindHeaders(req, NewWsParamsForHeaders(h), map[string]string{"Tenant": "x-tenant-id", "Version": "X-Version"})
+	pb.BindCookies(req, NewWsParamsForCookies(c), map[string]string{"Session": "session"})
+
+	test.ExpectString(t, ht.Tenant, "t1")
+	test.ExpectString(t, ht.Session.String(), "abc")
+	test.ExpectBool(t, ht.Missing.IsSet(), false)
+
+	test.ExpectBool(t, req.WasFieldBound("Tenant"), true)
+	test.ExpectBool(t, req.WasFieldBound("Version"), false)
+
+	test.ExpectInt(t, len(req.FrameworkErrors), 1)
+
+	fe := req.FrameworkErrors[0]
+	test.ExpectInt(t, int(fe.Phase), HeaderBind)
+	test.ExpectString(t, fe.ClientField, "X-Version")
+	test.ExpectString(t, fe.TargetField, "Version")
+}
+
+type taggedBindingTarget struct {
+	ID       int                  `ws:"path=id"`
+	PageSize *types.NilableInt64  `ws:"query=page_size"`
+	Sort     string               `ws:"query=sort,header=X-Sort"`
+	Tenant   string               `ws:"header=X-Tenant-Id"`
+	Session  *types.NilableString `ws:"cookie=session"`
+	Untagged string
+}
+
+func TestBindTaggedParameters(t *testing.T) {
+
+	v, _ := url.ParseQuery("page_size=20&Untagged=u")
+
+	req := httptest.NewRequest("GET", "/artist/7", nil)
+	req.Header.Set("X-Tenant-Id", "acme")
+	req.Header.Set("X-Sort", "name")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+
+	bt := new(taggedBindingTarget)
+
+	wsReq := new(WsRequest)
+	wsReq.RequestBody = bt
+	wsReq.QueryParams = NewWsParamsForQuery(v)
+	wsReq.NamedPathParams = NewWsParamsForNamedPath([]string{"id"}, []string{"7"})
+
+	pb := createParamBinder()
+	pb.BindTaggedParameters(wsReq, req)
+
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 0)
+	test.ExpectInt(t, bt.ID, 7)
+	test.ExpectInt(t, int(bt.PageSize.Int64()), 20)
+	test.ExpectString(t, bt.Sort, "name")
+	test.ExpectString(t, bt.Tenant, "acme")
+	test.ExpectString(t, bt.Session.String(), "s1")
+	test.ExpectString(t, bt.Untagged, "")
+
+	test.ExpectBool(t, wsReq.WasFieldBound("PageSize"), true)
+
+	v, _ = url.ParseQuery("page_size=big")
+	wsReq = &WsRequest{RequestBody: new(taggedBindingTarget), QueryParams: NewWsParamsForQuery(v)}
+
+	pb.BindTaggedParameters(wsReq, req)
+
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 1)
+	test.ExpectInt(t, int(wsReq.FrameworkErrors[0].Phase), QueryBind)
+}
+
+func TestBindNestedTaggedParameters(t *testing.T) {
+
+	v, _ := url.ParseQuery("status=open&page_size=20&cursor=abc")
+
+	req := httptest.NewRequest("GET", "/owner/9", nil)
+
+	nt := new(nestedTagTarget)
+
+	wsReq := new(WsRequest)
+	wsReq.RequestBody = nt
+	wsReq.QueryParams = NewWsParamsForQuery(v)
+	wsReq.NamedPathParams = NewWsParamsForNamedPath([]string{"owner"}, []string{"9"})
+
+	pb := createParamBinder()
+	pb.BindTaggedParameters(wsReq, req)
+
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 0)
+	test.ExpectString(t, nt.Filter.Status, "open")
+	test.ExpectInt(t, int(nt.Filter.Owner), 9)
+	test.ExpectInt(t, nt.Page.Size, 20)
+	test.ExpectString(t, nt.Page.Cursor.String(), "abc")
+	test.ExpectBool(t, nt.Page.Next == nil, true)
+
+	test.ExpectBool(t, wsReq.WasFieldBound("Page.Size"), true)
+}
+
+type sliceTarget struct {
+	Tags   []string
+	IDs    []int64
+	Scores []float32
+	Flags  []bool
+	Since  time.Time
+	Within time.Duration
+	Dates  []time.Time
+	Filter sliceFilter
+	Page   *slicePage
+}
+
+type sliceFilter struct {
+	Status string
+	Owners []uint16
+}
+
+type slicePage struct {
+	Size   int
+	Cursor *types.NilableString
+}
+
+func TestSliceAndNestedQueryBinding(t *testing.T) {
+
+	q := "Tags=a&Tags=b,c&IDs=1,%202&Scores=0.5&Flags=true,false&Since=2018-03-01T10:00:00Z&Within=90s&Dates=2018-01-01,2018-02-01" +
+		"&Filter.Status=open&Filter.Owners=3&Filter.Owners=4&Page.Size=20"
+
+	v, _ := url.ParseQuery(q)
+
+	pb := createParamBinder()
+	pb.ValueSeparator = ","
+
+	st := new(sliceTarget)
+
+	req := new(WsRequest)
+	req.QueryParams = NewWsParamsForQuery(v)
+	req.RequestBody = st
+
+	pb.AutoBindQueryParameters(req)
+
+	printErrs(req.FrameworkErrors)
+	test.ExpectInt(t, len(req.FrameworkErrors), 0)
+
+	test.ExpectBool(t, reflect.DeepEqual(st.Tags, []string{"a", "b", "c"}), true)
+	test.ExpectBool(t, reflect.DeepEqual(st.IDs, []int64{1, 2}), true)
+	test.ExpectBool(t, reflect.DeepEqual(st.Scores, []float32{0.5}), true)
+	test.ExpectBool(t, reflect.DeepEqual(st.Flags, []bool{true, false}), true)
+
+	test.ExpectBool(t, st.Since.Equal(time.Date(2018, 3, 1, 10, 0, 0, 0, time.UTC)), true)
+	test.ExpectInt(t, int(st.Within), int(90*time.Second))
+	test.ExpectInt(t, len(st.Dates), 2)
+	test.ExpectInt(t, int(st.Dates[1].Month()), int(time.February))
+
+	test.ExpectString(t, st.Filter.Status, "open")
+	test.ExpectBool(t, reflect.DeepEqual(st.Filter.Owners, []uint16{3, 4}), true)
+
+	test.ExpectNotNil(t, st.Page)
+	test.ExpectInt(t, st.Page.Size, 20)
+
+	test.ExpectBool(t, req.WasFieldBound("Filter.Status"), true)
+	test.ExpectBool(t, req.WasFieldBound("Page.Size"), true)
+
+	pb.ValueSeparator = ""
+	pb.TimeLayouts = []string{"02/01/2006"}
+
+	v, _ = url.ParseQuery("Tags=a,b&Since=01/03/2018")
+	st = new(sliceTarget)
+	req = &WsRequest{QueryParams: NewWsParamsForQuery(v), RequestBody: st}
+
+	pb.AutoBindQueryParameters(req)
+
+	test.ExpectInt(t, len(req.FrameworkErrors), 0)
+	test.ExpectBool(t, reflect.DeepEqual(st.Tags, []string{"a,b"}), true)
+	test.ExpectInt(t, int(st.Since.Month()), int(time.March))
+	test.ExpectBool(t, st.Page == nil, true)
+}
+
+func TestSliceAndNestedQueryBindingErrors(t *testing.T) {
+
+	v, _ := url.ParseQuery("IDs=1,x,3&Within=soon&Since=yesterday&Filter.Status=a&Filter.Status=b")
+
+	pb := createParamBinder()
+	pb.ValueSeparator = ","
+	pb.FrameworkErrors.Messages = map[FrameworkErrorEvent][]string{
+		QueryWrongType:      {"QUERYBIND", "%s %s %s"},
+		QueryTargetNotArray: {"QUERYBIND", "%s"},
+	}
+
+	req := new(WsRequest)
+	req.QueryParams = NewWsParamsForQuery(v)
+	req.RequestBody = new(sliceTarget)
+
+	pb.BindQueryParameters(req, map[string]string{"IDs": "IDs", "Within": "Within", "Since": "Since", "Filter.Status": "Filter.Status"})
+
+	errs := make(map[string]*WsFrameworkError)
+
+	for _, fe := range req.FrameworkErrors {
+		errs[fe.TargetField] = fe
+	}
+
+	test.ExpectInt(t, len(errs), 4)
+	test.ExpectString(t, errs["IDs"].Message, "IDs []int64 x")
+	test.ExpectString(t, errs["Within"].Message, "Within time.Duration soon")
+	test.ExpectString(t, errs["Since"].Message, "Since time.Time yesterday")
+	test.ExpectNotNil(t, errs["Filter.Status"])
+
+	test.ExpectBool(t, HasFieldPath(new(sliceTarget), "Filter.Status"), true)
+	test.ExpectBool(t, HasFieldPath(new(sliceTarget), "Page.Cursor"), true)
+	test.ExpectBool(t, HasFieldPath(new(sliceTarget), "Filter.Missing"), false)
+	test.ExpectBool(t, HasFieldPath(new(sliceTarget), "Tags.Len"), false)
+	test.ExpectBool(t, HasFieldPath(new(sliceTarget), "Since.wall"), false)
+
+	test.ExpectBool(t, IsBindableType(reflect.TypeOf([]uint8{})), true)
+	test.ExpectBool(t, IsBindableType(reflect.TypeOf(time.Second)), true)
+	test.ExpectBool(t, IsBindableType(reflect.TypeOf([]*types.NilableString{})), false)
+}
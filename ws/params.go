@@ -6,6 +6,7 @@ package ws
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strconv"
 )
@@ -35,6 +36,30 @@ func NewWsParamsForPath(targets []string, values []string) *WsParams {
 
 }
 
+// NewWsParamsForNamedPath creates a WsParams used to store the elements of a request path extracted using named
+// regular expression groups (or the parameters of a path template). names and values are in the order the groups appear
+// in the expression and values with an empty name (unnamed groups or optional groups that were not matched) are discarded.
+func NewWsParamsForNamedPath(names []string, values []string) *WsParams {
+
+	contents := make(url.Values)
+	v := len(values)
+	var paramNames []string
+
+	for i, n := range names {
+
+		if n != "" && i < v {
+			contents[n] = []string{values[i]}
+			paramNames = append(paramNames, n)
+		}
+	}
+
+	p := new(WsParams)
+	p.values = contents
+	p.paramNames = paramNames
+
+	return p
+}
+
 // NewWsParamsForQuery creates a WsParams storing the HTTP query parameters from a request.
 func NewWsParamsForQuery(values url.Values) *WsParams {
 
@@ -53,6 +78,38 @@ func NewWsParamsForQuery(values url.Values) *WsParams {
 
 }
 
+// NewWsParamsForHeaders creates a WsParams storing the headers of an HTTP request. Parameter names are canonical header
+// names (see http.CanonicalHeaderKey) and only the first value of each header is stored.
+func NewWsParamsForHeaders(headers http.Header) *WsParams {
+
+	contents := make(url.Values)
+
+	for k, v := range headers {
+
+		if len(v) > 0 {
+			contents[http.CanonicalHeaderKey(k)] = v[:1]
+		}
+	}
+
+	return NewWsParamsForQuery(contents)
+}
+
+// NewWsParamsForCookies creates a WsParams storing the cookies sent with an HTTP request. If more than one cookie has
+// the same name, only the first is stored.
+func NewWsParamsForCookies(cookies []*http.Cookie) *WsParams {
+
+	contents := make(url.Values)
+
+	for _, c := range cookies {
+
+		if contents[c.Name] == nil {
+			contents[c.Name] = []string{c.Value}
+		}
+	}
+
+	return NewWsParamsForQuery(contents)
+}
+
 // An abstraction of the HTTP query parameters or path parameters with type-safe accessors.
 type WsParams struct {
 	values     url.Values
@@ -80,3 +80,20 @@ func TestStringValues(t *testing.T) {
 	}
 
 }
+
+func TestNamedPathParams(t *testing.T) {
+
+	p := NewWsParamsForNamedPath([]string{"", "id", "rest"}, []string{"x", "12"})
+
+	if len(p.ParamNames()) != 1 || p.ParamNames()[0] != "id" {
+		t.Errorf("Expected only the named parameter with a value to be stored")
+	}
+
+	if i, err := p.IntNValue("id", 64); err != nil || i != 12 {
+		t.Errorf("Expected id to be 12")
+	}
+
+	if p.Exists("rest") || p.Exists("0") {
+		t.Errorf("Did not expect unnamed or missing groups to be present")
+	}
+}
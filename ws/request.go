@@ -5,8 +5,10 @@ package ws
 
 import (
 	"context"
+	"errors"
 	"github.com/graniticio/granitic/iam"
 	"github.com/graniticio/granitic/types"
+	"io"
 	"net/http"
 )
 
@@ -19,12 +21,20 @@ type WsRequest struct {
 	// then RequestBody will contain a struct representation of the request body.
 	RequestBody interface{}
 
+	// If the handler that generated this WsRequest streams request bodies (see handler.WsHandler.StreamBody), a reader
+	// of the HTTP request's body. Reading beyond the maximum size allowed for the body returns an error (see IsBodyTooLarge).
+	BodyReader io.Reader
+
 	// A copy of the HTTP query parameters from the underlying HTTP request with type-safe accessors.
 	QueryParams *WsParams
 
 	// Information extracted from the path portion of the HTTP request using regular expression groups with type-safe accessors.
 	PathParams []string
 
+	// Information extracted from the path portion of the HTTP request using named regular expression groups (or path
+	// template parameters), accessed by the name of the group.
+	NamedPathParams *WsParams
+
 	// Problems encountered during the parsing and binding phases of request processing.
 	FrameworkErrors []*WsFrameworkError
 	populatedFields types.StringSet
@@ -40,6 +50,15 @@ type WsRequest struct {
 	ServingHandler string
 }
 
+// IsBodyTooLarge returns true if the supplied error was caused by reading more of an HTTP request's body than is allowed
+// (see httpserver.HttpServer.MaxRequestBodyBytes and handler.WsHandler.MaxBodyBytes).
+func IsBodyTooLarge(err error) bool {
+
+	var mbe *http.MaxBytesError
+
+	return errors.As(err, &mbe)
+}
+
 // HasFrameworkErrors returns true if one or more framework errors have been recorded.
 func (wsr *WsRequest) HasFrameworkErrors() bool {
 	return len(wsr.FrameworkErrors) > 0
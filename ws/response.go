@@ -206,6 +206,39 @@ type ResponseWrapper interface {
 	WrapResponse(body interface{}, errors interface{}) interface{}
 }
 
+type contextHeadersKey struct{}
+
+// AddContextHeaders returns a copy of the supplied context carrying the supplied headers (in addition to any already
+// carried by the context). Response writers merge these headers into the response (see CommonHeaders), which allows an
+// httpendpoint.HttpFilter (such as the HttpServer facility's CORS support) to add headers to responses it does not write.
+func AddContextHeaders(ctx context.Context, headers map[string]string) context.Context {
+
+	merged := MergeHeaders(&WsResponse{Headers: headers}, nil, ContextHeaders(ctx))
+
+	return context.WithValue(ctx, contextHeadersKey{}, merged)
+}
+
+// ContextHeaders returns the headers added to the supplied context with AddContextHeaders (or nil).
+func ContextHeaders(ctx context.Context) map[string]string {
+
+	h, _ := ctx.Value(contextHeadersKey{}).(map[string]string)
+
+	return h
+}
+
+// CommonHeaders returns the headers carried by the context (see AddContextHeaders) merged with, and overridden by, the
+// headers constructed by the supplied builder (which may be nil).
+func CommonHeaders(ctx context.Context, state *WsProcessState, hb WsCommonResponseHeaderBuilder) map[string]string {
+
+	var built map[string]string
+
+	if hb != nil {
+		built = hb.BuildHeaders(ctx, state)
+	}
+
+	return MergeHeaders(&WsResponse{Headers: built}, nil, ContextHeaders(ctx))
+}
+
 // Merges together the headers that have been defined on the WsResponse, the static default headers attache to this writer
 // and (optionally) those constructed by the  ws.WsCommonResponseHeaderBuilder attached to this writer. The order of precedence,
 // from lowest to highest, is static headers, constructed headers, headers in the WsResponse.
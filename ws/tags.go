@@ -0,0 +1,192 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// The name of a Go tag on the fields of a request body struct that declares where the field's value should be bound
+	// from, e.g. `ws:"query=page_size"`. More than one source can be declared, separated by commas, e.g.
+	// `ws:"path=id,header=X-Id"`. Tags on the fields of nested structs (and pointers to structs) are also honoured.
+	BindingTag = "ws"
+
+	// The source in a BindingTag for values bound from HTTP query parameters.
+	QueryBinding = "query"
+
+	// The source in a BindingTag for values bound from named path parameters (named regular expression groups or path
+	// template parameters).
+	PathBinding = "path"
+
+	// The source in a BindingTag for values bound from HTTP request headers.
+	HeaderBinding = "header"
+
+	// The source in a BindingTag for values bound from HTTP cookies.
+	CookieBinding = "cookie"
+)
+
+// The bindings declared by BindingTags on a type, keyed by source then by field name (or dot-delimited path for fields
+// of nested structs).
+type tagBindings map[string]map[string]string
+
+// TagBindings examines the fields of the supplied struct (or pointer to a struct), and the fields of any nested structs or
+// pointers to structs, for BindingTags and returns a map of the names of fields to the names of the parameters they should
+// be bound from for the supplied source (e.g. QueryBinding). Fields of nested structs are named with a dot-delimited path
+// (e.g. Filter.Status). Returns an error if a tag is malformed or declares an unknown source.
+func TagBindings(target interface{}, source string) (map[string]string, error) {
+
+	tb, err := parseTagBindings(reflect.TypeOf(target))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tb[source] == nil {
+		return make(map[string]string), nil
+	}
+
+	return tb[source], nil
+}
+
+// CheckTagBindings verifies that the BindingTags on the fields of the supplied struct (or pointer to a struct) are well
+// formed, are on fields of a type that can be bound (see IsBindableType) and, for path bindings, name one of the supplied
+// path parameters.
+func CheckTagBindings(target interface{}, pathParams []string) error {
+
+	t := reflect.TypeOf(target)
+
+	tb, err := parseTagBindings(t)
+
+	if err != nil {
+		return err
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	known := make(map[string]bool)
+
+	for _, p := range pathParams {
+		known[p] = true
+	}
+
+	for source, fields := range tb {
+
+		for field, name := range fields {
+
+			ft, _ := typeAtPath(t, field)
+
+			if !IsBindableType(ft) {
+				return errors.New(fmt.Sprintf("Field %s is tagged to be bound from %s %s, but fields of type %v cannot be bound", field, source, name, ft))
+			}
+
+			if source == PathBinding && !known[name] {
+				return errors.New(fmt.Sprintf("Field %s is tagged to be bound from path parameter %s, but there is no group or template parameter with that name", field, name))
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseTagBindings(t reflect.Type) (tagBindings, error) {
+
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New(fmt.Sprintf("Cannot find %s tags on a %v", BindingTag, t))
+	}
+
+	tb := make(tagBindings)
+
+	if err := addTagBindings(tb, t, "", map[reflect.Type]bool{t: true}); err != nil {
+		return nil, err
+	}
+
+	return tb, nil
+}
+
+// Records the BindingTags on the fields of the supplied struct type, following untagged, exported fields that are structs
+// or pointers to structs. Fields are recorded with the supplied prefix (the path to the struct). visiting contains the
+// types on the current path, so that recursive types are not followed indefinitely.
+func addTagBindings(tb tagBindings, t reflect.Type, prefix string, visiting map[reflect.Type]bool) error {
+
+	for i := 0; i < t.NumField(); i++ {
+
+		f := t.Field(i)
+		path := prefix + f.Name
+		tag, found := f.Tag.Lookup(BindingTag)
+
+		if !found {
+
+			nt := f.Type
+
+			if nt.Kind() == reflect.Ptr {
+				nt = nt.Elem()
+			}
+
+			if f.PkgPath != "" || nt.Kind() != reflect.Struct || visiting[nt] {
+				continue
+			}
+
+			visiting[nt] = true
+			err := addTagBindings(tb, nt, path+".", visiting)
+			delete(visiting, nt)
+
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		sources, err := parseBindingTag(tag)
+
+		if err != nil {
+			return errors.New(fmt.Sprintf("Field %s: %s", path, err.Error()))
+		}
+
+		for source, name := range sources {
+
+			if tb[source] == nil {
+				tb[source] = make(map[string]string)
+			}
+
+			tb[source][path] = name
+		}
+	}
+
+	return nil
+}
+
+// Converts a tag like header=X-Foo,cookie=foo into a map of sources to parameter names
+func parseBindingTag(tag string) (map[string]string, error) {
+
+	sources := make(map[string]string)
+
+	for _, part := range strings.Split(tag, ",") {
+
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, errors.New(fmt.Sprintf("%s tag element '%s' must be in the form source=name", BindingTag, part))
+		}
+
+		switch kv[0] {
+		case QueryBinding, PathBinding, HeaderBinding, CookieBinding:
+			sources[kv[0]] = kv[1]
+		default:
+			return nil, errors.New(fmt.Sprintf("Unknown %s tag source '%s'", BindingTag, kv[0]))
+		}
+	}
+
+	return sources, nil
+}
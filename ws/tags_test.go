@@ -0,0 +1,134 @@
+// Copyright 2018 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"github.com/graniticio/granitic/test"
+	"github.com/graniticio/granitic/types"
+	"strings"
+	"testing"
+)
+
+type taggedTarget struct {
+	Tenant  string `ws:"header=X-Tenant-Id"`
+	Session string `ws:"cookie=session, header=X-Session"`
+	Plain   string `json:"plain"`
+}
+
+type badTag struct {
+	A string `ws:"header"`
+}
+
+type unknownSource struct {
+	A string `ws:"body=a"`
+}
+
+func TestTagBindings(t *testing.T) {
+
+	h, err := TagBindings(new(taggedTarget), HeaderBinding)
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(h), 2)
+	test.ExpectString(t, h["Tenant"], "X-Tenant-Id")
+	test.ExpectString(t, h["Session"], "X-Session")
+
+	c, err := TagBindings(taggedTarget{}, CookieBinding)
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(c), 1)
+	test.ExpectString(t, c["Session"], "session")
+
+	_, err = TagBindings(new(badTag), HeaderBinding)
+	test.ExpectNotNil(t, err)
+
+	_, err = TagBindings(new(unknownSource), HeaderBinding)
+	test.ExpectNotNil(t, err)
+
+	_, err = TagBindings("string", HeaderBinding)
+	test.ExpectNotNil(t, err)
+}
+
+type checkedTarget struct {
+	ID       int                  `ws:"path=id"`
+	PageSize *types.NilableInt64  `ws:"query=page_size"`
+	Tags     map[string]string    `ws:"query=tags"`
+	Session  *types.NilableString `ws:"cookie=session"`
+}
+
+type checkedPathTarget struct {
+	ID   int    `ws:"path=id"`
+	Name string `ws:"path=name,query=name"`
+}
+
+func TestCheckTagBindings(t *testing.T) {
+
+	err := CheckTagBindings(new(checkedTarget), []string{"", "id"})
+	test.ExpectNotNil(t, err)
+	test.ExpectBool(t, strings.Contains(err.Error(), "Tags"), true)
+
+	test.ExpectNil(t, CheckTagBindings(new(checkedPathTarget), []string{"", "id", "name"}))
+
+	err = CheckTagBindings(new(checkedPathTarget), []string{"", "id"})
+	test.ExpectNotNil(t, err)
+	test.ExpectBool(t, strings.Contains(err.Error(), "name"), true)
+}
+
+type nestedTagTarget struct {
+	Filter  nestedTagFilter
+	Page    *nestedTagPage
+	Tenant  string `ws:"header=X-Tenant-Id"`
+	private nestedTagFilter
+}
+
+type nestedTagFilter struct {
+	Status string `ws:"query=status"`
+	Owner  int64  `ws:"query=owner,path=owner"`
+}
+
+type nestedTagPage struct {
+	Size   int                  `ws:"query=page_size"`
+	Cursor *types.NilableString `ws:"query=cursor"`
+	Next   *nestedTagPage
+}
+
+type nestedUnbindable struct {
+	Filter struct {
+		Tags map[string]string `ws:"query=tags"`
+	}
+}
+
+type nestedBadTag struct {
+	Page *struct {
+		Size int `ws:"query"`
+	}
+}
+
+func TestNestedTagBindings(t *testing.T) {
+
+	q, err := TagBindings(new(nestedTagTarget), QueryBinding)
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(q), 4)
+	test.ExpectString(t, q["Filter.Status"], "status")
+	test.ExpectString(t, q["Filter.Owner"], "owner")
+	test.ExpectString(t, q["Page.Size"], "page_size")
+	test.ExpectString(t, q["Page.Cursor"], "cursor")
+
+	h, _ := TagBindings(new(nestedTagTarget), HeaderBinding)
+	test.ExpectInt(t, len(h), 1)
+
+	test.ExpectNil(t, CheckTagBindings(new(nestedTagTarget), []string{"", "owner"}))
+
+	err = CheckTagBindings(new(nestedTagTarget), []string{""})
+	test.ExpectNotNil(t, err)
+	test.ExpectBool(t, strings.Contains(err.Error(), "Filter.Owner"), true)
+
+	err = CheckTagBindings(new(nestedUnbindable), []string{""})
+	test.ExpectNotNil(t, err)
+	test.ExpectBool(t, strings.Contains(err.Error(), "Filter.Tags"), true)
+
+	_, err = TagBindings(new(nestedBadTag), QueryBinding)
+	test.ExpectNotNil(t, err)
+	test.ExpectBool(t, strings.Contains(err.Error(), "Page.Size"), true)
+}
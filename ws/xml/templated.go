@@ -52,11 +52,7 @@ type TemplatedXmlResponseWriter struct {
 
 // See WsResponseWriter.Write
 func (rw *TemplatedXmlResponseWriter) Write(ctx context.Context, state *ws.WsProcessState, outcome ws.WsOutcome) error {
-	var ch map[string]string
-
-	if rw.HeaderBuilder != nil {
-		ch = rw.HeaderBuilder.BuildHeaders(ctx, state)
-	}
+	ch := ws.CommonHeaders(ctx, state, rw.HeaderBuilder)
 
 	switch outcome {
 	case ws.Normal:
@@ -18,12 +18,13 @@ type StandardXmlUnmarshaller struct {
 // Unmarshall decodes XML into a Go struct using Go's builtin xml.Unmarshal method.
 func (um *StandardXmlUnmarshaller) Unmarshall(ctx context.Context, req *http.Request, wsReq *ws.WsRequest) error {
 
-	var b bytes.Buffer
-	b.ReadFrom(req.Body)
+	defer req.Body.Close()
 
-	err := xml.Unmarshal(b.Bytes(), &wsReq.RequestBody)
+	var b bytes.Buffer
 
-	req.Body.Close()
+	if _, err := b.ReadFrom(req.Body); err != nil {
+		return err
+	}
 
-	return err
+	return xml.Unmarshal(b.Bytes(), &wsReq.RequestBody)
 }